@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(catCmd)
+}
+
+var catCmd = &cobra.Command{
+	Use:   "cat input.epub <href|id>",
+	Short: "Print a resource of the book",
+	Long: `Prints the content of a resource to stdout. The resource is a manifest item id,
+an href relative to the OPF, or a full path in the container
+(e.g. META-INF/container.xml).`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile, ref := args[0], args[1]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		data, err := readResource(ep, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Stdout.Write(data)
+	},
+}
+
+// readResource resolves ref as a manifest id, an OPF-relative href, then a
+// container path.
+func readResource(ep *epub.Reader, ref string) ([]byte, error) {
+	for _, item := range ep.Package.Manifest.Items {
+		if item.ID == ref {
+			rc, err := ep.OpenItem(ref)
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+	}
+	if data, err := ep.ReadItem(ref); err == nil {
+		return data, nil
+	}
+	if data, err := ep.ReadFile(ref); err == nil {
+		return data, nil
+	}
+	return nil, fmt.Errorf("no manifest item, href or file %q", ref)
+}
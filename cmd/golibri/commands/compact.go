@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var compactOutput string
+
+func init() {
+	compactCmd.Flags().StringVarP(&compactOutput, "output", "o", "", "Output file path (default: modify in-place)")
+
+	rootCmd.AddCommand(compactCmd)
+}
+
+var compactCmd = &cobra.Command{
+	Use:   "compact [flags] input.epub",
+	Short: "Rewrite a book saved with --append to reclaim space",
+	Long: `Rewrites the archive without the superseded entries that "golibri meta --append"
+leaves behind. A book whose append was interrupted is recovered from its last
+complete central directory.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		outputPath := compactOutput
+		if outputPath == "" {
+			outputPath = inputFile
+		}
+
+		before, err := os.Stat(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(1)
+		}
+		if err := epub.Compact(inputFile, outputPath); err != nil {
+			fmt.Printf("Error compacting %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		after, err := os.Stat(outputPath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Compacted %d -> %d bytes. Saved to %s\n", before.Size(), after.Size(), outputPath)
+	},
+}
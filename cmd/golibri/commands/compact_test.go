@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestMetaAppendAndCompact(t *testing.T) {
+	inputPath := createTestEPUB(t)
+	defer os.Remove(inputPath)
+	original, _ := os.ReadFile(inputPath)
+
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "--append", "-t", "Appended", inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute meta command: %v", err)
+	}
+	appended, _ := os.ReadFile(inputPath)
+	if len(appended) <= len(original) || !bytes.HasPrefix(appended, original) {
+		t.Fatal("Expected the changes to be appended to the original bytes")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "compacted.epub")
+	compactOutput = ""
+	rootCmd.SetArgs([]string{"compact", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute compact command: %v", err)
+	}
+
+	compacted, _ := os.ReadFile(outputPath)
+	if len(compacted) >= len(appended) {
+		t.Errorf("Expected compaction to shrink %d bytes, got %d", len(appended), len(compacted))
+	}
+	for _, path := range []string{inputPath, outputPath} {
+		ep, err := epub.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", path, err)
+		}
+		if got := ep.Package.GetTitle(); got != "Appended" {
+			t.Errorf("Expected title Appended in %s, got %q", path, got)
+		}
+		ep.Close()
+	}
+}
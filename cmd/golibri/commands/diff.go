@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffJSON    bool
+	diffContent bool
+)
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Output the differences in JSON format")
+	diffCmd.Flags().BoolVar(&diffContent, "content", false, "Include a unified diff of every changed XHTML file")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+// DiffJSON is the JSON output of golibri diff.
+type DiffJSON struct {
+	Package []epub.Change  `json:"package"`
+	Files   []FileDiffJSON `json:"files"`
+}
+
+// FileDiffJSON is a changed file; Diff is only filled with --content.
+type FileDiffJSON struct {
+	epub.FileChange
+	Diff string `json:"diff,omitempty"`
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [flags] a.epub b.epub",
+	Short: "Compare the metadata, manifest, spine and files of two EPUBs",
+	Long: `Compares two books: metadata field by field, manifest items added, removed or
+changed (href, media type, properties), the spine order, and every file by the
+CRC-32 and size in the zip headers. With --content, changed XHTML files are
+shown as unified diffs.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var books [2]*epub.Reader
+		for i, inputFile := range args {
+			ep, err := epub.Open(inputFile)
+			if err != nil {
+				fmt.Printf("Error opening %s: %v\n", inputFile, err)
+				os.Exit(exitCode(err))
+			}
+			defer ep.Close()
+			books[i] = ep
+		}
+
+		d, err := books[0].Diff(books[1])
+		if err != nil {
+			fmt.Printf("Error comparing: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		out := DiffJSON{Package: d.Package}
+		for _, fc := range d.Files {
+			file := FileDiffJSON{FileChange: fc}
+			if diffContent && isXHTMLPath(fc.Path) {
+				file.Diff, err = contentDiff(books[0], books[1], fc)
+				if err != nil {
+					fmt.Printf("Error reading %s: %v\n", fc.Path, err)
+					os.Exit(exitCode(err))
+				}
+			}
+			out.Files = append(out.Files, file)
+		}
+
+		if diffJSON {
+			if out.Package == nil {
+				out.Package = []epub.Change{}
+			}
+			if out.Files == nil {
+				out.Files = []FileDiffJSON{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			}
+			return
+		}
+		printDiff(out)
+	},
+}
+
+func printDiff(d DiffJSON) {
+	if len(d.Package) == 0 && len(d.Files) == 0 {
+		fmt.Println("No differences")
+		return
+	}
+	if len(d.Package) > 0 {
+		fmt.Println("Package:")
+		for _, c := range d.Package {
+			fmt.Printf("  %s: %q -> %q\n", c.Field, c.Old, c.New)
+		}
+	}
+	if len(d.Files) > 0 {
+		fmt.Println("Files:")
+		for _, f := range d.Files {
+			switch f.Status {
+			case epub.FileAdded:
+				fmt.Printf("  A %s (%d bytes)\n", f.Path, f.NewSize)
+			case epub.FileRemoved:
+				fmt.Printf("  D %s (%d bytes)\n", f.Path, f.OldSize)
+			default:
+				fmt.Printf("  M %s (crc32 %08x -> %08x, %d -> %d bytes)\n", f.Path, f.OldCRC32, f.NewCRC32, f.OldSize, f.NewSize)
+			}
+		}
+	}
+	for _, f := range d.Files {
+		if f.Diff != "" {
+			fmt.Println()
+			fmt.Print(f.Diff)
+		}
+	}
+}
+
+// contentDiff returns the unified diff of a changed file; a missing side is
+// compared as empty.
+func contentDiff(a, b *epub.Reader, fc epub.FileChange) (string, error) {
+	var before, after []byte
+	var err error
+	if fc.Status != epub.FileAdded {
+		if before, err = a.ReadFile(fc.Path); err != nil {
+			return "", err
+		}
+	}
+	if fc.Status != epub.FileRemoved {
+		if after, err = b.ReadFile(fc.Path); err != nil {
+			return "", err
+		}
+	}
+	return unifiedDiff("a/"+fc.Path, "b/"+fc.Path, before, after), nil
+}
+
+func isXHTMLPath(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xhtml", ".html", ".htm":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestDiffCommand(t *testing.T) {
+	base := createTestEPUB(t)
+	defer os.Remove(base)
+	dir := t.TempDir()
+
+	// v1 adds a chapter, v2 revises it and retitles the book
+	save := func(from, to string, edit func(ep *epub.Reader)) {
+		ep, err := epub.Open(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ep.Close()
+		edit(ep)
+		if err := ep.Save(to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v1 := filepath.Join(dir, "v1.epub")
+	save(base, v1, func(ep *epub.Reader) {
+		ep.AddResource("ch1.xhtml", "application/xhtml+xml", []byte("<html>\n<p>one</p>\n</html>\n"), "")
+	})
+	v2 := filepath.Join(dir, "v2.epub")
+	save(v1, v2, func(ep *epub.Reader) {
+		ep.Package.SetTitle("Resent Book")
+		ep.ReplaceResource(ep.Package.Manifest.Items[0].ID, []byte("<html>\n<p>two</p>\n</html>\n"))
+	})
+
+	run := func(args ...string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		diffJSON, diffContent = false, false
+		rootCmd.SetArgs(append([]string{"diff"}, args...))
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	out := run("--content", v1, v2)
+	for _, want := range []string{
+		`title: "JSON Test Book" -> "Resent Book"`,
+		"  M ch1.xhtml (crc32 ",
+		"--- a/ch1.xhtml\n+++ b/ch1.xhtml\n",
+		"-<p>one</p>\n+<p>two</p>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	if out := run(v1, v1); strings.TrimSpace(out) != "No differences" {
+		t.Errorf("Expected no differences, got:\n%s", out)
+	}
+
+	var d DiffJSON
+	if err := json.Unmarshal([]byte(run("--json", base, v1)), &d); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	var added bool
+	for _, f := range d.Files {
+		if f.Path == "ch1.xhtml" && f.Status == epub.FileAdded {
+			added = true
+		}
+	}
+	if !added {
+		t.Errorf("Expected ch1.xhtml to be added, got %+v", d.Files)
+	}
+	var manifestAdded bool
+	for _, c := range d.Package {
+		if strings.HasPrefix(c.Field, "manifest.") && c.Old == "" && strings.HasPrefix(c.New, "ch1.xhtml application/xhtml+xml") {
+			manifestAdded = true
+		}
+	}
+	if !manifestAdded {
+		t.Errorf("Expected a manifest addition, got %+v", d.Package)
+	}
+}
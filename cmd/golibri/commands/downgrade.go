@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var downgradeOutput string
+
+func init() {
+	downgradeCmd.Flags().StringVarP(&downgradeOutput, "output", "o", "", "Output file path (default: modify in-place)")
+
+	rootCmd.AddCommand(downgradeCmd)
+}
+
+var downgradeCmd = &cobra.Command{
+	Use:   "downgrade [flags] input.epub",
+	Short: "Convert an EPUB 3 book to EPUB 2 for legacy readers",
+	Long: `Sets the package version to 2.0, generates toc.ncx from the navigation document,
+builds a guide from landmarks, converts refines meta to opf:role/file-as/scheme
+attributes and series collections to calibre:series, and strips EPUB 3-only
+manifest and spine properties.
+
+Features EPUB 2 cannot represent (media overlays, fixed layout, right-to-left
+page progression, HTML5 content documents) are reported as warnings.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		warnings, err := epub.DowngradeToEPUB2(ep)
+		if err != nil {
+			fmt.Printf("Error downgrading: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		for _, w := range warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+
+		outputPath := downgradeOutput
+		if outputPath == "" {
+			outputPath = inputFile
+		}
+
+		if err := ep.Save(outputPath); err != nil {
+			fmt.Printf("Error saving EPUB: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		fmt.Printf("Downgraded to EPUB 2. Saved to %s\n", outputPath)
+	},
+}
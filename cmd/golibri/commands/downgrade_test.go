@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestDowngradeCommand(t *testing.T) {
+	inputPath := createEPUB3WithMultipleAuthors(t)
+	defer os.Remove(inputPath)
+
+	outputPath := filepath.Join(t.TempDir(), "downgraded.epub")
+
+	downgradeOutput = ""
+	rootCmd.SetArgs([]string{"downgrade", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute downgrade command: %v", err)
+	}
+
+	ep, err := epub.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	if ep.Package.Version != "2.0" {
+		t.Errorf("Expected version 2.0, got %s", ep.Package.Version)
+	}
+	if c := ep.Package.Metadata.Creators[1]; c.Role != "aut" || c.FileAs != "Li, Si" {
+		t.Errorf("Expected opf attributes on creator, got %+v", c)
+	}
+	if ep.Package.GetSeries() != "测试系列" {
+		t.Errorf("Expected series to be kept, got %q", ep.Package.GetSeries())
+	}
+	if ep.Package.Spine.Toc == "" {
+		t.Error("NCX not referenced from the spine")
+	}
+}
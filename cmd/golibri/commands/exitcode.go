@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+// Exit codes of golibri. Failures caused by the input file get a distinct
+// code so that scripts can tell a broken book from a broken run.
+const (
+	exitFailure       = 1 // any other error
+	exitNotZip        = 2 // epub.ErrNotZip
+	exitNoContainer   = 3 // epub.ErrNoContainer
+	exitNoRootfile    = 4 // epub.ErrNoRootfile
+	exitMalformedOPF  = 5 // epub.ErrMalformedOPF
+	exitNoCover       = 6 // epub.ErrNoCover
+	exitLimitExceeded = 7 // epub.ErrLimitExceeded
+	exitUnsafeArchive = 8 // epub.ErrUnsafePath, epub.ErrDuplicateEntry
+)
+
+// exitCode maps an error to the golibri exit code.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, epub.ErrNotZip):
+		return exitNotZip
+	case errors.Is(err, epub.ErrNoContainer):
+		return exitNoContainer
+	case errors.Is(err, epub.ErrNoRootfile):
+		return exitNoRootfile
+	case errors.Is(err, epub.ErrMalformedOPF):
+		return exitMalformedOPF
+	case errors.Is(err, epub.ErrNoCover):
+		return exitNoCover
+	case errors.Is(err, epub.ErrLimitExceeded):
+		return exitLimitExceeded
+	case errors.Is(err, epub.ErrUnsafePath), errors.Is(err, epub.ErrDuplicateEntry):
+		return exitUnsafeArchive
+	}
+	return exitFailure
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("other"), exitFailure},
+		{fmt.Errorf("failed to open zip reader: %w", epub.ErrNotZip), exitNotZip},
+		{fmt.Errorf("failed to parse container: %w", epub.ErrNoContainer), exitNoContainer},
+		{epub.ErrNoRootfile, exitNoRootfile},
+		{&epub.MalformedOPFError{Path: "content.opf", Line: 3, Column: 5, Err: errors.New("syntax")}, exitMalformedOPF},
+		{fmt.Errorf("no cover found in EPUB: %w", epub.ErrNoCover), exitNoCover},
+		{&epub.LimitError{Limit: "entries", Value: 2, Max: 1}, exitLimitExceeded},
+		{&epub.UnsafePathError{Path: "../x", Source: "zip entry"}, exitUnsafeArchive},
+		{epub.ErrDuplicateEntry, exitUnsafeArchive},
+	}
+	seen := make(map[int]bool)
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+		seen[tt.want] = true
+	}
+	if len(seen) != 8 {
+		t.Errorf("Expected 8 distinct exit codes, got %d", len(seen))
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var extractOutput string
+
+func init() {
+	extractCmd.Flags().StringVarP(&extractOutput, "output", "o", "", "Output directory (default: the book name without .epub)")
+
+	rootCmd.AddCommand(extractCmd)
+}
+
+var extractCmd = &cobra.Command{
+	Use:   "extract [flags] input.epub",
+	Short: "Unpack the archive into a directory",
+	Long: `Unpacks every file of the archive. Entries with absolute paths or paths that
+escape the output directory are refused before anything is written.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		outputDir := extractOutput
+		if outputDir == "" {
+			outputDir = strings.TrimSuffix(inputFile, filepath.Ext(inputFile))
+		}
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		if err := ep.Extract(outputDir); err != nil {
+			fmt.Printf("Error extracting: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		fmt.Printf("Extracted to %s\n", outputDir)
+	},
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fontsDeobfuscate bool
+	fontsObfuscate   bool
+	fontsAlgorithm   string
+	fontsOutput      string
+)
+
+func init() {
+	fontsCmd.Flags().BoolVar(&fontsDeobfuscate, "deobfuscate", false, "Remove font obfuscation and drop the entries from encryption.xml")
+	fontsCmd.Flags().BoolVar(&fontsObfuscate, "obfuscate", false, "Obfuscate every font that is not obfuscated yet")
+	fontsCmd.Flags().StringVar(&fontsAlgorithm, "algorithm", "idpf", "Obfuscation algorithm for --obfuscate (idpf, adobe)")
+	fontsCmd.Flags().StringVarP(&fontsOutput, "output", "o", "", "Output file path (default: modify in-place)")
+
+	rootCmd.AddCommand(fontsCmd)
+}
+
+var fontsCmd = &cobra.Command{
+	Use:   "fonts [flags] input.epub",
+	Short: "List, obfuscate or deobfuscate embedded fonts",
+	Long: `Lists the fonts of a book with their obfuscation. With --deobfuscate, IDPF and
+Adobe font obfuscation is removed; with --obfuscate, the remaining fonts are
+obfuscated with the key derived from the book identifier.
+
+Obfuscated fonts are re-obfuscated automatically whenever a command changes the
+identifier their key is derived from.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		if fontsDeobfuscate && fontsObfuscate {
+			fmt.Println("Error: --deobfuscate and --obfuscate are mutually exclusive")
+			os.Exit(1)
+		}
+		algorithm := ""
+		switch fontsAlgorithm {
+		case "idpf":
+			algorithm = epub.AlgorithmIDPFObfuscation
+		case "adobe":
+			algorithm = epub.AlgorithmAdobeObfuscation
+		default:
+			fmt.Printf("Error: unknown algorithm %q (use idpf or adobe)\n", fontsAlgorithm)
+			os.Exit(1)
+		}
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		if !fontsDeobfuscate && !fontsObfuscate {
+			fonts, err := ep.Fonts()
+			if err != nil {
+				fmt.Printf("Error reading fonts: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			if len(fonts) == 0 {
+				fmt.Println("No fonts")
+				return
+			}
+			for _, font := range fonts {
+				status := string(font.Encryption)
+				if status == "" {
+					status = "plain"
+				}
+				fmt.Printf("%-20s %s (%s)\n", status, font.Path, font.MediaType)
+			}
+			return
+		}
+
+		if ep.IsDRMProtected() {
+			fmt.Printf("Error: %s is DRM-protected; fonts cannot be changed\n", inputFile)
+			os.Exit(1)
+		}
+
+		var n int
+		var action string
+		if fontsDeobfuscate {
+			n, err = ep.DeobfuscateFonts()
+			action = "Deobfuscated"
+		} else {
+			n, err = ep.ObfuscateFonts(algorithm)
+			action = "Obfuscated"
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		if n == 0 {
+			fmt.Println("No fonts to change")
+			return
+		}
+
+		outputPath := fontsOutput
+		if outputPath == "" {
+			outputPath = inputFile
+		}
+
+		if err := ep.Save(outputPath); err != nil {
+			fmt.Printf("Error saving EPUB: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		fmt.Printf("%s %d font(s). Saved to %s\n", action, n, outputPath)
+	},
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+// testFontData returns fake font data longer than the obfuscated prefix
+func testFontData() []byte {
+	data := make([]byte, 1500)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	return data
+}
+
+// Helper to create an EPUB whose unique identifier is the ISBN, with an IDPF
+// obfuscated font
+func createFontEPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-fonts-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	obfuscated, err := epub.ObfuscateFont(testFontData(), epub.AlgorithmIDPFObfuscation, epub.IDPFObfuscationKey("9787020002207"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"META-INF/encryption.xml", `<?xml version="1.0"?><encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#"><enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"/><enc:CipherData><enc:CipherReference URI="font.otf"/></enc:CipherData></enc:EncryptedData></encryption>`},
+		{"content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="isbn_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>Font Test Book</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="isbn_id" opf:scheme="ISBN">9787020002207</dc:identifier>
+  </metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="font" href="font.otf" media-type="application/vnd.ms-opentype"/>
+    <item id="font2" href="font2.ttf" media-type="font/ttf"/>
+  </manifest>
+  <spine>
+    <itemref idref="ch1"/>
+  </spine>
+</package>`},
+		{"ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Text</p></body></html>`},
+		{"font.otf", string(obfuscated)},
+		{"font2.ttf", string(testFontData())},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.content)
+	}
+
+	return f.Name()
+}
+
+func resetFontsFlags() {
+	fontsDeobfuscate = false
+	fontsObfuscate = false
+	fontsAlgorithm = "idpf"
+	fontsOutput = ""
+}
+
+// checkFontsReadable opens path and checks that every font reads back as the
+// original data, returning the number of obfuscated fonts
+func checkFontsReadable(t *testing.T, path string) int {
+	t.Helper()
+	ep, err := epub.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	for _, name := range []string{"font.otf", "font2.ttf"} {
+		data, err := ep.ReadFont(name)
+		if err != nil {
+			t.Fatalf("ReadFont(%s) failed: %v", name, err)
+		}
+		if !bytes.Equal(data, testFontData()) {
+			t.Errorf("Font %s is not readable", name)
+		}
+	}
+	info, err := ep.Encryption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(info.ObfuscatedFonts())
+}
+
+func TestFontsCommand(t *testing.T) {
+	inputPath := createFontEPUB(t)
+	defer os.Remove(inputPath)
+
+	obfuscatedPath := filepath.Join(t.TempDir(), "obfuscated.epub")
+	resetFontsFlags()
+	rootCmd.SetArgs([]string{"fonts", "--obfuscate", "-o", obfuscatedPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute fonts --obfuscate: %v", err)
+	}
+	if n := checkFontsReadable(t, obfuscatedPath); n != 2 {
+		t.Errorf("Expected 2 obfuscated fonts, got %d", n)
+	}
+
+	plainPath := filepath.Join(t.TempDir(), "plain.epub")
+	resetFontsFlags()
+	rootCmd.SetArgs([]string{"fonts", "--deobfuscate", "-o", plainPath, obfuscatedPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute fonts --deobfuscate: %v", err)
+	}
+	if n := checkFontsReadable(t, plainPath); n != 0 {
+		t.Errorf("Expected no obfuscated font, got %d", n)
+	}
+}
+
+func TestMetaISBNRekeysFonts(t *testing.T) {
+	inputPath := createFontEPUB(t)
+	defer os.Remove(inputPath)
+
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "--isbn", "9787111111111", inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute meta --isbn: %v", err)
+	}
+	if n := checkFontsReadable(t, inputPath); n != 1 {
+		t.Errorf("Expected the font to stay obfuscated, got %d", n)
+	}
+
+	ep, err := epub.Open(inputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Close()
+	if ep.Package.GetISBN() != "9787111111111" {
+		t.Errorf("ISBN not updated: %q", ep.Package.GetISBN())
+	}
+}
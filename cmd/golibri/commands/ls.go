@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var lsJSON bool
+
+func init() {
+	lsCmd.Flags().BoolVar(&lsJSON, "json", false, "Output the entries in JSON format")
+
+	rootCmd.AddCommand(lsCmd)
+}
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [flags] input.epub",
+	Short: "List the zip entries with their manifest and spine information",
+	Long: `Lists every file of the archive with its size, compression method and CRC-32,
+and for manifest items their id, media type and spine position. Files that no
+manifest lists are flagged as orphaned.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		entries, err := ep.Entries()
+		if err != nil {
+			fmt.Printf("Error listing entries: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		if lsJSON {
+			if entries == nil {
+				entries = []epub.FileEntry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entries); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			}
+			return
+		}
+
+		fmt.Printf("%10s  %-8s %-8s  %5s  %-16s %-24s %s\n", "SIZE", "METHOD", "CRC32", "SPINE", "ID", "MEDIA-TYPE", "PATH")
+		orphans := 0
+		var total uint64
+		for _, e := range entries {
+			spine := "-"
+			if e.SpinePosition > 0 {
+				spine = fmt.Sprint(e.SpinePosition)
+			}
+			id, mediaType := e.ManifestID, e.MediaType
+			if id == "" {
+				id, mediaType = "-", "-"
+			}
+			fmt.Printf("%10d  %-8s %08x  %5s  %-16s %-24s %s", e.Size, e.Method, e.CRC32, spine, id, mediaType, e.Path)
+			if e.Orphan {
+				fmt.Print("  [orphan]")
+				orphans++
+			}
+			fmt.Println()
+			total += e.Size
+		}
+		fmt.Printf("%d files, %d bytes, %d orphaned\n", len(entries), total, orphans)
+	},
+}
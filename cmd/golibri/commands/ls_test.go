@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestArchiveCommands(t *testing.T) {
+	base := createTestEPUB(t)
+	defer os.Remove(base)
+
+	inputPath := filepath.Join(t.TempDir(), "book.epub")
+	ep, err := epub.Open(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ep.AddResource("Text/ch1.xhtml", "application/xhtml+xml", []byte("<html>chapter</html>"), ""); err != nil {
+		t.Fatal(err)
+	}
+	ep.Package.Spine.ItemRefs = append(ep.Package.Spine.ItemRefs, epub.ItemRef{IDRef: ep.Package.Manifest.Items[0].ID})
+	if err := ep.Save(inputPath); err != nil {
+		t.Fatal(err)
+	}
+	ep.Close()
+	id := ep.Package.Manifest.Items[0].ID
+
+	run := func(args ...string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		lsJSON, extractOutput = false, ""
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	out := run("ls", inputPath)
+	if !strings.Contains(out, "Text/ch1.xhtml") || !strings.Contains(out, id) {
+		t.Errorf("Expected the chapter in the listing, got:\n%s", out)
+	}
+	if !strings.Contains(out, "0 orphaned") {
+		t.Errorf("Expected no orphans, got:\n%s", out)
+	}
+
+	var entries []epub.FileEntry
+	if err := json.Unmarshal([]byte(run("ls", "--json", inputPath)), &entries); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	found := false
+	for _, e := range entries {
+		if e.Path == "Text/ch1.xhtml" {
+			found = e.ManifestID == id && e.SpinePosition == 1
+		}
+	}
+	if !found {
+		t.Errorf("Expected the chapter at spine position 1, got %+v", entries)
+	}
+
+	for _, ref := range []string{id, "Text/ch1.xhtml"} {
+		if got := run("cat", inputPath, ref); got != "<html>chapter</html>" {
+			t.Errorf("cat %s: got %q", ref, got)
+		}
+	}
+	if got := run("cat", inputPath, "META-INF/container.xml"); !strings.Contains(got, "<container") {
+		t.Errorf("Expected container.xml, got %q", got)
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	run("extract", "-o", dir, inputPath)
+	if data, err := os.ReadFile(filepath.Join(dir, "Text", "ch1.xhtml")); err != nil || string(data) != "<html>chapter</html>" {
+		t.Errorf("Unexpected extracted chapter %q: %v", data, err)
+	}
+}
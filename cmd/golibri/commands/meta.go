@@ -24,6 +24,7 @@ var (
 	metaIdentifiers []string
 	metaJSON        bool
 	metaGetCover    string // Export cover to file
+	metaRemoveCover bool
 	// New write flags
 	metaPublisher   string
 	metaDate        string
@@ -32,6 +33,22 @@ var (
 	metaComments    string
 	metaSeriesIndex string
 	metaRating      int
+	// Multi-rendition books
+	metaRendition     int
+	metaAllRenditions bool
+	// Signed books
+	metaStripSignatures bool
+	metaVerbose         bool
+	// Huge books
+	metaAppend bool
+	// Reproducible output
+	metaDeterministic bool
+	metaMTime         string
+	// Previews
+	metaDryRun  bool
+	metaDiffOPF bool
+	// Archives with mismatched name case
+	metaCaseInsensitive bool
 )
 
 func init() {
@@ -45,6 +62,7 @@ func init() {
 	metaCmd.Flags().StringVarP(&metaOutput, "output", "o", "", "Output file path (default: modify in-place)")
 	metaCmd.Flags().BoolVar(&metaJSON, "json", false, "Output metadata in JSON format (compatible with ebook-meta)")
 	metaCmd.Flags().StringVar(&metaGetCover, "get-cover", "", "Export cover image to specified file")
+	metaCmd.Flags().BoolVar(&metaRemoveCover, "remove-cover", false, "Remove the cover image and all references to it")
 	// New write flags
 	metaCmd.Flags().StringVar(&metaPublisher, "publisher", "", "Set publisher")
 	metaCmd.Flags().StringVar(&metaDate, "date", "", "Set publication date")
@@ -53,6 +71,16 @@ func init() {
 	metaCmd.Flags().StringVar(&metaComments, "comments", "", "Set description/comments")
 	metaCmd.Flags().StringVar(&metaSeriesIndex, "series-index", "", "Set series index")
 	metaCmd.Flags().IntVar(&metaRating, "rating", -1, "Set rating (0-5, Calibre extension)")
+	metaCmd.Flags().IntVar(&metaRendition, "rendition", -1, "Rendition (rootfile index) to read or modify in multi-rendition books")
+	metaCmd.Flags().BoolVar(&metaAllRenditions, "all-renditions", false, "Apply metadata changes to every rendition (cover changes apply to the selected one)")
+	metaCmd.Flags().BoolVarP(&metaVerbose, "verbose", "v", false, "Report the problems tolerated while parsing the book and verify its signatures")
+	metaCmd.Flags().BoolVar(&metaDryRun, "dry-run", false, "Print the metadata changes without writing the file")
+	metaCmd.Flags().BoolVar(&metaDiffOPF, "diff-opf", false, "Print a unified diff of the OPF without writing the file")
+	metaCmd.Flags().BoolVar(&metaAppend, "append", false, "Save in place by appending the changed entries instead of rewriting the file (see golibri compact)")
+	metaCmd.Flags().BoolVar(&metaDeterministic, "deterministic", false, "Write byte-identical output for the same book and changes (fixed timestamps, sorted new entries)")
+	metaCmd.Flags().StringVar(&metaMTime, "mtime", "", "Timestamp for --deterministic (RFC 3339; default: $SOURCE_DATE_EPOCH or 1980-01-01)")
+	metaCmd.Flags().BoolVar(&metaStripSignatures, "strip-signatures", false, "Remove the signatures in META-INF/signatures.xml that the changes invalidate")
+	metaCmd.Flags().BoolVar(&metaCaseInsensitive, "case-insensitive", false, "Match file names in the archive case-insensitively when an href's case differs")
 
 	rootCmd.AddCommand(metaCmd)
 }
@@ -64,38 +92,77 @@ var metaCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		inputFile := args[0]
 
-		ep, err := epub.Open(inputFile)
+		// Read Mode - check if any write flag is set
+		isWriteMode := metaTitle != "" || metaAuthor != "" || metaSeries != "" || metaCover != "" || metaRemoveCover ||
+			metaISBN != "" || metaASIN != "" || len(metaIdentifiers) > 0 ||
+			metaPublisher != "" || metaDate != "" || metaLanguage != "" ||
+			metaTags != "" || metaComments != "" || metaSeriesIndex != "" || metaRating >= 0
+
+		// Reading only needs the metadata: the manifest is loaded if the
+		// cover has to be exported or --verbose checks the whole book
+		ep, err := openMeta(inputFile, !isWriteMode)
 		if err != nil {
 			fmt.Printf("Error opening %s: %v\n", inputFile, err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
 		}
 		defer ep.Close()
 
+		// Warn on stderr so that --json output stays parseable
+		if ep.IsDRMProtected() {
+			fmt.Fprintf(os.Stderr, "Warning: %s is DRM-protected (%s); content is encrypted and changes may invalidate the license\n", inputFile, drmScheme(ep))
+		}
+
 		// Extract cover mode
 		if metaGetCover != "" {
 			if err := extractCover(ep, metaGetCover); err != nil {
 				fmt.Printf("Error extracting cover: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitCode(err))
 			}
 			fmt.Printf("Cover exported to %s\n", metaGetCover)
 			return
 		}
 
-		// Read Mode - check if any write flag is set
-		isWriteMode := metaTitle != "" || metaAuthor != "" || metaSeries != "" || metaCover != "" ||
-			metaISBN != "" || metaASIN != "" || len(metaIdentifiers) > 0 ||
-			metaPublisher != "" || metaDate != "" || metaLanguage != "" ||
-			metaTags != "" || metaComments != "" || metaSeriesIndex != "" || metaRating >= 0
 		if !isWriteMode {
+			if metaVerbose {
+				if err := ep.LoadPackage(); err != nil {
+					fmt.Printf("Error reading %s: %v\n", inputFile, err)
+					os.Exit(exitCode(err))
+				}
+			}
 			if metaJSON {
 				printMetadataJSON(ep)
 			} else {
 				printMetadata(ep)
+				if metaVerbose {
+					printWarnings(ep)
+				}
 			}
 			return
 		}
 
 		// Write Mode
+		if metaAppend && metaOutput != "" {
+			fmt.Println("Error: --append modifies the file in place and cannot be used with --output")
+			os.Exit(1)
+		}
+		if metaAppend && metaDeterministic {
+			fmt.Println("Error: --append cannot be used with --deterministic")
+			os.Exit(1)
+		}
+		if metaMTime != "" && !metaDeterministic {
+			fmt.Println("Error: --mtime requires --deterministic")
+			os.Exit(1)
+		}
+		saveOpts := epub.SaveOptions{Deterministic: metaDeterministic}
+		if metaDeterministic {
+			t, err := deterministicTime(metaMTime)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			saveOpts.ModTime = t
+		}
+
 		// If no output specified, modify in-place
 		outputPath := metaOutput
 		if outputPath == "" {
@@ -104,12 +171,33 @@ var metaCmd = &cobra.Command{
 
 		if err := applyChanges(ep); err != nil {
 			fmt.Printf("Error applying changes: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
 		}
 
-		if err := ep.Save(outputPath); err != nil {
+		if metaDryRun || metaDiffOPF {
+			if err := previewChanges(ep, inputFile); err != nil {
+				fmt.Printf("Error previewing changes: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		}
+
+		if err := checkSignatures(ep); err != nil {
+			fmt.Printf("Error checking signatures: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		save := func() error { return ep.SaveWithOptions(outputPath, saveOpts) }
+		if metaAppend {
+			save = ep.SaveAppend
+		}
+		if err := save(); err != nil {
 			fmt.Printf("Error saving EPUB: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
+		}
+
+		if metaVerbose && !metaJSON {
+			printWarnings(ep)
 		}
 
 		if metaJSON {
@@ -137,6 +225,9 @@ type MetadataJSON struct {
 	Producer    string            `json:"producer,omitempty"`
 	Comments    string            `json:"comments,omitempty"`
 	Cover       bool              `json:"cover"`
+	DRM         string            `json:"drm,omitempty"`
+	// Warnings is only filled with --verbose
+	Warnings []epub.Warning `json:"warnings,omitempty"`
 }
 
 func printMetadataJSON(ep *epub.Reader) {
@@ -161,10 +252,12 @@ func printMetadataJSON(ep *epub.Reader) {
 		meta.Authors = []string{}
 	}
 
-	// Check cover
-	_, _, err := ep.GetCoverImage()
-	if err == nil {
-		meta.Cover = true
+	meta.Cover = ep.HasCover()
+	if ep.IsDRMProtected() {
+		meta.DRM = drmScheme(ep)
+	}
+	if metaVerbose {
+		meta.Warnings = ep.Warnings()
 	}
 
 	enc := json.NewEncoder(os.Stdout)
@@ -258,12 +351,134 @@ func printMetadata(ep *epub.Reader) {
 		}
 	}
 
-	_, _, err := ep.GetCoverImage()
-	if err == nil {
+	if ep.HasCover() {
 		fmt.Println("Cover:       Found")
 	} else {
 		fmt.Println("Cover:       Not Found")
 	}
+
+	// Encryption: DRM or obfuscated fonts
+	if info, err := ep.Encryption(); err != nil {
+		fmt.Printf("Encryption:  unreadable encryption.xml (%v)\n", err)
+	} else if info.DRM != "" {
+		fmt.Printf("Encryption:  DRM (%s), %d encrypted resources\n", info.DRM, len(info.Resources))
+	} else if fonts := info.ObfuscatedFonts(); len(fonts) > 0 {
+		fmt.Printf("Encryption:  %d obfuscated fonts (%s)\n", len(fonts), fonts[0].Type)
+	}
+
+	// Digital signatures: count references whose digest does not verify.
+	// Verifying hashes every signed resource, so only with --verbose.
+	if metaVerbose {
+		printSignatures(ep)
+	}
+
+	// Multi-rendition books: list every rootfile, marking the one shown
+	if len(ep.RootFiles) > 1 {
+		fmt.Println("Renditions:")
+		for i, rf := range ep.RootFiles {
+			marker := " "
+			if rf.FullPath == ep.OpfPath {
+				marker = "*"
+			}
+			var attrs []string
+			for _, a := range [][2]string{{"layout", rf.Layout}, {"media", rf.Media}, {"language", rf.Language},
+				{"accessMode", rf.AccessMode}, {"label", rf.Label}} {
+				if a[1] != "" {
+					attrs = append(attrs, a[0]+"="+a[1])
+				}
+			}
+			fmt.Println(strings.TrimRight(fmt.Sprintf("  %s[%d] %s %s", marker, i, rf.FullPath, strings.Join(attrs, " ")), " "))
+		}
+	}
+}
+
+// printSignatures summarizes the digest checks of META-INF/signatures.xml.
+func printSignatures(ep *epub.Reader) {
+	sigs, err := ep.Signatures()
+	if err != nil {
+		fmt.Printf("Signatures:  unreadable signatures.xml (%v)\n", err)
+		return
+	}
+	if len(sigs) == 0 {
+		return
+	}
+	refs, broken := 0, 0
+	for _, sig := range sigs {
+		for _, ref := range sig.References {
+			refs++
+			if ref.Status == epub.SignatureMismatch || ref.Status == epub.SignatureMissing {
+				broken++
+			}
+		}
+	}
+	fmt.Printf("Signatures:  %d (%d signed resources, %d failing digest check)\n", len(sigs), refs, broken)
+}
+
+// printWarnings lists the problems tolerated while parsing the book.
+func printWarnings(ep *epub.Reader) {
+	warnings := ep.Warnings()
+	if len(warnings) == 0 {
+		fmt.Println("Warnings:    none")
+		return
+	}
+	fmt.Println("Warnings:")
+	for _, w := range warnings {
+		fmt.Printf("  - %s\n", w)
+	}
+}
+
+// checkSignatures warns about (or, with --strip-signatures, removes) the
+// signatures in META-INF/signatures.xml that saving the changes invalidates.
+func checkSignatures(ep *epub.Reader) error {
+	invalid, err := ep.InvalidatedSignatures()
+	if err != nil || len(invalid) == 0 {
+		return err
+	}
+	if metaStripSignatures {
+		n, err := ep.StripInvalidSignatures()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Removed %d invalidated signature(s) from META-INF/signatures.xml\n", n)
+		return nil
+	}
+	for _, sig := range invalid {
+		var paths []string
+		for _, ref := range sig.References {
+			if ref.Status == epub.SignatureInvalidated {
+				paths = append(paths, ref.Path)
+			}
+		}
+		name := sig.ID
+		if name == "" {
+			name = "(unnamed)"
+		}
+		fmt.Fprintf(os.Stderr, "Warning: signature %s becomes invalid (modified: %s); use --strip-signatures to remove it\n", name, strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// openMeta opens the selected rendition without the size limits of
+// epub.OpenWithOptions, like epub.Open. With metadataOnly the manifest is
+// only parsed when something needs it.
+func openMeta(inputFile string, metadataOnly bool) (*epub.Reader, error) {
+	return epub.OpenRenditionWithOptions(inputFile, metaRendition, epub.OpenOptions{
+		MaxOPFSize:          -1,
+		MaxTotalSize:        -1,
+		MaxCompressionRatio: -1,
+		MaxEntries:          -1,
+		MetadataOnly:        metadataOnly,
+		CaseInsensitive:     metaCaseInsensitive,
+	})
+}
+
+// drmScheme returns the DRM scheme name for warnings.
+func drmScheme(ep *epub.Reader) string {
+	info, err := ep.Encryption()
+	if err != nil {
+		return "unreadable encryption.xml"
+	}
+	return string(info.DRM)
 }
 
 // stripHTML removes HTML tags from a string for plain text display.
@@ -313,34 +528,19 @@ func extractCover(ep *epub.Reader, outputPath string) error {
 }
 
 func applyChanges(ep *epub.Reader) error {
-	if metaTitle != "" {
-		ep.Package.SetTitle(metaTitle)
-	}
-	if metaAuthor != "" {
-		ep.Package.SetAuthor(metaAuthor)
-	}
-	if metaSeries != "" {
-		ep.Package.SetSeries(metaSeries)
-	}
-	if metaISBN != "" {
-		ep.Package.SetISBN(metaISBN)
-	}
-	if metaASIN != "" {
-		ep.Package.SetASIN(metaASIN)
+	if metaAllRenditions {
+		if err := ep.EditRenditions(applyPackageChanges); err != nil {
+			return err
+		}
+	} else if err := applyPackageChanges(ep.Package); err != nil {
+		return err
 	}
 
-	// Handle custom identifiers (format: scheme:value)
-	for _, id := range metaIdentifiers {
-		parts := strings.SplitN(id, ":", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid identifier format '%s', expected 'scheme:value' (e.g., douban:12345678)", id)
+	// Remove first so that --remove-cover together with --cover replaces cleanly
+	if metaRemoveCover {
+		if err := ep.RemoveCover(); err != nil {
+			return fmt.Errorf("error removing cover: %w", err)
 		}
-		scheme := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		if scheme == "" || value == "" {
-			return fmt.Errorf("invalid identifier '%s', both scheme and value must be non-empty", id)
-		}
-		ep.Package.SetIdentifier(scheme, value)
 	}
 
 	if metaCover != "" {
@@ -364,15 +564,90 @@ func applyChanges(ep *epub.Reader) error {
 		ep.SetCover(data, mime)
 	}
 
+	return nil
+}
+
+// previewChanges prints the changes made to ep against the book on disk:
+// the field-level diff for --dry-run and the OPF diff for --diff-opf.
+func previewChanges(ep *epub.Reader, inputFile string) error {
+	if metaDryRun {
+		orig, err := openMeta(inputFile, false)
+		if err != nil {
+			return err
+		}
+		defer orig.Close()
+
+		changes := orig.Package.Diff(ep.Package)
+		if metaJSON {
+			if changes == nil {
+				changes = []epub.Change{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(changes); err != nil {
+				return err
+			}
+		} else if len(changes) == 0 {
+			fmt.Println("No changes")
+		} else {
+			for _, c := range changes {
+				fmt.Printf("%s: %q -> %q\n", c.Field, c.Old, c.New)
+			}
+		}
+	}
+
+	if metaDiffOPF {
+		// The OPF in the book against the bytes Save will write
+		before, after, err := ep.OPFPreview()
+		if err != nil {
+			return err
+		}
+		fmt.Print(unifiedDiff("a/"+ep.OpfPath, "b/"+ep.OpfPath, before, after))
+	}
+	return nil
+}
+
+// applyPackageChanges applies the OPF metadata flags to pkg.
+func applyPackageChanges(pkg *epub.Package) error {
+	if metaTitle != "" {
+		pkg.SetTitle(metaTitle)
+	}
+	if metaAuthor != "" {
+		pkg.SetAuthor(metaAuthor)
+	}
+	if metaSeries != "" {
+		pkg.SetSeries(metaSeries)
+	}
+	if metaISBN != "" {
+		pkg.SetISBN(metaISBN)
+	}
+	if metaASIN != "" {
+		pkg.SetASIN(metaASIN)
+	}
+
+	// Handle custom identifiers (format: scheme:value)
+	for _, id := range metaIdentifiers {
+		parts := strings.SplitN(id, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid identifier format '%s', expected 'scheme:value' (e.g., douban:12345678)", id)
+		}
+		scheme := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if scheme == "" || value == "" {
+			return fmt.Errorf("invalid identifier '%s', both scheme and value must be non-empty", id)
+		}
+		pkg.SetIdentifier(scheme, value)
+	}
+
 	// New write fields
 	if metaPublisher != "" {
-		ep.Package.SetPublisher(metaPublisher)
+		pkg.SetPublisher(metaPublisher)
 	}
 	if metaDate != "" {
-		ep.Package.SetPublishDate(metaDate)
+		pkg.SetPublishDate(metaDate)
 	}
 	if metaLanguage != "" {
-		ep.Package.SetLanguage(metaLanguage)
+		pkg.SetLanguage(metaLanguage)
 	}
 	if metaTags != "" {
 		// Parse comma-separated tags
@@ -380,16 +655,16 @@ func applyChanges(ep *epub.Reader) error {
 		for i := range tags {
 			tags[i] = strings.TrimSpace(tags[i])
 		}
-		ep.Package.SetSubjects(tags)
+		pkg.SetSubjects(tags)
 	}
 	if metaComments != "" {
-		ep.Package.SetDescription(metaComments)
+		pkg.SetDescription(metaComments)
 	}
 	if metaSeriesIndex != "" {
-		ep.Package.SetSeriesIndex(metaSeriesIndex)
+		pkg.SetSeriesIndex(metaSeriesIndex)
 	}
 	if metaRating >= 0 {
-		ep.Package.SetRating(metaRating)
+		pkg.SetRating(metaRating)
 	}
 
 	return nil
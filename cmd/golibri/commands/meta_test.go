@@ -3,9 +3,12 @@ package commands
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -161,6 +164,7 @@ func resetMetaFlags() {
 	metaIdentifiers = []string{}
 	metaJSON = false
 	metaGetCover = "" // Reset cover extraction flag
+	metaRemoveCover = false
 	// New flags
 	metaPublisher = ""
 	metaDate = ""
@@ -169,6 +173,16 @@ func resetMetaFlags() {
 	metaComments = ""
 	metaSeriesIndex = ""
 	metaRating = -1
+	metaRendition = -1
+	metaAllRenditions = false
+	metaStripSignatures = false
+	metaVerbose = false
+	metaAppend = false
+	metaDeterministic = false
+	metaMTime = ""
+	metaDryRun = false
+	metaDiffOPF = false
+	metaCaseInsensitive = false
 }
 
 func TestMetaJSONOutput(t *testing.T) {
@@ -1080,3 +1094,570 @@ func TestMetaCoverRoundtrip(t *testing.T) {
 		t.Error("Extracted cover does not match original")
 	}
 }
+
+// TestMetaRemoveCover tests removing a cover via --remove-cover
+func TestMetaRemoveCover(t *testing.T) {
+	inputPath := createTestEPUB(t)
+	defer os.Remove(inputPath)
+
+	coverPath := createTestCover(t)
+	defer os.Remove(coverPath)
+
+	withCover, err := os.CreateTemp("", "with-cover-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withCoverPath := withCover.Name()
+	withCover.Close()
+	defer os.Remove(withCoverPath)
+
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "-c", coverPath, "-o", withCoverPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to write cover: %v", err)
+	}
+
+	outputFile, err := os.CreateTemp("", "no-cover-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputPath := outputFile.Name()
+	outputFile.Close()
+	defer os.Remove(outputPath)
+
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "--remove-cover", "-o", outputPath, withCoverPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to remove cover: %v", err)
+	}
+
+	ep, err := epub.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	if _, _, err := ep.GetCoverImage(); err == nil {
+		t.Error("Expected cover to be removed")
+	}
+
+	zr, err := zip.OpenReader(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "cover.") {
+			t.Errorf("Cover file %s still present in archive", f.Name)
+		}
+	}
+}
+
+// Helper to create a book with two renditions (fixed layout and reflowable)
+func createMultiRenditionEPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-renditions-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	cw, err := w.Create("META-INF/container.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(cw, `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:rendition="http://www.idpf.org/2013/rendition"><rootfiles>
+<rootfile full-path="fixed.opf" media-type="application/oebps-package+xml" rendition:layout="pre-paginated"/>
+<rootfile full-path="reflow.opf" media-type="application/oebps-package+xml" rendition:layout="reflowable" rendition:label="Reflowable"/>
+</rootfiles></container>`)
+
+	for _, name := range []string{"fixed.opf", "reflow.opf"} {
+		ow, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(ow, `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uuid_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>`+name+`</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uuid_id">renditions-test-uuid</dc:identifier>
+  </metadata>
+</package>`)
+	}
+
+	return f.Name()
+}
+
+// TestMetaRenditions tests reading a specific rendition and editing all of them
+func TestMetaRenditions(t *testing.T) {
+	inputPath := createMultiRenditionEPUB(t)
+	defer os.Remove(inputPath)
+	outputPath := filepath.Join(t.TempDir(), "out.epub")
+
+	// Edit only the second rendition
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "--rendition", "1", "-a", "Reflow Author", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute meta command: %v", err)
+	}
+	for i, want := range []string{"", "Reflow Author"} {
+		ep, err := epub.OpenRendition(outputPath, i)
+		if err != nil {
+			t.Fatalf("Failed to open rendition %d: %v", i, err)
+		}
+		if got := ep.Package.GetAuthor(); got != want {
+			t.Errorf("Rendition %d: expected author %q, got %q", i, want, got)
+		}
+		ep.Close()
+	}
+
+	// Edit every rendition
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "--all-renditions", "-s", "Shared Series", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute meta command: %v", err)
+	}
+	for i := range 2 {
+		ep, err := epub.OpenRendition(outputPath, i)
+		if err != nil {
+			t.Fatalf("Failed to open rendition %d: %v", i, err)
+		}
+		if got := ep.Package.GetSeries(); got != "Shared Series" {
+			t.Errorf("Rendition %d: expected series to be set, got %q", i, got)
+		}
+		ep.Close()
+	}
+}
+
+// Helper to create an Adobe ADEPT protected EPUB (encryption.xml + rights.xml)
+func createDRMEPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-drm-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"META-INF/encryption.xml", `<?xml version="1.0"?><encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#"><enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/><enc:CipherData><enc:CipherReference URI="ch1.xhtml"/></enc:CipherData></enc:EncryptedData></encryption>`},
+		{"META-INF/rights.xml", `<adept:rights xmlns:adept="http://ns.adobe.com/adept"/>`},
+		{"content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uuid_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>DRM Test Book</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uuid_id">drm-test-uuid</dc:identifier>
+  </metadata>
+</package>`},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.content)
+	}
+
+	return f.Name()
+}
+
+// TestMetaDRMWarning tests that DRM-protected books are reported
+func TestMetaDRMWarning(t *testing.T) {
+	epubPath := createDRMEPUB(t)
+	defer os.Remove(epubPath)
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	rOut, wOut, _ := os.Pipe()
+	rErr, wErr, _ := os.Pipe()
+	os.Stdout, os.Stderr = wOut, wErr
+
+	resetMetaFlags()
+	rootCmd.SetArgs([]string{"meta", "--json", epubPath})
+	err := rootCmd.Execute()
+
+	wOut.Close()
+	wErr.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	if err != nil {
+		t.Fatalf("Command execution failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	io.Copy(&stdout, rOut)
+	io.Copy(&stderr, rErr)
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &data); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput was: %s", err, stdout.String())
+	}
+	if data["drm"] != "adobe-adept" {
+		t.Errorf("Expected drm 'adobe-adept', got '%v'", data["drm"])
+	}
+	if !strings.Contains(stderr.String(), "DRM-protected") {
+		t.Errorf("Expected DRM warning on stderr, got %q", stderr.String())
+	}
+}
+
+// TestMetaCaseInsensitive tests reading a book whose hrefs differ in case
+// from the archive's entry names
+func TestMetaCaseInsensitive(t *testing.T) {
+	f, err := os.CreateTemp("", "test-case-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	w := zip.NewWriter(f)
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uuid_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Case Test</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uuid_id">case-uuid</dc:identifier>
+    <meta name="cover" content="cover"/>
+  </metadata>
+  <manifest>
+    <item id="cover" href="images/cover.jpg" media-type="image/jpeg"/>
+  </manifest>
+</package>`},
+		{"Images/Cover.jpg", "\xFF\xD8\xFF\xE0"},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.content)
+	}
+	w.Close()
+	f.Close()
+
+	run := func(args ...string) map[string]interface{} {
+		oldStdout := os.Stdout
+		r, pw, _ := os.Pipe()
+		os.Stdout = pw
+
+		resetMetaFlags()
+		rootCmd.SetArgs(append([]string{"meta", "--json"}, args...))
+		err := rootCmd.Execute()
+
+		pw.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var out bytes.Buffer
+		io.Copy(&out, r)
+		var data map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &data); err != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput was: %s", err, out.String())
+		}
+		return data
+	}
+
+	if data := run(f.Name()); data["cover"] != false {
+		t.Errorf("Expected no cover without --case-insensitive, got %v", data["cover"])
+	}
+	data := run("--case-insensitive", f.Name())
+	if data["title"] != "Case Test" {
+		t.Errorf("Expected title 'Case Test', got %v", data["title"])
+	}
+	if data["cover"] != true {
+		t.Errorf("Expected the cover to be found with --case-insensitive, got %v", data["cover"])
+	}
+}
+
+// Helper to create an EPUB with a signature over its OPF
+func createSignedEPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-signed-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	opf := `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uuid_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Signed Book</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uuid_id">signed-uuid</dc:identifier>
+  </metadata>
+</package>`
+	digest := sha256.Sum256([]byte(opf))
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"META-INF/signatures.xml", `<?xml version="1.0"?><signatures xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><Signature Id="sig1" xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo><Reference URI="content.opf"><DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/><DigestValue>` +
+			base64.StdEncoding.EncodeToString(digest[:]) + `</DigestValue></Reference></SignedInfo><SignatureValue>AAAA</SignatureValue></Signature></signatures>`},
+		{"content.opf", opf},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.content)
+	}
+
+	return f.Name()
+}
+
+// TestMetaSignatures tests the warning and --strip-signatures for signed books
+func TestMetaSignatures(t *testing.T) {
+	epubPath := createSignedEPUB(t)
+	defer os.Remove(epubPath)
+
+	for _, strip := range []bool{false, true} {
+		outputPath := filepath.Join(t.TempDir(), "out.epub")
+
+		oldStderr := os.Stderr
+		rErr, wErr, _ := os.Pipe()
+		os.Stderr = wErr
+
+		resetMetaFlags()
+		args := []string{"meta", "--title", "Changed", "-o", outputPath, epubPath}
+		if strip {
+			args = append(args, "--strip-signatures")
+		}
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+
+		wErr.Close()
+		os.Stderr = oldStderr
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var stderr bytes.Buffer
+		io.Copy(&stderr, rErr)
+
+		ep, err := epub.Open(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to open output: %v", err)
+		}
+		sigs, err := ep.Signatures()
+		ep.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strip {
+			if len(sigs) != 0 {
+				t.Errorf("Expected signatures to be stripped, got %+v", sigs)
+			}
+			if !strings.Contains(stderr.String(), "Removed 1 invalidated signature") {
+				t.Errorf("Unexpected stderr: %q", stderr.String())
+			}
+		} else {
+			if len(sigs) != 1 || sigs[0].References[0].Status != epub.SignatureMismatch {
+				t.Errorf("Expected the signature to be kept (and broken), got %+v", sigs)
+			}
+			if !strings.Contains(stderr.String(), "signature sig1 becomes invalid (modified: content.opf)") {
+				t.Errorf("Expected signature warning on stderr, got %q", stderr.String())
+			}
+		}
+	}
+}
+
+// TestMetaSignatures_Read tests that reading a signed book verifies its
+// signatures only with --verbose
+func TestMetaSignatures_Read(t *testing.T) {
+	epubPath := createSignedEPUB(t)
+	defer os.Remove(epubPath)
+
+	run := func(args ...string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		resetMetaFlags()
+		rootCmd.SetArgs(append([]string{"meta"}, args...))
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	if out := run(epubPath); strings.Contains(out, "Signatures:") {
+		t.Errorf("Expected no signature check without --verbose:\n%s", out)
+	}
+	if out := run("--verbose", epubPath); !strings.Contains(out, "Signatures:  1 (1 signed resources, 0 failing digest check)") {
+		t.Errorf("Expected signature summary with --verbose:\n%s", out)
+	}
+}
+
+// Helper to create an EPUB with problems the parser tolerates
+func createWarningEPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-warnings-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uuid_id">
+  <!-- generated -- by hand -->
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Warning Book</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uuid_id">warning-uuid</dc:identifier>
+  </metadata>
+  <manifest/>
+  <spine>
+    <itemref idref="missing"/>
+  </spine>
+</package>`},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.content)
+	}
+
+	return f.Name()
+}
+
+// TestMetaVerbose tests that --verbose reports parse warnings
+func TestMetaVerbose(t *testing.T) {
+	epubPath := createWarningEPUB(t)
+	defer os.Remove(epubPath)
+
+	run := func(args ...string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		resetMetaFlags()
+		rootCmd.SetArgs(append([]string{"meta"}, args...))
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	if out := run(epubPath); strings.Contains(out, "Warnings") {
+		t.Errorf("Warnings printed without --verbose:\n%s", out)
+	}
+
+	out := run("--verbose", epubPath)
+	for _, want := range []string{"Warnings:", "[invalid-comment]", `spine itemref "missing" has no manifest item`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	var data MetadataJSON
+	if err := json.Unmarshal([]byte(run("--json", "-v", epubPath)), &data); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(data.Warnings) != 2 || data.Warnings[0].Code != epub.WarnInvalidComment {
+		t.Errorf("Unexpected JSON warnings: %+v", data.Warnings)
+	}
+}
+
+// TestMetaDryRun tests that --dry-run and --diff-opf preview changes without writing
+func TestMetaDryRun(t *testing.T) {
+	epubPath := createTestEPUB(t)
+	defer os.Remove(epubPath)
+	original, _ := os.ReadFile(epubPath)
+
+	run := func(args ...string) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		resetMetaFlags()
+		rootCmd.SetArgs(append([]string{"meta"}, args...))
+		err := rootCmd.Execute()
+
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("Command execution failed: %v", err)
+		}
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String()
+	}
+
+	out := run("--dry-run", "-t", "Preview Title", "--isbn", "9787020002207", epubPath)
+	for _, want := range []string{
+		`title: "JSON Test Book" -> "Preview Title"`,
+		`identifiers.isbn: "" -> "9787020002207"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	out = run("--diff-opf", "-t", "Preview Title", epubPath)
+	for _, want := range []string{"--- a/content.opf\n+++ b/content.opf\n@@ ", "-    <dc:title>JSON Test Book</dc:title>\n+    <dc:title>Preview Title</dc:title>\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	var changes []epub.Change
+	if err := json.Unmarshal([]byte(run("--dry-run", "--json", "-t", "JSON Test Book", epubPath)), &changes); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+
+	if data, _ := os.ReadFile(epubPath); !bytes.Equal(data, original) {
+		t.Error("Dry run modified the file")
+	}
+}
+
+func TestMetaDeterministic(t *testing.T) {
+	inputPath := createTestEPUB(t)
+	defer os.Remove(inputPath)
+	dir := t.TempDir()
+
+	save := func(out string) []byte {
+		resetMetaFlags()
+		rootCmd.SetArgs([]string{"meta", "--deterministic", "--mtime", "2024-01-02T03:04:05Z", "-t", "Same Bytes", "--tags", "a,b", "-o", out, inputPath})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Failed to execute meta command: %v", err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	first := save(filepath.Join(dir, "a.epub"))
+	if second := save(filepath.Join(dir, "b.epub")); !bytes.Equal(first, second) {
+		t.Error("Expected byte-identical output with --deterministic")
+	}
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateOutput string
+
+func init() {
+	migrateCmd.Flags().StringVarP(&migrateOutput, "output", "o", "", "Output file path (default: modify in-place)")
+
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [flags] input.epub",
+	Short: "Convert an OEBPS 1.x book to EPUB 2",
+	Long: `Rewrites an OEBPS 1.x package (dc-metadata/x-metadata, OEB 1 media types) as a
+valid EPUB 2 package: flat Dublin Core metadata in the OPF 2.0 namespace,
+application/xhtml+xml and text/css media types, a unique identifier, dc:language
+and an NCX generated from the spine when the book has none.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		if err := epub.MigrateToEPUB2(ep); err != nil {
+			fmt.Printf("Error migrating: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		outputPath := migrateOutput
+		if outputPath == "" {
+			outputPath = inputFile
+		}
+
+		if err := ep.Save(outputPath); err != nil {
+			fmt.Printf("Error saving EPUB: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		fmt.Printf("Migrated to EPUB 2. Saved to %s\n", outputPath)
+	},
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+// Helper to create an OEBPS 1.2 book (dc-metadata/x-metadata layout)
+func createOEB1EPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-oeb1-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"content.opf", `<?xml version="1.0"?>
+<package unique-identifier="bookid" xmlns="http://openebook.org/namespaces/oeb-package/1.0/">
+  <metadata>
+    <dc-metadata xmlns:dc="http://purl.org/dc/elements/1.0/">
+      <dc:Title>OEB Test Book</dc:Title>
+      <dc:Creator role="aut">Test Author</dc:Creator>
+      <dc:Identifier id="bookid">oeb-test-id</dc:Identifier>
+      <dc:Language>en</dc:Language>
+    </dc-metadata>
+  </metadata>
+  <manifest>
+    <item id="ch1" href="ch1.html" media-type="text/x-oeb1-document"/>
+  </manifest>
+  <spine>
+    <itemref idref="ch1"/>
+  </spine>
+</package>`},
+		{"ch1.html", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter 1</h1></body></html>`},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.content)
+	}
+
+	return f.Name()
+}
+
+func TestMigrateCommand(t *testing.T) {
+	inputPath := createOEB1EPUB(t)
+	defer os.Remove(inputPath)
+
+	outputPath := filepath.Join(t.TempDir(), "migrated.epub")
+
+	migrateOutput = ""
+	rootCmd.SetArgs([]string{"migrate", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute migrate command: %v", err)
+	}
+
+	ep, err := epub.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	if ep.Package.Version != "2.0" {
+		t.Errorf("Expected version 2.0, got %s", ep.Package.Version)
+	}
+	if ep.Package.GetTitle() != "OEB Test Book" {
+		t.Errorf("Expected title to be kept, got %q", ep.Package.GetTitle())
+	}
+	if ep.Package.Manifest.Items[0].MediaType != "application/xhtml+xml" {
+		t.Errorf("Expected XHTML media type, got %s", ep.Package.Manifest.Items[0].MediaType)
+	}
+	if ep.Package.Spine.Toc == "" {
+		t.Error("NCX not referenced from the spine")
+	}
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	packOutput        string
+	packDeterministic bool
+	packMTime         string
+)
+
+func init() {
+	packCmd.Flags().StringVarP(&packOutput, "output", "o", "", "Output file path (default: the directory name with .epub)")
+	packCmd.Flags().BoolVar(&packDeterministic, "deterministic", false, "Use a fixed timestamp and permissions for byte-identical output")
+	packCmd.Flags().StringVar(&packMTime, "mtime", "", "Entry timestamp for --deterministic (RFC 3339; default: $SOURCE_DATE_EPOCH or 1980-01-01)")
+
+	rootCmd.AddCommand(packCmd)
+}
+
+var packCmd = &cobra.Command{
+	Use:   "pack [flags] dir",
+	Short: "Pack an unpacked EPUB directory into an archive",
+	Long: `Packs a directory into a conformant EPUB: mimetype first and stored, then
+META-INF, the OPF and the remaining files sorted by path, deflated.
+META-INF/container.xml must point to an existing OPF.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := filepath.Clean(args[0])
+
+		outputPath := packOutput
+		if outputPath == "" {
+			outputPath = dir + ".epub"
+		}
+
+		opts := epub.PackOptions{Deterministic: packDeterministic}
+		if packDeterministic {
+			t, err := deterministicTime(packMTime)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts.ModTime = t
+		} else if packMTime != "" {
+			fmt.Println("Error: --mtime requires --deterministic")
+			os.Exit(1)
+		}
+
+		if err := epub.PackDirWithOptions(dir, outputPath, opts); err != nil {
+			fmt.Printf("Error packing %s: %v\n", dir, err)
+			os.Exit(exitCode(err))
+		}
+		fmt.Printf("Packed %s to %s\n", dir, outputPath)
+	},
+}
+
+// deterministicTime parses an RFC 3339 timestamp, falling back to
+// $SOURCE_DATE_EPOCH; the zero time leaves the choice to the library.
+func deterministicTime(value string) (time.Time, error) {
+	if value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --mtime %q: %w", value, err)
+		}
+		return t, nil
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestPackCommand(t *testing.T) {
+	inputPath := createTestEPUB(t)
+	defer os.Remove(inputPath)
+
+	dir := filepath.Join(t.TempDir(), "book")
+	extractOutput = ""
+	rootCmd.SetArgs([]string{"extract", "-o", dir, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute extract command: %v", err)
+	}
+
+	pack := func(out string) []byte {
+		packOutput, packDeterministic, packMTime = "", false, ""
+		rootCmd.SetArgs([]string{"pack", "--deterministic", "--mtime", "2024-01-02T03:04:05Z", "-o", out, dir})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Failed to execute pack command: %v", err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	outDir := t.TempDir()
+	first := pack(filepath.Join(outDir, "a.epub"))
+	if second := pack(filepath.Join(outDir, "b.epub")); !bytes.Equal(first, second) {
+		t.Error("Expected byte-identical output with --deterministic")
+	}
+
+	ep, err := epub.Open(filepath.Join(outDir, "a.epub"))
+	if err != nil {
+		t.Fatalf("Failed to open packed book: %v", err)
+	}
+	defer ep.Close()
+	if got := ep.Package.GetTitle(); got != "JSON Test Book" {
+		t.Errorf("Expected title to survive the round trip, got %q", got)
+	}
+}
+
+func TestDeterministicTime(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if got, err := deterministicTime(""); err != nil || got.Unix() != 1700000000 {
+		t.Errorf("Expected SOURCE_DATE_EPOCH, got %v, %v", got, err)
+	}
+	if got, _ := deterministicTime("2024-01-02T03:04:05Z"); got.Year() != 2024 {
+		t.Errorf("Expected the flag to win, got %v", got)
+	}
+	if _, err := deterministicTime("yesterday"); err == nil {
+		t.Error("Expected an error for an invalid timestamp")
+	}
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	restructureOutput  string
+	restructureVerbose bool
+)
+
+func init() {
+	restructureCmd.Flags().StringVarP(&restructureOutput, "output", "o", "", "Output file path (default: modify in-place)")
+	restructureCmd.Flags().BoolVarP(&restructureVerbose, "verbose", "v", false, "List every moved file")
+
+	rootCmd.AddCommand(restructureCmd)
+}
+
+var restructureCmd = &cobra.Command{
+	Use:   "restructure [flags] input.epub",
+	Short: "Normalize EPUB layout into OEBPS/Text, Styles and Images",
+	Long: `Moves the OPF to OEBPS/content.opf and every resource into a folder by media type
+(OEBPS/Text, Styles, Images, Fonts, Audio, Video, Misc). Links in XHTML, CSS,
+NCX and navigation documents are rewritten to match.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		moves, err := ep.Restructure()
+		if err != nil {
+			fmt.Printf("Error restructuring: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		outputPath := restructureOutput
+		if outputPath == "" {
+			outputPath = inputFile
+		}
+
+		if err := ep.Save(outputPath); err != nil {
+			fmt.Printf("Error saving EPUB: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		if restructureVerbose {
+			var oldPaths []string
+			for p := range moves {
+				oldPaths = append(oldPaths, p)
+			}
+			sort.Strings(oldPaths)
+			for _, p := range oldPaths {
+				fmt.Printf("%s -> %s\n", p, moves[p])
+			}
+		}
+		fmt.Printf("Moved %d files. Saved to %s\n", len(moves), outputPath)
+	},
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestRestructureCommand(t *testing.T) {
+	inputPath := createTestEPUB(t)
+	defer os.Remove(inputPath)
+
+	outputFile, err := os.CreateTemp("", "restructure-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputPath := outputFile.Name()
+	outputFile.Close()
+	defer os.Remove(outputPath)
+
+	restructureOutput = ""
+	restructureVerbose = false
+	rootCmd.SetArgs([]string{"restructure", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute restructure command: %v", err)
+	}
+
+	ep, err := epub.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	if ep.OpfPath != "OEBPS/content.opf" {
+		t.Errorf("Expected OPF at OEBPS/content.opf, got %s", ep.OpfPath)
+	}
+	if ep.Package.GetTitle() != "JSON Test Book" {
+		t.Errorf("Title lost after restructure: %s", ep.Package.GetTitle())
+	}
+}
@@ -17,6 +17,6 @@ and modifying EPUB 2/3 metadata, designed for performance and correctness.`,
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
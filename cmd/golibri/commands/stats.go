@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsJSON        bool
+	statsItems       bool
+	statsPagesColumn string
+	statsWordsColumn string
+	statsOutput      string
+)
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Output statistics in JSON format")
+	statsCmd.Flags().BoolVar(&statsItems, "items", false, "Show counts for each spine item")
+	statsCmd.Flags().StringVar(&statsPagesColumn, "pages-column", "", "Write page count into this Calibre custom column (e.g. #pages)")
+	statsCmd.Flags().StringVar(&statsWordsColumn, "words-column", "", "Write word count into this Calibre custom column (e.g. #words)")
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", "", "Output file path when writing columns (default: modify in-place)")
+
+	rootCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [flags] input.epub",
+	Short: "Show word count, page count and reading time",
+	Long: `Counts words and characters of the linear spine content. CJK text is counted
+by characters. Pages assume 250 words or 500 CJK characters per page; reading
+time assumes 230 words or 400 CJK characters per minute.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		stats, err := ep.Stats()
+		if err != nil {
+			fmt.Printf("Error computing statistics: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		if statsPagesColumn != "" || statsWordsColumn != "" {
+			if err := writeStatsColumns(ep, stats); err != nil {
+				fmt.Printf("Error writing Calibre columns: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			outputPath := statsOutput
+			if outputPath == "" {
+				outputPath = inputFile
+			}
+			if err := ep.Save(outputPath); err != nil {
+				fmt.Printf("Error saving EPUB: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			if !statsJSON {
+				fmt.Printf("Saved Calibre columns to %s\n", outputPath)
+			}
+		}
+
+		if statsJSON {
+			if !statsItems {
+				stats.Items = nil
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				fmt.Printf("Error encoding JSON: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			return
+		}
+
+		printStats(stats)
+	},
+}
+
+func writeStatsColumns(ep *epub.Reader, stats *epub.BookStats) error {
+	if statsPagesColumn != "" {
+		if err := ep.Package.SetCalibreColumn(statsPagesColumn, "Pages", "int", stats.Pages); err != nil {
+			return err
+		}
+	}
+	if statsWordsColumn != "" {
+		if err := ep.Package.SetCalibreColumn(statsWordsColumn, "Words", "int", stats.Words); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printStats(stats *epub.BookStats) {
+	fmt.Printf("%-16s: %d\n", "Words", stats.Words)
+	fmt.Printf("%-16s: %d\n", "Characters", stats.Characters)
+	fmt.Printf("%-16s: %d\n", "CJK characters", stats.CJKCharacters)
+	fmt.Printf("%-16s: %d\n", "Pages", stats.Pages)
+	fmt.Printf("%-16s: %dh %02dm\n", "Reading time", stats.ReadingMinutes/60, stats.ReadingMinutes%60)
+
+	if statsItems {
+		fmt.Println()
+		for _, item := range stats.Items {
+			fmt.Printf("%4d  %-40s %8d words %8d chars\n", item.Index, item.Path, item.Words, item.Characters)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestStatsCommand_CalibreColumns(t *testing.T) {
+	inputPath := createContentEPUB(t)
+	defer os.Remove(inputPath)
+
+	outputPath := filepath.Join(t.TempDir(), "stats.epub")
+
+	statsJSON = false
+	statsItems = false
+	rootCmd.SetArgs([]string{"stats", "--pages-column", "#pages", "--words-column", "#words", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute stats command: %v", err)
+	}
+	statsPagesColumn, statsWordsColumn, statsOutput = "", "", ""
+
+	ep, err := epub.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	// "One The first chapter." = 4 words, "Two" + 6 CJK characters = 7 words
+	if v, ok := ep.Package.GetCalibreColumn("#words"); !ok || v != float64(11) {
+		t.Errorf("Unexpected #words column: %v (%v)", v, ok)
+	}
+	if v, ok := ep.Package.GetCalibreColumn("#pages"); !ok || v != float64(1) {
+		t.Errorf("Unexpected #pages column: %v (%v)", v, ok)
+	}
+}
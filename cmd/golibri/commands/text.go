@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	textFormat           string
+	textOutput           string
+	textSeparator        string
+	textSplitDir         string
+	textIncludeHidden    bool
+	textIncludeNonLinear bool
+)
+
+func init() {
+	textCmd.Flags().StringVarP(&textFormat, "format", "f", "text", "Output format: text or markdown")
+	textCmd.Flags().StringVarP(&textOutput, "output", "o", "", "Write to file instead of stdout")
+	textCmd.Flags().StringVar(&textSeparator, "separator", "", "Line inserted between chapters (e.g. \"---\")")
+	textCmd.Flags().StringVar(&textSplitDir, "split", "", "Write one file per chapter into this directory")
+	textCmd.Flags().BoolVar(&textIncludeHidden, "include-hidden", false, "Include hidden elements (hidden attribute, display:none)")
+	textCmd.Flags().BoolVar(&textIncludeNonLinear, "include-nonlinear", false, "Include spine items marked linear=\"no\"")
+
+	rootCmd.AddCommand(textCmd)
+}
+
+var textCmd = &cobra.Command{
+	Use:   "text [flags] input.epub",
+	Short: "Extract book content as plain text or Markdown",
+	Long: `Walks the spine in reading order and converts each XHTML document to text.
+Headings, paragraphs, lists and footnotes keep their structure.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		opts := epub.TextOptions{
+			IncludeHidden:    textIncludeHidden,
+			IncludeNonLinear: textIncludeNonLinear,
+		}
+		ext := ".txt"
+		switch textFormat {
+		case "text", "txt":
+		case "markdown", "md":
+			opts.Format = epub.FormatMarkdown
+			ext = ".md"
+		default:
+			fmt.Printf("Error: unknown format %q (use text or markdown)\n", textFormat)
+			os.Exit(1)
+		}
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		chapters, err := ep.ExtractText(opts)
+		if err != nil {
+			fmt.Printf("Error extracting text: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		if textSplitDir != "" {
+			n, err := writeChapterFiles(chapters, textSplitDir, ext)
+			if err != nil {
+				fmt.Printf("Error writing chapters: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			fmt.Printf("Wrote %d chapters to %s\n", n, textSplitDir)
+			return
+		}
+
+		text := epub.JoinChapters(chapters, textSeparator) + "\n"
+		if textOutput == "" {
+			fmt.Print(text)
+			return
+		}
+		if err := os.WriteFile(textOutput, []byte(text), 0644); err != nil {
+			fmt.Printf("Error writing %s: %v\n", textOutput, err)
+			os.Exit(exitCode(err))
+		}
+	},
+}
+
+// writeChapterFiles writes each non-empty chapter to dir as NNN-<id><ext>.
+func writeChapterFiles(chapters []epub.ChapterText, dir, ext string) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, ch := range chapters {
+		if ch.Text == "" {
+			continue
+		}
+		n++
+		name := fmt.Sprintf("%03d-%s%s", n, safeFileName(ch.ID), ext)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(ch.Text+"\n"), 0644); err != nil {
+			return n - 1, err
+		}
+	}
+	return n, nil
+}
+
+// safeFileName replaces characters that are unsafe in file names.
+func safeFileName(s string) string {
+	out := []rune(s)
+	for i, c := range out {
+		switch c {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
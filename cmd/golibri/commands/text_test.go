@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Helper to create an EPUB with a two-chapter spine
+func createContentEPUB(t *testing.T) string {
+	f, err := os.CreateTemp("", "test-content-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	files := []struct{ name, body string }{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uuid_id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Content Test Book</dc:title>
+    <dc:language>en</dc:language>
+    <dc:identifier id="uuid_id">1234-5678</dc:identifier>
+  </metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="ch1"/>
+    <itemref idref="ch2"/>
+  </spine>
+</package>`},
+		{"ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>One</h1><p>The first chapter.</p></body></html>`},
+		{"ch2.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Two</h1><p>第二章的内容。</p></body></html>`},
+	}
+	for _, file := range files {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, file.body)
+	}
+
+	return f.Name()
+}
+
+func resetTextFlags() {
+	textFormat = "text"
+	textOutput = ""
+	textSeparator = ""
+	textSplitDir = ""
+	textIncludeHidden = false
+	textIncludeNonLinear = false
+}
+
+func TestTextCommand(t *testing.T) {
+	inputPath := createContentEPUB(t)
+	defer os.Remove(inputPath)
+
+	outputPath := filepath.Join(t.TempDir(), "book.md")
+
+	resetTextFlags()
+	rootCmd.SetArgs([]string{"text", "--format", "markdown", "--separator", "***", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute text command: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# One\n\nThe first chapter.\n\n***\n\n# Two\n\n第二章的内容。\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected output:\n%s", data)
+	}
+}
+
+func TestTextCommand_Split(t *testing.T) {
+	inputPath := createContentEPUB(t)
+	defer os.Remove(inputPath)
+
+	dir := t.TempDir()
+
+	resetTextFlags()
+	rootCmd.SetArgs([]string{"text", "--split", dir, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute text command: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "002-ch2.txt"))
+	if err != nil {
+		t.Fatalf("Chapter file not written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "Two\n\n") {
+		t.Errorf("Unexpected chapter content: %q", data)
+	}
+}
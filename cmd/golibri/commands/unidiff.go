@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each hunk.
+const diffContext = 3
+
+// diffMaxCells bounds the LCS table; larger inputs are diffed as one
+// replaced block.
+const diffMaxCells = 4 << 20
+
+type diffLine struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// unifiedDiff returns a unified diff from a to b, "" when they are equal.
+func unifiedDiff(oldName, newName string, a, b []byte) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	// Group the changes and their context into hunks of line indexes
+	var hunks [][2]int
+	for i, l := range lines {
+		if l.kind == ' ' {
+			continue
+		}
+		start, end := max(i-diffContext, 0), min(i+diffContext+1, len(lines))
+		if n := len(hunks); n > 0 && start <= hunks[n-1][1] {
+			hunks[n-1][1] = end
+		} else {
+			hunks = append(hunks, [2]int{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	oldLine, newLine, pos := 1, 1, 0
+	for _, h := range hunks {
+		for ; pos < h[0]; pos++ {
+			oldLine, newLine = advance(lines[pos], oldLine, newLine)
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range lines[h[0]:h[1]] {
+			if l.kind != '+' {
+				oldCount++
+			}
+			if l.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for ; pos < h[1]; pos++ {
+			sb.WriteByte(lines[pos].kind)
+			sb.WriteString(lines[pos].text)
+			sb.WriteByte('\n')
+			oldLine, newLine = advance(lines[pos], oldLine, newLine)
+		}
+	}
+	return sb.String()
+}
+
+func advance(l diffLine, oldLine, newLine int) (int, int) {
+	if l.kind != '+' {
+		oldLine++
+	}
+	if l.kind != '-' {
+		newLine++
+	}
+	return oldLine, newLine
+}
+
+// hunkRange formats a hunk range; an empty range starts at the line before.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(data []byte) []string {
+	s := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the edit script from a to b, based on their longest
+// common subsequence.
+func diffLines(a, b []string) []diffLine {
+	var result []diffLine
+
+	// The common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		result = append(result, diffLine{' ', a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(x)*len(y) > diffMaxCells {
+		for _, l := range x {
+			result = append(result, diffLine{'-', l})
+		}
+		for _, l := range y {
+			result = append(result, diffLine{'+', l})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of x[i:] and y[j:]
+		lcs := make([][]int, len(x)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(y)+1)
+		}
+		for i := len(x) - 1; i >= 0; i-- {
+			for j := len(y) - 1; j >= 0; j-- {
+				if x[i] == y[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(x) || j < len(y) {
+			switch {
+			case i < len(x) && j < len(y) && x[i] == y[j]:
+				result = append(result, diffLine{' ', x[i]})
+				i++
+				j++
+			case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+				result = append(result, diffLine{'-', x[i]})
+				i++
+			default:
+				result = append(result, diffLine{'+', y[j]})
+				j++
+			}
+		}
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		result = append(result, diffLine{' ', l})
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jianyun8023/golibri/epub"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeOutput string
+
+func init() {
+	upgradeCmd.Flags().StringVarP(&upgradeOutput, "output", "o", "", "Output file path (default: modify in-place)")
+
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [flags] input.epub",
+	Short: "Convert an EPUB 2 book to EPUB 3",
+	Long: `Bumps the package version to 3.0, generates an XHTML navigation document from
+the NCX (the NCX is kept for older readers), maps guide references to landmarks,
+converts opf:role/file-as/scheme attributes to refines meta, adds dcterms:modified
+and marks the cover image and nav document in the manifest.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inputFile := args[0]
+
+		ep, err := epub.Open(inputFile)
+		if err != nil {
+			fmt.Printf("Error opening %s: %v\n", inputFile, err)
+			os.Exit(exitCode(err))
+		}
+		defer ep.Close()
+
+		if err := epub.UpgradeToEPUB3(ep); err != nil {
+			fmt.Printf("Error upgrading: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		outputPath := upgradeOutput
+		if outputPath == "" {
+			outputPath = inputFile
+		}
+
+		if err := ep.Save(outputPath); err != nil {
+			fmt.Printf("Error saving EPUB: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+
+		fmt.Printf("Upgraded to EPUB 3. Saved to %s\n", outputPath)
+	},
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestUpgradeCommand(t *testing.T) {
+	inputPath := createContentEPUB(t)
+	defer os.Remove(inputPath)
+
+	outputPath := filepath.Join(t.TempDir(), "upgraded.epub")
+
+	upgradeOutput = ""
+	rootCmd.SetArgs([]string{"upgrade", "-o", outputPath, inputPath})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute upgrade command: %v", err)
+	}
+
+	ep, err := epub.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output EPUB: %v", err)
+	}
+	defer ep.Close()
+
+	if ep.Package.Version != "3.0" {
+		t.Errorf("Expected version 3.0, got %s", ep.Package.Version)
+	}
+	hasNav := false
+	for _, item := range ep.Package.Manifest.Items {
+		if item.Properties == "nav" {
+			hasNav = true
+		}
+	}
+	if !hasNav {
+		t.Error("Nav document not added")
+	}
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -89,13 +90,20 @@ func runFunctional(dir string) {
 	fmt.Printf("\nResult: %d passed, %d failed, %d corrupt\n", passed, failed, corrupt)
 }
 
+// golibriExitNotZip is the exit code of golibri for files that are not zip
+// archives (see cmd/golibri/commands/exitcode.go).
+const golibriExitNotZip = 2
+
 func isCorruptZipError(err error) bool {
 	if err == nil {
 		return false
 	}
-	// Go's archive/zip uses this exact error string in several failure paths.
 	// Treat these as "dataset corrupt" rather than product regression failures.
-	return strings.Contains(err.Error(), "zip: not a valid zip file")
+	if errors.Is(err, epub.ErrNotZip) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == golibriExitNotZip
 }
 
 func testFile(path string, mode string) error {
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jianyun8023/golibri/epub"
+)
+
+func TestIsCorruptZipError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.epub")
+	if err := os.WriteFile(path, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := epub.Open(path)
+	if !isCorruptZipError(fmt.Errorf("open failed: %w", err)) {
+		t.Errorf("Expected corrupt zip error, got %v", err)
+	}
+
+	if isCorruptZipError(nil) || isCorruptZipError(fmt.Errorf("title is empty")) {
+		t.Error("Unexpected corrupt zip error")
+	}
+
+	// golibri failures are reported through its exit code
+	cmd := exec.Command("sh", "-c", fmt.Sprintf("exit %d", golibriExitNotZip))
+	if err := cmd.Run(); !isCorruptZipError(fmt.Errorf("golibri command failed: %w", err)) {
+		t.Errorf("Expected exit code %d to be a corrupt zip error", golibriExitNotZip)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync/atomic"
 
@@ -39,6 +40,10 @@ func runScan(root string) {
 		countTotal      int64
 		errors          []string
 		unknownVersions []string
+		// Parse warnings (tolerated problems), by code
+		countWarned   int64
+		warningCounts = make(map[string]int)
+		warningSample = make(map[string]string)
 	)
 
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
@@ -55,7 +60,7 @@ func runScan(root string) {
 
 		atomic.AddInt64(&countTotal, 1)
 
-		ver, hasNCX, err := analyzeEpub(path)
+		ver, hasNCX, warnings, err := analyzeEpub(path)
 		if err != nil {
 			atomic.AddInt64(&countError, 1)
 			msg := fmt.Sprintf("%s: %v", filepath.Base(path), err)
@@ -66,6 +71,16 @@ func runScan(root string) {
 			return nil
 		}
 
+		if len(warnings) > 0 {
+			atomic.AddInt64(&countWarned, 1)
+		}
+		for _, w := range warnings {
+			warningCounts[w.Code]++
+			if _, ok := warningSample[w.Code]; !ok {
+				warningSample[w.Code] = fmt.Sprintf("%s: %s", filepath.Base(path), w)
+			}
+		}
+
 		if strings.HasPrefix(ver, "2") {
 			atomic.AddInt64(&countV2, 1)
 		} else if strings.HasPrefix(ver, "3") {
@@ -104,6 +119,7 @@ func runScan(root string) {
 	fmt.Printf("OEBPS 1.x:         %d\n", countV1)
 	fmt.Printf("Unknown Ver:       %d\n", countUnknown)
 	fmt.Printf("Errors:            %d\n", countError)
+	fmt.Printf("With Warnings:     %d\n", countWarned)
 
 	if len(unknownVersions) > 0 {
 		fmt.Println("\nUnknown Version Samples:")
@@ -115,6 +131,18 @@ func runScan(root string) {
 		}
 	}
 
+	if len(warningCounts) > 0 {
+		fmt.Println("\nParse Warnings:")
+		codes := make([]string, 0, len(warningCounts))
+		for code := range warningCounts {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Printf("  - %-26s %d (e.g. %s)\n", code, warningCounts[code], warningSample[code])
+		}
+	}
+
 	if len(errors) > 0 {
 		fmt.Println("\nError Details:")
 		for _, e := range errors {
@@ -123,15 +151,15 @@ func runScan(root string) {
 	}
 }
 
-func analyzeEpub(path string) (version string, hasNCX bool, err error) {
+func analyzeEpub(path string) (version string, hasNCX bool, warnings []epub.Warning, err error) {
 	ep, err := epub.Open(path)
 	if err != nil {
-		return "", false, err
+		return "", false, nil, err
 	}
 	defer ep.Close()
 
 	if ep.Package == nil {
-		return "", false, fmt.Errorf("no package found")
+		return "", false, nil, fmt.Errorf("no package found")
 	}
 
 	// Check for NCX (toc attribute in spine or just standard toc.ncx existence)
@@ -149,5 +177,10 @@ func analyzeEpub(path string) (version string, hasNCX bool, err error) {
 		}
 	}
 
-	return ep.Package.Version, hasNCX, nil
+	version = ep.Package.Version
+	if version == "" && ep.Package.IsOEBPS1() {
+		// OEBPS 1.x packages usually omit the version attribute
+		version = "1.2"
+	}
+	return version, hasNCX, ep.Warnings(), nil
 }
@@ -0,0 +1,429 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Zip record signatures and sizes (APPNOTE.TXT 4.3)
+const (
+	centralDirSignature   = 0x02014b50
+	directoryEndSignature = 0x06054b50
+	centralDirHeaderLen   = 46
+	directoryEndLen       = 22
+)
+
+// errAppendUnsupported makes SaveAppend fall back to Save.
+var errAppendUnsupported = errors.New("archive cannot be appended to")
+
+// directoryEnd is an end of central directory record.
+type directoryEnd struct {
+	offset   int64 // of the record itself
+	cdOffset int64
+	cdSize   int64
+	entries  int
+	comment  []byte
+}
+
+// end returns the offset just past the record.
+func (d *directoryEnd) end() int64 {
+	return d.offset + directoryEndLen + int64(len(d.comment))
+}
+
+// centralRecord is a raw central directory file header.
+type centralRecord struct {
+	name string
+	raw  []byte
+}
+
+// SaveAppend saves the changes into the file the book was opened from by
+// appending the changed entries and a new central directory, instead of
+// rewriting the archive like Save. Unchanged entries, mimetype included, stay
+// byte-identical at their offsets; replaced and removed entries remain in the
+// file as unreferenced data until it is compacted (see Compact).
+//
+// The original central directory is never overwritten, and a failed append
+// truncates the file back to its original length. An append interrupted by a
+// crash or power loss, however, leaves a file that other readers may reject;
+// only Compact can recover it. Archives that cannot be appended to (zip64,
+// trailing data, a changed mimetype) are rewritten with Save.
+//
+// After a successful save the Reader reads the saved file and has no pending
+// changes, so SaveAppend can be called again for further edits.
+func (r *Reader) SaveAppend() error {
+	if r.file == nil {
+		return fmt.Errorf("book was not opened from a file")
+	}
+	if _, err := r.fullPackage(); err != nil {
+		return err
+	}
+	if err := r.rekeyObfuscatedFonts(); err != nil {
+		return fmt.Errorf("failed to re-obfuscate fonts: %w", err)
+	}
+
+	err := r.appendChanges()
+	if errors.Is(err, errAppendUnsupported) {
+		err = r.Save(r.file.Name())
+	}
+	if err != nil {
+		return err
+	}
+	return r.reopen()
+}
+
+// reopen reads the archive back from the file it was saved to in place and
+// drops the pending changes, which are now part of the file.
+func (r *Reader) reopen() error {
+	f, err := os.Open(r.file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to reopen file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	z, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to reopen zip reader: %w", err)
+	}
+	r.closer.Close()
+	r.zipReader, r.closer, r.file = z, f, f
+	r.indexEntries(r.foldedEntries != nil)
+
+	r.Replacements = nil
+	r.sources = nil
+	r.removed = nil
+	r.encryption = nil
+	return nil
+}
+
+// appendChanges implements SaveAppend.
+func (r *Reader) appendChanges() error {
+	opfContent, err := r.opfContent()
+	if err != nil {
+		return fmt.Errorf("failed to marshal OPF: %w", err)
+	}
+
+	// Entries to write, in a stable order
+	changed := make(map[string]bool)
+	for name := range r.Replacements {
+		changed[name] = true
+	}
+	for name := range r.sources {
+		changed[name] = true
+	}
+	if original, err := r.readOriginal(r.OpfPath); err != nil || !bytes.Equal(original, opfContent) {
+		changed[r.OpfPath] = true
+	}
+	if changed["mimetype"] || r.removed["mimetype"] {
+		return errAppendUnsupported
+	}
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 && len(r.removed) == 0 {
+		return nil
+	}
+
+	path := r.file.Name()
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := info.Size()
+	dirEnd, err := findDirectoryEnd(r.file, size, directoryEndLen+0xffff)
+	if err != nil {
+		return err
+	}
+	if dirEnd.end() != size {
+		return fmt.Errorf("%w: data after the central directory", errAppendUnsupported)
+	}
+	cd := make([]byte, dirEnd.cdSize)
+	if _, err := r.file.ReadAt(cd, dirEnd.cdOffset); err != nil {
+		return fmt.Errorf("failed to read central directory: %w", err)
+	}
+	records, err := parseCentralDirectory(cd)
+	if err != nil || len(records) != dirEnd.entries {
+		return fmt.Errorf("%w: unreadable central directory", errAppendUnsupported)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file for writing: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := r.appendEntries(f, size, names, opfContent, records, dirEnd); err != nil {
+		// Drop the incomplete tail; the original directory is still in place
+		if terr := f.Truncate(size); terr != nil {
+			return fmt.Errorf("failed to append: %w (restoring the file failed: %w)", err, terr)
+		}
+		return err
+	}
+	return f.Close()
+}
+
+// appendEntries writes the named entries at offset size of f, followed by a
+// central directory listing them together with the kept original records.
+func (r *Reader) appendEntries(f *os.File, size int64, names []string, opfContent []byte, records []centralRecord, dirEnd *directoryEnd) error {
+	// zip.Writer writes the entries to the file; its central directory, which
+	// lists only the new entries, is captured and merged below.
+	out := &appendWriter{w: f}
+	w := zip.NewWriter(out)
+	w.SetOffset(size)
+	for _, name := range names {
+		method := uint16(zip.Deflate)
+		if orig, ok := r.entries[name]; ok {
+			method = orig.Method
+		}
+		if name == r.OpfPath {
+			if err := writeContentWithMethod(w, name, bytes.NewReader(opfContent), method, nil); err != nil {
+				return fmt.Errorf("failed to write OPF: %w", err)
+			}
+			continue
+		}
+		src, ok := r.replacementSource(name)
+		if !ok {
+			continue // removed
+		}
+		if err := r.writeSource(w, name, src, method, nil); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	out.w = &out.dir
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close zip writer: %w", err)
+	}
+
+	// Close also finishes the last entry: split the captured bytes into that
+	// tail, the directory and its end record.
+	buf := out.dir.Bytes()
+	if len(buf) < directoryEndLen {
+		return fmt.Errorf("zip writer wrote no directory")
+	}
+	goEnd := buf[len(buf)-directoryEndLen:]
+	cdSize := int(binary.LittleEndian.Uint32(goEnd[12:]))
+	if binary.LittleEndian.Uint16(goEnd[10:]) == 0xffff || cdSize == 0xffffffff || binary.LittleEndian.Uint32(goEnd[16:]) == 0xffffffff {
+		return fmt.Errorf("%w: zip64 required", errAppendUnsupported)
+	}
+	tail := len(buf) - directoryEndLen - cdSize
+	if binary.LittleEndian.Uint32(goEnd) != directoryEndSignature || tail < 0 {
+		return fmt.Errorf("unexpected zip writer output")
+	}
+	if _, err := f.Write(buf[:tail]); err != nil {
+		return err
+	}
+	cdOffset := size + out.n + int64(tail)
+	added, err := parseCentralDirectory(buf[tail : tail+cdSize])
+	if err != nil {
+		return err
+	}
+	if cdOffset > 0xffffffff || len(records)+len(added) >= 0xffff {
+		return fmt.Errorf("%w: zip64 required", errAppendUnsupported)
+	}
+
+	// The entries must be on disk before a directory references them
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	// Original order; replaced entries keep their position
+	byName := make(map[string]centralRecord, len(added))
+	for _, rec := range added {
+		byName[rec.name] = rec
+	}
+	var dir bytes.Buffer
+	count := 0
+	for _, rec := range records {
+		if rec2, ok := byName[rec.name]; ok {
+			rec = rec2
+			delete(byName, rec.name)
+		} else if r.removed[rec.name] {
+			continue
+		}
+		dir.Write(rec.raw)
+		count++
+	}
+	for _, rec := range added {
+		if _, ok := byName[rec.name]; ok {
+			dir.Write(rec.raw)
+			count++
+		}
+	}
+
+	var end [directoryEndLen]byte
+	binary.LittleEndian.PutUint32(end[0:], directoryEndSignature)
+	binary.LittleEndian.PutUint16(end[8:], uint16(count))
+	binary.LittleEndian.PutUint16(end[10:], uint16(count))
+	binary.LittleEndian.PutUint32(end[12:], uint32(dir.Len()))
+	binary.LittleEndian.PutUint32(end[16:], uint32(cdOffset))
+	binary.LittleEndian.PutUint16(end[20:], uint16(len(dirEnd.comment)))
+	dir.Write(end[:])
+	dir.Write(dirEnd.comment)
+
+	if _, err := f.Write(dir.Bytes()); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// appendWriter counts the bytes written to w. Once w is switched to dir, the
+// output of zip.Writer.Close is captured instead.
+type appendWriter struct {
+	w   io.Writer
+	n   int64
+	dir bytes.Buffer
+}
+
+func (a *appendWriter) Write(p []byte) (int, error) {
+	n, err := a.w.Write(p)
+	if a.w != &a.dir {
+		a.n += int64(n)
+	}
+	return n, err
+}
+
+// readOriginal returns the content of the original archive entry name.
+func (r *Reader) readOriginal(name string) ([]byte, error) {
+	rc, err := r.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// parseCentralDirectory splits raw central directory data into records. It
+// stops at the first byte that does not start a record.
+func parseCentralDirectory(b []byte) ([]centralRecord, error) {
+	var records []centralRecord
+	for len(b) >= 4 && binary.LittleEndian.Uint32(b) == centralDirSignature {
+		if len(b) < centralDirHeaderLen {
+			return nil, fmt.Errorf("truncated central directory")
+		}
+		nameLen := int(binary.LittleEndian.Uint16(b[28:]))
+		n := centralDirHeaderLen + nameLen + int(binary.LittleEndian.Uint16(b[30:])) + int(binary.LittleEndian.Uint16(b[32:]))
+		if len(b) < n {
+			return nil, fmt.Errorf("truncated central directory")
+		}
+		records = append(records, centralRecord{
+			name: string(b[centralDirHeaderLen : centralDirHeaderLen+nameLen]),
+			raw:  b[:n:n],
+		})
+		b = b[n:]
+	}
+	return records, nil
+}
+
+// findDirectoryEnd returns the last end of central directory record within
+// the last limit bytes before size that is immediately preceded by its
+// central directory and yields a readable archive. Zip64 archives are
+// reported as errAppendUnsupported.
+func findDirectoryEnd(f io.ReaderAt, size, limit int64) (*directoryEnd, error) {
+	const chunk = 1 << 20
+	low := max(size-limit, 0)
+	buf := make([]byte, chunk+3)
+	for hi := size; hi > low; {
+		lo := max(hi-chunk, low)
+		b := buf[:min(hi+3, size)-lo]
+		if _, err := f.ReadAt(b, lo); err != nil && err != io.EOF {
+			return nil, err
+		}
+		for i := int(hi-lo) - 1; i >= 0; i-- {
+			if i+4 > len(b) || binary.LittleEndian.Uint32(b[i:]) != directoryEndSignature {
+				continue
+			}
+			if d, err := readDirectoryEnd(f, lo+int64(i), size); err == nil {
+				return d, nil
+			} else if errors.Is(err, errAppendUnsupported) {
+				return nil, err
+			}
+		}
+		hi = lo
+	}
+	return nil, fmt.Errorf("%w: no central directory found", ErrNotZip)
+}
+
+// readDirectoryEnd parses and validates the end of central directory record
+// at offset.
+func readDirectoryEnd(f io.ReaderAt, offset, size int64) (*directoryEnd, error) {
+	var b [directoryEndLen]byte
+	if offset+directoryEndLen > size {
+		return nil, fmt.Errorf("truncated record")
+	}
+	if _, err := f.ReadAt(b[:], offset); err != nil {
+		return nil, err
+	}
+	d := &directoryEnd{
+		offset:   offset,
+		entries:  int(binary.LittleEndian.Uint16(b[10:])),
+		cdSize:   int64(binary.LittleEndian.Uint32(b[12:])),
+		cdOffset: int64(binary.LittleEndian.Uint32(b[16:])),
+		comment:  make([]byte, binary.LittleEndian.Uint16(b[20:])),
+	}
+	if d.entries == 0xffff || d.cdSize == 0xffffffff || d.cdOffset == 0xffffffff {
+		return nil, fmt.Errorf("%w: zip64", errAppendUnsupported)
+	}
+	if d.end() > size || d.cdOffset+d.cdSize != offset {
+		return nil, fmt.Errorf("not a directory end")
+	}
+	if _, err := f.ReadAt(d.comment, offset+directoryEndLen); err != nil {
+		return nil, err
+	}
+	if _, err := zip.NewReader(io.NewSectionReader(f, 0, d.end()), d.end()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Compact rewrites a book saved with SaveAppend to outputPath, dropping the
+// data of replaced and removed entries. A book whose append was interrupted
+// is recovered: the incomplete tail is ignored and the last complete central
+// directory is used.
+func Compact(path, outputPath string) error {
+	r, err := Open(path)
+	if errors.Is(err, ErrNotZip) {
+		r, err = openRecovered(path)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return r.Save(outputPath)
+}
+
+// openRecovered opens path up to the last complete end of central directory
+// record.
+func openRecovered(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	d, err := findDirectoryEnd(f, info.Size(), info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return newReader(f, d.end(), -1, noLimits)
+}
@@ -0,0 +1,213 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAppend(t *testing.T) {
+	path := writeTestEPUB(t, epub2TestFiles())
+	original, _ := os.ReadFile(path)
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	r.Package.SetTitle("Appended Title")
+	if err := r.ReplaceResource("ch1", []byte("<html>new chapter</html>")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RemoveResource("ch2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddResource("Text/extra.xhtml", "application/xhtml+xml", []byte("<html>extra</html>"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveAppend(); err != nil {
+		t.Fatalf("SaveAppend failed: %v", err)
+	}
+	r.Close()
+
+	appended, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(appended, original) {
+		t.Fatal("SaveAppend changed the original bytes")
+	}
+
+	r2, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer r2.Close()
+	if got := r2.Package.GetTitle(); got != "Appended Title" {
+		t.Errorf("Expected appended title, got %q", got)
+	}
+	if r2.zipReader.File[0].Name != "mimetype" {
+		t.Errorf("Expected mimetype first, got %s", r2.zipReader.File[0].Name)
+	}
+	if data, _ := r2.readFile("OEBPS/Text/ch1.xhtml"); string(data) != "<html>new chapter</html>" {
+		t.Errorf("Unexpected replaced content: %q", data)
+	}
+	if data, _ := r2.readFile("OEBPS/Text/extra.xhtml"); string(data) != "<html>extra</html>" {
+		t.Errorf("Unexpected added content: %q", data)
+	}
+	if r2.fileExists("OEBPS/Text/ch2.xhtml") {
+		t.Error("Removed entry still listed")
+	}
+	if rc, _, err := r2.GetCoverImage(); err != nil {
+		t.Errorf("Unchanged entry unreadable: %v", err)
+	} else {
+		rc.Close()
+	}
+
+	// Compaction drops the superseded data
+	compacted := filepath.Join(t.TempDir(), "compacted.epub")
+	if err := Compact(path, compacted); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	info, _ := os.Stat(compacted)
+	if info.Size() >= int64(len(appended)) {
+		t.Errorf("Compacted size %d not below %d", info.Size(), len(appended))
+	}
+	r3, err := Open(compacted)
+	if err != nil {
+		t.Fatalf("Open compacted failed: %v", err)
+	}
+	defer r3.Close()
+	if got := r3.Package.GetTitle(); got != "Appended Title" {
+		t.Errorf("Expected title after compaction, got %q", got)
+	}
+}
+
+func TestSaveAppend_Twice(t *testing.T) {
+	path := writeTestEPUB(t, epub2TestFiles())
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.AddResource("Text/extra.xhtml", "application/xhtml+xml", []byte("<html>extra</html>"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RemoveResource("ch2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveAppend(); err != nil {
+		t.Fatalf("SaveAppend failed: %v", err)
+	}
+	if len(r.Replacements) != 0 || len(r.removed) != 0 {
+		t.Errorf("Pending changes left after SaveAppend: %v, %v", r.Replacements, r.removed)
+	}
+	first, _ := os.ReadFile(path)
+
+	// Nothing is pending, so nothing is appended
+	if err := r.SaveAppend(); err != nil {
+		t.Fatalf("Second SaveAppend failed: %v", err)
+	}
+	if second, _ := os.ReadFile(path); !bytes.Equal(second, first) {
+		t.Errorf("Second SaveAppend without changes grew the file from %d to %d bytes", len(first), len(second))
+	}
+
+	// The Reader sees the appended directory
+	entries, err := r.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	paths := make(map[string]bool)
+	for _, e := range entries {
+		paths[e.Path] = true
+	}
+	if !paths["OEBPS/Text/extra.xhtml"] || paths["OEBPS/Text/ch2.xhtml"] {
+		t.Errorf("Entries do not reflect the appended directory: %v", paths)
+	}
+
+	r.Package.SetTitle("Second Title")
+	if err := r.SaveAppend(); err != nil {
+		t.Fatalf("Third SaveAppend failed: %v", err)
+	}
+	r2, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer r2.Close()
+	if got := r2.Package.GetTitle(); got != "Second Title" {
+		t.Errorf("Expected second title, got %q", got)
+	}
+	if data, _ := r2.readFile("OEBPS/Text/extra.xhtml"); string(data) != "<html>extra</html>" {
+		t.Errorf("Unexpected added content: %q", data)
+	}
+}
+
+func TestSaveAppend_Unchanged(t *testing.T) {
+	path := writeTestEPUB(t, epub2TestFiles())
+	original, _ := os.ReadFile(path)
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	if err := r.SaveAppend(); err != nil {
+		t.Fatalf("SaveAppend failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, original) {
+		t.Error("SaveAppend without changes modified the file")
+	}
+}
+
+func TestSaveAppend_FallsBackToSave(t *testing.T) {
+	path := writeTestEPUB(t, epub2TestFiles())
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte("trailing data"))
+	f.Close()
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	r.Package.SetTitle("Rewritten")
+	if err := r.SaveAppend(); err != nil {
+		t.Fatalf("SaveAppend failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); bytes.HasSuffix(data, []byte("trailing data")) {
+		t.Error("Expected the archive to be rewritten")
+	}
+	r2, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer r2.Close()
+	if got := r2.Package.GetTitle(); got != "Rewritten" {
+		t.Errorf("Expected title, got %q", got)
+	}
+}
+
+func TestCompact_RecoversInterruptedAppend(t *testing.T) {
+	path := writeTestEPUB(t, epub2TestFiles())
+
+	// An append that stopped before its central directory was written
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write(bytes.Repeat([]byte("PK\x03\x04partial"), 16<<10))
+	f.Close()
+	if _, err := Open(path); !errors.Is(err, ErrNotZip) {
+		t.Fatalf("Expected ErrNotZip before recovery, got %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "recovered.epub")
+	if err := Compact(path, out); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	r, err := Open(out)
+	if err != nil {
+		t.Fatalf("Open recovered failed: %v", err)
+	}
+	defer r.Close()
+	if got := r.Package.GetTitle(); got != "Upgrade Test" {
+		t.Errorf("Expected original title, got %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package epub
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corpusDir holds the sample EPUBs of the test suite.
+const corpusDir = "../cmd/test-suite/testdata"
+
+// corpus returns the sample EPUBs that open without error.
+func corpus(b *testing.B) []string {
+	b.Helper()
+	var paths []string
+	filepath.Walk(corpusDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(strings.ToLower(path), ".epub") {
+			return nil
+		}
+		if r, err := Open(path); err == nil {
+			r.Close()
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if len(paths) == 0 {
+		b.Skip("no sample EPUBs in " + corpusDir)
+	}
+	return paths
+}
+
+func benchmarkOpen(b *testing.B, opts OpenOptions, read func(r *Reader)) {
+	paths := corpus(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			r, err := OpenWithOptions(path, opts)
+			if err != nil {
+				b.Fatalf("Open %s failed: %v", path, err)
+			}
+			read(r)
+			r.Close()
+		}
+	}
+}
+
+// BenchmarkOpen measures a full open of every sample book.
+func BenchmarkOpen(b *testing.B) {
+	benchmarkOpen(b, OpenOptions{}, func(r *Reader) {})
+}
+
+// BenchmarkOpenMetadataOnly measures the metadata-only fast path.
+func BenchmarkOpenMetadataOnly(b *testing.B) {
+	benchmarkOpen(b, OpenOptions{MetadataOnly: true}, func(r *Reader) {
+		r.Package.GetTitle()
+	})
+}
+
+// BenchmarkReadMeta measures what `golibri meta --json` reads.
+func BenchmarkReadMeta(b *testing.B) {
+	benchmarkOpen(b, OpenOptions{}, func(r *Reader) {
+		r.Package.GetIdentifiers()
+		if rc, _, err := r.GetCoverImage(); err == nil {
+			rc.Close()
+		}
+		r.IsDRMProtected()
+	})
+}
+
+// BenchmarkFileLookup measures entry lookups by name across every entry.
+func BenchmarkFileLookup(b *testing.B) {
+	paths := corpus(b)
+	var readers []*Reader
+	for _, path := range paths {
+		r, err := Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer r.Close()
+		readers = append(readers, r)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range readers {
+			for _, f := range r.zipReader.File {
+				if !r.fileExists(f.Name) {
+					b.Fatalf("%s not found", f.Name)
+				}
+			}
+		}
+	}
+}
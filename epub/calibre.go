@@ -0,0 +1,151 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Calibre stores custom column values ("user metadata") in the OPF:
+//   - EPUB 3: <meta property="calibre:user_metadata">{"#label": {...}, ...}</meta>
+//   - EPUB 2: <meta name="calibre:user_metadata:#label" content="{...}"/>
+// Each column is a JSON object describing the column; "#value#" holds the value.
+const calibreUserMetadata = "calibre:user_metadata"
+
+// GetCalibreColumn returns the value of the Calibre custom column with the given
+// lookup label (with or without the leading '#').
+func (pkg *Package) GetCalibreColumn(label string) (interface{}, bool) {
+	key := "#" + strings.TrimPrefix(label, "#")
+	for _, m := range pkg.Metadata.Meta {
+		if m.Property == calibreUserMetadata {
+			var columns map[string]map[string]interface{}
+			if err := json.Unmarshal([]byte(m.Value), &columns); err == nil {
+				if col, ok := columns[key]; ok {
+					return col["#value#"], true
+				}
+			}
+		}
+		if m.Name == calibreUserMetadata+":"+key {
+			var col map[string]interface{}
+			if err := json.Unmarshal([]byte(m.Content), &col); err == nil {
+				return col["#value#"], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// SetCalibreColumn sets the value of a Calibre custom column.
+// Existing column definitions are updated in place; otherwise a new definition is
+// added with the given display name and datatype ("int", "float", "text", ...).
+// Calibre applies the value on import when the library has a column with this label.
+func (pkg *Package) SetCalibreColumn(label, name, datatype string, value interface{}) error {
+	label = strings.TrimPrefix(label, "#")
+	key := "#" + label
+	found := false
+
+	// 1. EPUB 3 property-style metadata (one JSON object for all columns)
+	for i := range pkg.Metadata.Meta {
+		m := &pkg.Metadata.Meta[i]
+		if m.Property != calibreUserMetadata {
+			continue
+		}
+		columns := make(map[string]map[string]interface{})
+		if strings.TrimSpace(m.Value) != "" {
+			if err := json.Unmarshal([]byte(m.Value), &columns); err != nil {
+				return fmt.Errorf("invalid calibre:user_metadata: %w", err)
+			}
+		}
+		col, ok := columns[key]
+		if !ok {
+			col = newCalibreColumn(label, name, datatype)
+			columns[key] = col
+		}
+		col["#value#"] = value
+		data, err := marshalCalibreJSON(columns)
+		if err != nil {
+			return err
+		}
+		m.Value = data
+		found = true
+	}
+
+	// 2. EPUB 2 name-style metadata (one meta per column)
+	for i := range pkg.Metadata.Meta {
+		m := &pkg.Metadata.Meta[i]
+		if m.Name != calibreUserMetadata+":"+key {
+			continue
+		}
+		col := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(m.Content), &col); err != nil {
+			col = newCalibreColumn(label, name, datatype)
+		}
+		col["#value#"] = value
+		data, err := marshalCalibreJSON(col)
+		if err != nil {
+			return err
+		}
+		m.Content = data
+		found = true
+	}
+	if found {
+		return nil
+	}
+
+	// 3. Add a new definition in the style of the package version
+	if pkg.isEPUB3() {
+		data, err := marshalCalibreJSON(map[string]interface{}{key: addValue(newCalibreColumn(label, name, datatype), value)})
+		if err != nil {
+			return err
+		}
+		pkg.Metadata.Meta = append(pkg.Metadata.Meta, Meta{Property: calibreUserMetadata, Value: data})
+		return nil
+	}
+	data, err := marshalCalibreJSON(addValue(newCalibreColumn(label, name, datatype), value))
+	if err != nil {
+		return err
+	}
+	pkg.Metadata.Meta = append(pkg.Metadata.Meta, Meta{Name: calibreUserMetadata + ":" + key, Content: data})
+	return nil
+}
+
+// newCalibreColumn returns a column definition in the shape Calibre writes.
+func newCalibreColumn(label, name, datatype string) map[string]interface{} {
+	if name == "" {
+		name = label
+	}
+	return map[string]interface{}{
+		"label":         label,
+		"name":          name,
+		"datatype":      datatype,
+		"kind":          "field",
+		"column":        "value",
+		"is_custom":     true,
+		"is_editable":   true,
+		"is_category":   false,
+		"is_csp":        false,
+		"is_multiple":   map[string]interface{}{},
+		"display":       map[string]interface{}{},
+		"search_terms":  []string{"#" + label},
+		"category_sort": "value",
+		"link_column":   "value",
+		"#extra#":       nil,
+	}
+}
+
+func addValue(col map[string]interface{}, value interface{}) map[string]interface{} {
+	col["#value#"] = value
+	return col
+}
+
+// marshalCalibreJSON encodes v without HTML escaping (the OPF writer escapes XML).
+func marshalCalibreJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("failed to encode calibre:user_metadata: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,119 @@
+package epub
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// SpineEntry is a spine itemref resolved against the manifest.
+type SpineEntry struct {
+	// Index is the position in the spine (0-based).
+	Index int
+
+	// Item is the manifest item referenced by the itemref.
+	Item Item
+
+	// Path is the full zip path of the content document.
+	Path string
+
+	// Linear is false for itemrefs marked linear="no" (auxiliary content).
+	Linear bool
+
+	// Properties lists itemref properties, e.g. "page-spread-left".
+	Properties []string
+}
+
+// HasProperty reports whether the itemref carries the given property.
+func (e SpineEntry) HasProperty(prop string) bool {
+	for _, p := range e.Properties {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
+
+// Spine returns the spine in reading order with each itemref resolved.
+// Itemrefs that point to missing manifest items are skipped. The error is
+// that of loading the manifest of a Reader opened with MetadataOnly.
+func (r *Reader) Spine() ([]SpineEntry, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+	var entries []SpineEntry
+	for i, ref := range pkg.Spine.ItemRefs {
+		item := r.findItem(ref.IDRef)
+		if item == nil {
+			continue
+		}
+		entries = append(entries, SpineEntry{
+			Index:      i,
+			Item:       *item,
+			Path:       r.itemPath(item.Href),
+			Linear:     !strings.EqualFold(strings.TrimSpace(ref.Linear), "no"),
+			Properties: strings.Fields(ref.Properties),
+		})
+	}
+	return entries, nil
+}
+
+// OpenItem opens the content of the manifest item with the given id.
+// Pending replacements are returned instead of the original entry.
+func (r *Reader) OpenItem(id string) (io.ReadCloser, error) {
+	if _, err := r.fullPackage(); err != nil {
+		return nil, err
+	}
+	item := r.findItem(id)
+	if item == nil {
+		return nil, fmt.Errorf("manifest item %s not found", id)
+	}
+	return r.openPath(r.itemPath(item.Href))
+}
+
+// ReadItem reads a resource by href relative to the OPF.
+// The href may be percent-encoded, contain "../" segments or a fragment.
+func (r *Reader) ReadItem(href string) ([]byte, error) {
+	if _, err := r.fullPackage(); err != nil {
+		return nil, err
+	}
+	rc, err := r.openPath(r.itemPath(href))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// ReadFile reads a file by its full path in the container, e.g.
+// "META-INF/container.xml". Pending replacements are returned instead of the
+// original entry.
+func (r *Reader) ReadFile(fullPath string) ([]byte, error) {
+	return r.readFile(fullPath)
+}
+
+// itemPath resolves an OPF-relative href to a full zip path.
+// Percent-decoding is preferred; the literal form is used when only it exists
+// in the archive (some tools store escaped names verbatim).
+func (r *Reader) itemPath(href string) string {
+	decoded := r.resolveHref(href)
+	if r.fileExists(decoded) {
+		return decoded
+	}
+	p, _ := splitRef(href)
+	if literal := path.Join(path.Dir(r.OpfPath), p); literal != decoded && r.fileExists(literal) {
+		return literal
+	}
+	return decoded
+}
+
+// openPath opens the current content of a file by full path.
+func (r *Reader) openPath(fullPath string) (io.ReadCloser, error) {
+	src, ok := r.currentSource(fullPath)
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", fullPath)
+	}
+	return src.Open()
+}
@@ -0,0 +1,196 @@
+package epub
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func spineTestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Spine Test</dc:title>
+    <dc:identifier id="uid">urn:uuid:5678</dc:identifier>
+  </metadata>
+  <manifest>
+    <item id="c1" href="Text/Chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="../Extra/notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="Text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1" properties="page-spread-left"/>
+    <itemref idref="missing"/>
+    <itemref idref="notes" linear="no"/>
+    <itemref idref="c2" properties="page-spread-right rendition:layout-pre-paginated"/>
+  </spine>
+</package>`},
+		{"OEBPS/Text/Chapter 1.xhtml", "<p>one</p>"},
+		{"Extra/notes.xhtml", "<p>notes</p>"},
+		{"OEBPS/Text/ch2.xhtml", "<p>two</p>"},
+	}
+}
+
+func TestSpine(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, spineTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	entries, err := r.Spine()
+	if err != nil {
+		t.Fatalf("Spine failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 spine entries, got %d", len(entries))
+	}
+
+	expected := []struct {
+		id     string
+		path   string
+		index  int
+		linear bool
+	}{
+		{"c1", "OEBPS/Text/Chapter 1.xhtml", 0, true},
+		{"notes", "Extra/notes.xhtml", 2, false},
+		{"c2", "OEBPS/Text/ch2.xhtml", 3, true},
+	}
+	for i, want := range expected {
+		got := entries[i]
+		if got.Item.ID != want.id || got.Path != want.path || got.Index != want.index || got.Linear != want.linear {
+			t.Errorf("Entry %d mismatch: %+v", i, got)
+		}
+	}
+	if !entries[0].HasProperty("page-spread-left") {
+		t.Error("Expected page-spread-left on first entry")
+	}
+	if !entries[2].HasProperty("page-spread-right") || len(entries[2].Properties) != 2 {
+		t.Errorf("Unexpected properties: %v", entries[2].Properties)
+	}
+}
+
+func TestSpine_ManifestError(t *testing.T) {
+	files := coverTestFiles()
+	files[1].Content = strings.Replace(files[1].Content, `href="Text/ch1.xhtml"`, `href="../../ch1.xhtml"`, 1)
+	r, err := OpenWithOptions(writeTestEPUB(t, files), OpenOptions{MetadataOnly: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Spine(); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("Expected ErrUnsafePath, got %v", err)
+	}
+	if _, err := r.ExtractText(TextOptions{}); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("Expected ExtractText to fail with ErrUnsafePath, got %v", err)
+	}
+}
+
+func TestOpenItem(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, spineTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	rc, err := r.OpenItem("c1")
+	if err != nil {
+		t.Fatalf("OpenItem failed: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "<p>one</p>" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+
+	if _, err := r.OpenItem("missing"); err == nil {
+		t.Error("Expected error for unknown id")
+	}
+
+	// Pending replacements are visible
+	if err := r.ReplaceResource("c2", []byte("<p>new</p>")); err != nil {
+		t.Fatalf("ReplaceResource failed: %v", err)
+	}
+	rc, err = r.OpenItem("c2")
+	if err != nil {
+		t.Fatalf("OpenItem failed: %v", err)
+	}
+	data, _ = io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "<p>new</p>" {
+		t.Errorf("Replacement not returned: %q", data)
+	}
+}
+
+func TestReadItem(t *testing.T) {
+	files := spineTestFiles()
+	files = append(files, testFile{"OEBPS/Text/literal%20name.xhtml", "literal"})
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	cases := map[string]string{
+		"Text/Chapter%201.xhtml":    "<p>one</p>",
+		"Text/Chapter 1.xhtml#p1":   "<p>one</p>",
+		"../Extra/notes.xhtml":      "<p>notes</p>",
+		"Text/../Text/ch2.xhtml":    "<p>two</p>",
+		"Text/literal%20name.xhtml": "literal",
+	}
+	for href, want := range cases {
+		data, err := r.ReadItem(href)
+		if err != nil {
+			t.Errorf("ReadItem(%q) failed: %v", href, err)
+			continue
+		}
+		if string(data) != want {
+			t.Errorf("ReadItem(%q) = %q, want %q", href, data, want)
+		}
+	}
+
+	if _, err := r.ReadItem("Text/none.xhtml"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestSpine_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub2/215584.epub",
+		"../cmd/test-suite/testdata/samples/epub3-hybrid/215585.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215714.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			entries, err := r.Spine()
+			if err != nil {
+				t.Fatalf("Spine failed: %v", err)
+			}
+			if len(entries) == 0 {
+				t.Fatal("Empty spine")
+			}
+			for _, e := range entries {
+				data, err := r.ReadItem(e.Item.Href)
+				if err != nil {
+					t.Errorf("ReadItem(%s) failed: %v", e.Item.Href, err)
+				} else if len(data) == 0 {
+					t.Errorf("ReadItem(%s) returned no content", e.Item.Href)
+				}
+			}
+		})
+	}
+}
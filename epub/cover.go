@@ -1,13 +1,117 @@
 package epub
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"net/url"
 	"path"
+	"regexp"
+	"strings"
+
+	"github.com/beevik/etree"
 )
 
 // GetCoverImage returns the content of the cover image and its media type.
 func (r *Reader) GetCoverImage() (io.ReadCloser, string, error) {
+	if _, err := r.fullPackage(); err != nil {
+		return nil, "", err
+	}
+	coverItem, err := r.findCoverItem()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Resolve path relative to OPF
+	// OpfPath is e.g. "OEBPS/content.opf".
+	// coverItem.Href is relative to OPF folder.
+	fullPath := r.resolveHref(coverItem.Href)
+
+	rc, err := r.openFile(fullPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrNoCover, err)
+	}
+
+	return rc, coverItem.MediaType, nil
+}
+
+// HasCover reports whether the book has a cover image that GetCoverImage
+// would return. For a Reader opened with OpenOptions.MetadataOnly the
+// manifest is scanned for the cover item without being parsed, so checking
+// for a cover does not load the package.
+func (r *Reader) HasCover() bool {
+	if r.metadataOnly && r.loadErr == nil {
+		if href, ok := r.scanCoverHref(); ok {
+			return href != "" && r.fileExists(r.resolveHref(href))
+		}
+	}
+	if _, err := r.fullPackage(); err != nil {
+		return false
+	}
+	item, err := r.findCoverItem()
+	return err == nil && r.fileExists(r.resolveHref(item.Href))
+}
+
+// scanCoverHref finds the href of the cover item the way findCoverItem does,
+// reading the manifest items as raw tokens. The href is empty when the book
+// has no cover item; false means the OPF could not be scanned.
+func (r *Reader) scanCoverHref() (string, bool) {
+	data, err := r.readFile(r.OpfPath)
+	if err != nil {
+		return "", false
+	}
+	var coverID string
+	for _, m := range r.Package.Metadata.Meta {
+		if m.Name == "cover" {
+			coverID = m.Content
+			break
+		}
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(preprocessOPF(data, nil)))
+	dec.Strict = false
+	dec.CharsetReader = charsetReader
+	var fallback string
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false
+		}
+		t, ok := tok.(xml.StartElement)
+		if !ok || t.Name.Local != "item" {
+			continue
+		}
+		var id, href, properties string
+		for _, a := range t.Attr {
+			switch a.Name.Local {
+			case "id":
+				id = a.Value
+			case "href":
+				href = a.Value
+			case "properties":
+				properties = a.Value
+			}
+		}
+		switch {
+		case coverID != "":
+			if id == coverID {
+				return href, true
+			}
+		case hasProperty(properties, "cover-image"):
+			return href, true
+		case fallback == "" && (id == "cover" || id == "cover-image"):
+			fallback = href
+		}
+	}
+	return fallback, true
+}
+
+// findCoverItem locates the manifest item holding the cover image.
+func (r *Reader) findCoverItem() (*Item, error) {
 	// Strategy 1: Look for "cover" item in manifest (EPUB 2/3)
 	// Sometimes item id="cover" or id="cover-image"
 	// Or item properties="cover-image" (EPUB 3)
@@ -27,7 +131,7 @@ func (r *Reader) GetCoverImage() (io.ReadCloser, string, error) {
 	// 2. If not found, check Manifest properties (EPUB 3)
 	if coverItemID == "" {
 		for _, item := range r.Package.Manifest.Items {
-			if item.Properties == "cover-image" {
+			if hasProperty(item.Properties, "cover-image") {
 				coverItemID = item.ID
 				break
 			}
@@ -45,34 +149,55 @@ func (r *Reader) GetCoverImage() (io.ReadCloser, string, error) {
 	}
 
 	if coverItemID == "" {
-		return nil, "", fmt.Errorf("no cover found")
+		return nil, ErrNoCover
 	}
 
 	// Find item in manifest
-	var coverItem *Item
 	for i := range r.Package.Manifest.Items {
 		if r.Package.Manifest.Items[i].ID == coverItemID {
-			coverItem = &r.Package.Manifest.Items[i]
-			break
+			return &r.Package.Manifest.Items[i], nil
 		}
 	}
 
-	if coverItem == nil {
-		return nil, "", fmt.Errorf("cover item %s not found in manifest", coverItemID)
-	}
+	return nil, fmt.Errorf("%w: cover item %s not found in manifest", ErrNoCover, coverItemID)
+}
 
-	// Resolve path relative to OPF
-	// OpfPath is e.g. "OEBPS/content.opf".
-	// coverItem.Href is relative to OPF folder.
-	opfDir := path.Dir(r.OpfPath)
-	fullPath := path.Join(opfDir, coverItem.Href)
+// resolveHref converts a manifest href (relative to the OPF) into a full zip path.
+func (r *Reader) resolveHref(href string) string {
+	return resolveRelative(path.Dir(r.OpfPath), href)
+}
 
-	rc, err := r.openFile(fullPath)
-	if err != nil {
-		return nil, "", err
+// resolveRelative joins href onto baseDir, dropping any fragment and
+// decoding percent-escapes so the result matches zip entry names.
+func resolveRelative(baseDir, href string) string {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href = href[:i]
 	}
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Join(baseDir, href)
+}
 
-	return rc, coverItem.MediaType, nil
+// hasProperty reports whether a space-separated properties attribute contains prop.
+func hasProperty(properties, prop string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
+
+// removeProperty drops prop from a space-separated properties attribute.
+func removeProperty(properties, prop string) string {
+	var kept []string
+	for _, p := range strings.Fields(properties) {
+		if p != prop {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, " ")
 }
 
 // Note: SetCover logic is complex because it involves writing a NEW file into the zip
@@ -92,67 +217,424 @@ func (r *Reader) GetCoverImage() (io.ReadCloser, string, error) {
 
 // SetCover replaces or adds a cover image.
 // It updates the Manifest, Metadata, and adds the file to Replacements.
+//
+// When a cover already exists and the format matches, its path is reused.
+// Otherwise the image is written to a unique path next to the old cover
+// (or the OPF), links to the old image are updated throughout the book, and
+// the superseded file is removed.
+//
+// If the manifest of a Reader opened with OpenOptions.MetadataOnly cannot be
+// loaded, the cover is left unchanged and Save returns the error.
 func (r *Reader) SetCover(data []byte, mediaType string) {
-	// 1. Determine path for new cover
-	// If cover exists, overwrite it.
-	// If not, create "cover.jpg" (or matching ext) in OEBPS folder.
-
-	opfDir := path.Dir(r.OpfPath)
-	// Simple strategy: use "cover.jpg" or "cover.png" based on mediatype
-	ext := ".jpg"
-	if mediaType == "image/png" {
-		ext = ".png"
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return
 	}
-	// ... add other types if needed
+	var itemID string
+	if coverItem, err := r.findCoverItem(); err == nil {
+		// 1. Existing cover: reuse or relocate
+		oldPath := r.resolveHref(coverItem.Href)
+		itemID = coverItem.ID
 
-	fileName := "cover" + ext
-	fullPath := path.Join(opfDir, fileName)
+		if coverFormatMatches(coverItem, mediaType) {
+			r.setReplacement(oldPath, data)
+			coverItem.MediaType = mediaType
+		} else {
+			newPath := r.uniqueFilePath(path.Dir(oldPath), "cover", coverExtension(mediaType))
 
-	// 2. Add to Replacements
-	if r.Replacements == nil {
-		r.Replacements = make(map[string][]byte)
-	}
-	r.Replacements[fullPath] = data
+			r.setReplacement(newPath, data)
+			coverItem.Href = encodedHref(path.Dir(r.OpfPath), newPath)
+			coverItem.MediaType = mediaType
 
-	// 3. Update Manifest
-	// Check if item exists
-	var itemID string
-	found := false
-	for i, item := range r.Package.Manifest.Items {
-		if item.Properties == "cover-image" || item.ID == "cover" {
-			// Update existing item
-			r.Package.Manifest.Items[i].Href = fileName
-			r.Package.Manifest.Items[i].MediaType = mediaType
-			itemID = item.ID
-			found = true
-			break
+			// Point every page and stylesheet (cover page, title page, nav,
+			// CSS backgrounds) at the new image
+			r.rewriteBookRefs(map[string]string{oldPath: newPath})
+
+			// Drop the superseded file unless another manifest item still uses it
+			if !r.isManifestPath(oldPath) {
+				r.removeFile(oldPath)
+			}
 		}
-	}
+	} else {
+		// 2. No cover yet: create a new item next to the OPF
+		opfDir := path.Dir(r.OpfPath)
+		newPath := r.uniqueFilePath(opfDir, "cover", coverExtension(mediaType))
+		r.setReplacement(newPath, data)
 
-	if !found {
-		// Create new item
-		itemID = "cover-image"
+		itemID = r.uniqueItemID("cover-image")
 		newItem := Item{
 			ID:         itemID,
-			Href:       fileName,
+			Href:       encodedHref(opfDir, newPath),
 			MediaType:  mediaType,
 			Properties: "cover-image", // EPUB 3
 		}
-		r.Package.Manifest.Items = append(r.Package.Manifest.Items, newItem)
+		pkg.Manifest.Items = append(pkg.Manifest.Items, newItem)
 	}
 
-	// 4. Update Metadata (EPUB 2 compatibility)
+	// 3. Update Metadata (EPUB 2 compatibility)
 	// Ensure <meta name="cover" content="item-id" /> exists
 	metaFound := false
-	for i, m := range r.Package.Metadata.Meta {
+	for i, m := range pkg.Metadata.Meta {
 		if m.Name == "cover" {
-			r.Package.Metadata.Meta[i].Content = itemID
+			pkg.Metadata.Meta[i].Content = itemID
 			metaFound = true
 			break
 		}
 	}
 
 	if !metaFound {
-		r.Package.setLegacyMeta("cover", itemID)
+		pkg.setLegacyMeta("cover", itemID)
+	}
+}
+
+// coverExtensions maps image media types to their preferred file extension.
+var coverExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+}
+
+// coverExtension returns the file extension for an image media type.
+func coverExtension(mediaType string) string {
+	if ext, ok := coverExtensions[normalizeImageType(mediaType)]; ok {
+		return ext
 	}
+	return ".jpg"
+}
+
+// normalizeImageType folds common non-standard image media types.
+func normalizeImageType(mediaType string) string {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "image/jpg" || mediaType == "image/pjpeg" {
+		return "image/jpeg"
+	}
+	return mediaType
+}
+
+// coverFormatMatches reports whether new cover data of mediaType can reuse the item's path.
+func coverFormatMatches(item *Item, mediaType string) bool {
+	want := normalizeImageType(mediaType)
+	ext := strings.ToLower(path.Ext(item.Href))
+	switch want {
+	case "image/jpeg":
+		return ext == ".jpg" || ext == ".jpeg"
+	default:
+		return ext == coverExtension(want)
+	}
+}
+
+// isManifestPath reports whether any manifest item resolves to fullPath.
+func (r *Reader) isManifestPath(fullPath string) bool {
+	for _, item := range r.Package.Manifest.Items {
+		if r.resolveHref(item.Href) == fullPath {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExists reports whether fullPath is present in the edited archive.
+func (r *Reader) fileExists(fullPath string) bool {
+	if _, ok := r.replacementSource(fullPath); ok {
+		return true
+	}
+	if r.removed[fullPath] {
+		return false
+	}
+	_, ok := r.zipEntry(fullPath)
+	return ok
+}
+
+// uniqueFilePath returns dir/base+ext, adding a numeric suffix until the path
+// collides with neither an archive entry nor a manifest item.
+func (r *Reader) uniqueFilePath(dir, base, ext string) string {
+	for i := 0; ; i++ {
+		name := base + ext
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		candidate := path.Join(dir, name)
+		if !r.fileExists(candidate) && !r.isManifestPath(candidate) {
+			return candidate
+		}
+	}
+}
+
+// uniqueItemID returns base, or base with a numeric suffix, unused by the manifest.
+func (r *Reader) uniqueItemID(base string) string {
+	used := make(map[string]bool)
+	for _, item := range r.Package.Manifest.Items {
+		used[item.ID] = true
+	}
+	for i := 0; ; i++ {
+		candidate := base
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// encodedHref is relativeHref percent-encoded for use as an href, so that
+// file names with spaces, "#" or "%" stay valid in the manifest and in links.
+func encodedHref(baseDir, fullPath string) string {
+	href := encodeRefPath(relativeHref(baseDir, fullPath), true)
+	if schemeRe.MatchString(href) {
+		href = "./" + href // "a:b.xhtml" is not a URL
+	}
+	return href
+}
+
+// relativeHref expresses fullPath relative to baseDir ("." for the archive root).
+func relativeHref(baseDir, fullPath string) string {
+	var base []string
+	if baseDir != "." && baseDir != "" {
+		base = strings.Split(baseDir, "/")
+	}
+	target := strings.Split(fullPath, "/")
+
+	i := 0
+	for i < len(base) && i < len(target)-1 && base[i] == target[i] {
+		i++
+	}
+
+	var parts []string
+	for range base[i:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, target[i:]...)
+	return strings.Join(parts, "/")
+}
+
+// RemoveCover deletes the cover image and every reference to it.
+// It removes the image file and its manifest item, <meta name="cover">,
+// the cover-image property, the cover XHTML page with its spine itemref,
+// and guide/landmark entries pointing at either file.
+func (r *Reader) RemoveCover() error {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return err
+	}
+	coverItem, err := r.findCoverItem()
+	if err != nil {
+		return err
+	}
+
+	imagePath := r.resolveHref(coverItem.Href)
+	removedPaths := map[string]bool{imagePath: true}
+	removedIDs := map[string]bool{coverItem.ID: true}
+
+	// 1. Find the cover XHTML page (guide, landmarks, or an image-only first spine page)
+	for _, pagePath := range r.coverPagePaths(imagePath) {
+		removedPaths[pagePath] = true
+	}
+	for _, item := range pkg.Manifest.Items {
+		if removedPaths[r.resolveHref(item.Href)] {
+			removedIDs[item.ID] = true
+		}
+	}
+
+	// 2. Clean landmarks in the EPUB 3 navigation document
+	if err := r.removeLandmarks(removedPaths); err != nil {
+		return fmt.Errorf("failed to update navigation document: %w", err)
+	}
+
+	// 3. Drop files, manifest items, spine itemrefs and guide references
+	for id := range removedIDs {
+		if err := r.RemoveResource(id); err != nil {
+			return err
+		}
+	}
+	for p := range removedPaths {
+		if !r.isManifestPath(p) {
+			r.removeFile(p)
+		}
+	}
+
+	// 4. Clear cover-image from any remaining item
+	for i := range pkg.Manifest.Items {
+		item := &pkg.Manifest.Items[i]
+		item.Properties = removeProperty(item.Properties, "cover-image")
+	}
+
+	// 5. Drop remaining guide cover references and <meta name="cover">
+	if pkg.Guide != nil {
+		var refs []Reference
+		for _, ref := range pkg.Guide.References {
+			if !strings.EqualFold(ref.Type, "cover") {
+				refs = append(refs, ref)
+			}
+		}
+		pkg.Guide.References = refs
+	}
+	var metas []Meta
+	for _, m := range pkg.Metadata.Meta {
+		if m.Name != "cover" {
+			metas = append(metas, m)
+		}
+	}
+	pkg.Metadata.Meta = metas
+
+	return nil
+}
+
+// coverPagePaths returns the full paths of XHTML pages that only display the cover.
+func (r *Reader) coverPagePaths(imagePath string) []string {
+	seen := make(map[string]bool)
+	var pages []string
+	add := func(p string) {
+		if p != "" && p != imagePath && !seen[p] {
+			seen[p] = true
+			pages = append(pages, p)
+		}
+	}
+
+	// EPUB 2 guide: <reference type="cover" href="...">
+	if r.Package.Guide != nil {
+		for _, ref := range r.Package.Guide.References {
+			if strings.EqualFold(ref.Type, "cover") {
+				add(r.resolveHref(ref.Href))
+			}
+		}
+	}
+
+	// EPUB 3 landmarks: <a epub:type="cover" href="...">
+	if navItem := r.navItem(); navItem != nil {
+		navPath := r.resolveHref(navItem.Href)
+		if doc, err := r.readXMLDocument(navPath); err == nil {
+			for _, a := range landmarkLinks(doc) {
+				if hasProperty(a.SelectAttrValue("epub:type", ""), "cover") {
+					add(resolveRelative(path.Dir(navPath), a.SelectAttrValue("href", "")))
+				}
+			}
+		}
+	}
+
+	// Heuristic: first spine page that shows nothing but the cover image
+	if len(r.Package.Spine.ItemRefs) > 0 {
+		for _, item := range r.Package.Manifest.Items {
+			if item.ID != r.Package.Spine.ItemRefs[0].IDRef {
+				continue
+			}
+			pagePath := r.resolveHref(item.Href)
+			if data, err := r.readFile(pagePath); err == nil && isImageOnlyPage(data, path.Dir(pagePath), imagePath) {
+				add(pagePath)
+			}
+			break
+		}
+	}
+
+	return pages
+}
+
+// navItem returns the EPUB 3 navigation document item, if any.
+func (r *Reader) navItem() *Item {
+	for i := range r.Package.Manifest.Items {
+		if hasProperty(r.Package.Manifest.Items[i].Properties, "nav") {
+			return &r.Package.Manifest.Items[i]
+		}
+	}
+	return nil
+}
+
+// readXMLDocument parses an XML/XHTML file from the archive with etree.
+func (r *Reader) readXMLDocument(name string) (*etree.Document, error) {
+	data, err := r.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	doc.ReadSettings.CharsetReader = charsetReader
+	doc.ReadSettings.Permissive = true
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// landmarkLinks returns every <a> inside <nav epub:type="landmarks">.
+func landmarkLinks(doc *etree.Document) []*etree.Element {
+	var links []*etree.Element
+	for _, nav := range doc.FindElements("//nav") {
+		if hasProperty(nav.SelectAttrValue("epub:type", ""), "landmarks") {
+			links = append(links, nav.FindElements(".//a")...)
+		}
+	}
+	return links
+}
+
+// removeLandmarks drops landmark entries that are cover links or point at removed files.
+func (r *Reader) removeLandmarks(removedPaths map[string]bool) error {
+	navItem := r.navItem()
+	if navItem == nil {
+		return nil
+	}
+	navPath := r.resolveHref(navItem.Href)
+	if removedPaths[navPath] {
+		return nil
+	}
+	doc, err := r.readXMLDocument(navPath)
+	if err != nil {
+		// Unreadable nav documents are left untouched
+		return nil
+	}
+
+	changed := false
+	for _, a := range landmarkLinks(doc) {
+		target := resolveRelative(path.Dir(navPath), a.SelectAttrValue("href", ""))
+		if !hasProperty(a.SelectAttrValue("epub:type", ""), "cover") && !removedPaths[target] {
+			continue
+		}
+		// Remove the enclosing <li> (or the link itself if it is not in a list)
+		entry := a
+		if parent := a.Parent(); parent != nil && parent.Tag == "li" {
+			entry = parent
+		}
+		if parent := entry.Parent(); parent != nil {
+			parent.RemoveChild(entry)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return err
+	}
+	r.setReplacement(navPath, data)
+	return nil
+}
+
+var (
+	imageRefRe = regexp.MustCompile(`(?i)(?:src|href|xlink:href)\s*=\s*["']([^"']+)["']`)
+	bodyRe     = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	tagRe      = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// isImageOnlyPage reports whether an XHTML page references imagePath and has no visible text.
+func isImageOnlyPage(data []byte, baseDir, imagePath string) bool {
+	body := data
+	if m := bodyRe.FindSubmatch(data); m != nil {
+		body = m[1]
+	}
+
+	referencesImage := false
+	for _, m := range imageRefRe.FindAllSubmatch(body, -1) {
+		if resolveRelative(baseDir, string(m[1])) == imagePath {
+			referencesImage = true
+			break
+		}
+	}
+	if !referencesImage {
+		return false
+	}
+
+	text := tagRe.ReplaceAll(body, nil)
+	return len(strings.TrimSpace(string(text))) == 0
 }
@@ -0,0 +1,495 @@
+package epub
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testFile is a single zip entry used by writeTestEPUB.
+type testFile struct {
+	Name    string
+	Content string
+}
+
+// writeTestEPUB creates a temporary EPUB with mimetype first and the given entries in order.
+func writeTestEPUB(t *testing.T, files []testFile) string {
+	t.Helper()
+	f, err := os.CreateTemp("", "test-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	z := zip.NewWriter(f)
+	m, _ := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	m.Write([]byte("application/epub+zip"))
+	for _, tf := range files {
+		w, err := z.Create(tf.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(tf.Content))
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+// saveAndReopen saves the reader to a temp file and opens the result.
+func saveAndReopen(t *testing.T, r *Reader) *Reader {
+	t.Helper()
+	out, err := os.CreateTemp("", "out-*.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outPath := out.Name()
+	out.Close()
+	t.Cleanup(func() { os.Remove(outPath) })
+
+	if err := r.Save(outPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	r2, err := Open(outPath)
+	if err != nil {
+		t.Fatalf("Re-open failed: %v", err)
+	}
+	t.Cleanup(func() { r2.Close() })
+	return r2
+}
+
+// zipEntryNames lists all file entry names of the reader's underlying archive.
+func zipEntryNames(r *Reader) map[string]bool {
+	names := make(map[string]bool)
+	for _, f := range r.zipReader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		names[f.Name] = true
+	}
+	return names
+}
+
+const testContainerXML = `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`
+
+func coverTestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Cover Test</dc:title>
+    <dc:identifier id="uid">urn:uuid:1234</dc:identifier>
+    <meta name="cover" content="cover-img"/>
+  </metadata>
+  <manifest>
+    <item id="cover-img" href="Images/cover.jpg" media-type="image/jpeg" properties="cover-image"/>
+    <item id="cover-page" href="Text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="nav" href="Text/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ch1" href="Text/ch1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover-page"/>
+    <itemref idref="ch1"/>
+  </spine>
+  <guide>
+    <reference type="cover" title="Cover" href="Text/cover.xhtml"/>
+    <reference type="text" title="Start" href="Text/ch1.xhtml"/>
+  </guide>
+</package>`},
+		{"OEBPS/Images/cover.jpg", "\xFF\xD8\xFF\xE0"},
+		{"OEBPS/Text/cover.xhtml", `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Cover</title></head>
+<body><div><img src="../Images/cover.jpg" alt=""/></div></body></html>`},
+		{"OEBPS/Text/nav.xhtml", `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><head><title>Nav</title></head>
+<body>
+<nav epub:type="toc"><ol><li><a href="ch1.xhtml">Chapter 1</a></li></ol></nav>
+<nav epub:type="landmarks"><ol>
+<li><a epub:type="cover" href="cover.xhtml">Cover</a></li>
+<li><a epub:type="bodymatter" href="ch1.xhtml">Start</a></li>
+</ol></nav>
+</body></html>`},
+		{"OEBPS/Text/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Hello</p></body></html>`},
+	}
+}
+
+func TestRemoveCover(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, coverTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := r.RemoveCover(); err != nil {
+		t.Fatalf("RemoveCover failed: %v", err)
+	}
+
+	r2 := saveAndReopen(t, r)
+
+	if _, _, err := r2.GetCoverImage(); err == nil {
+		t.Error("Cover still found after RemoveCover")
+	}
+
+	names := zipEntryNames(r2)
+	for _, gone := range []string{"OEBPS/Images/cover.jpg", "OEBPS/Text/cover.xhtml"} {
+		if names[gone] {
+			t.Errorf("%s still present in archive", gone)
+		}
+	}
+	if !names["OEBPS/Text/ch1.xhtml"] {
+		t.Error("Unrelated chapter was removed")
+	}
+
+	for _, item := range r2.Package.Manifest.Items {
+		if item.ID == "cover-img" || item.ID == "cover-page" {
+			t.Errorf("Manifest item %s not removed", item.ID)
+		}
+	}
+	if len(r2.Package.Spine.ItemRefs) != 1 || r2.Package.Spine.ItemRefs[0].IDRef != "ch1" {
+		t.Errorf("Unexpected spine: %+v", r2.Package.Spine.ItemRefs)
+	}
+	for _, m := range r2.Package.Metadata.Meta {
+		if m.Name == "cover" {
+			t.Error("meta name=cover not removed")
+		}
+	}
+	if r2.Package.Guide == nil || len(r2.Package.Guide.References) != 1 || r2.Package.Guide.References[0].Type != "text" {
+		t.Errorf("Unexpected guide: %+v", r2.Package.Guide)
+	}
+
+	nav, err := r2.readFile("OEBPS/Text/nav.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(nav), "cover.xhtml") {
+		t.Errorf("Landmark to cover page not removed:\n%s", nav)
+	}
+	if !strings.Contains(string(nav), `epub:type="bodymatter"`) {
+		t.Errorf("Unrelated landmark was removed:\n%s", nav)
+	}
+}
+
+func TestRemoveCover_NoCover(t *testing.T) {
+	files := []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<package xmlns="http://www.idpf.org/2007/opf" version="2.0"><metadata/><manifest/><spine/></package>`},
+	}
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := r.RemoveCover(); err == nil {
+		t.Error("Expected error when no cover exists")
+	}
+}
+
+func TestRemoveCover_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub2/215584.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215864.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			rc, _, err := r.GetCoverImage()
+			if err != nil {
+				t.Skipf("sample has no cover: %v", err)
+			}
+			io.Copy(io.Discard, rc)
+			rc.Close()
+
+			if err := r.RemoveCover(); err != nil {
+				t.Fatalf("RemoveCover failed: %v", err)
+			}
+			r2 := saveAndReopen(t, r)
+			if _, _, err := r2.GetCoverImage(); err == nil {
+				t.Error("Cover still found after RemoveCover")
+			}
+			if r2.Package.GetTitle() != r.Package.GetTitle() {
+				t.Error("Title changed by RemoveCover")
+			}
+		})
+	}
+}
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n")
+
+func TestSetCover_ReusesPathWhenFormatMatches(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, coverTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	newJPEG := []byte("\xFF\xD8\xFF\xE1new")
+	r.SetCover(newJPEG, "image/jpeg")
+	r2 := saveAndReopen(t, r)
+
+	item, err := r2.findCoverItem()
+	if err != nil {
+		t.Fatalf("Cover not found: %v", err)
+	}
+	if item.Href != "Images/cover.jpg" {
+		t.Errorf("Expected href to be reused, got %s", item.Href)
+	}
+	data, _ := r2.readFile("OEBPS/Images/cover.jpg")
+	if string(data) != string(newJPEG) {
+		t.Error("Cover content not replaced")
+	}
+	if zipEntryNames(r2)["OEBPS/cover.jpg"] {
+		t.Error("Unexpected extra cover file next to the OPF")
+	}
+}
+
+func TestSetCover_FormatChangeRemovesOldFile(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, coverTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.SetCover(testPNG, "image/png")
+	r2 := saveAndReopen(t, r)
+
+	item, err := r2.findCoverItem()
+	if err != nil {
+		t.Fatalf("Cover not found: %v", err)
+	}
+	if item.Href != "Images/cover.png" || item.MediaType != "image/png" {
+		t.Errorf("Unexpected cover item: %+v", item)
+	}
+	names := zipEntryNames(r2)
+	if names["OEBPS/Images/cover.jpg"] {
+		t.Error("Old cover file left as orphan")
+	}
+	if !names["OEBPS/Images/cover.png"] {
+		t.Error("New cover file missing")
+	}
+	page, _ := r2.readFile("OEBPS/Text/cover.xhtml")
+	if !strings.Contains(string(page), `src="../Images/cover.png"`) {
+		t.Errorf("Cover page not updated:\n%s", page)
+	}
+}
+
+func TestSetCover_FormatChangeRewritesAllRefs(t *testing.T) {
+	files := coverTestFiles()
+	files[1].Content = strings.Replace(files[1].Content,
+		`<item id="ch1"`,
+		`<item id="title" href="Text/title.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="Styles/style.css" media-type="text/css"/>
+    <item id="ch1"`, 1)
+	files = append(files,
+		testFile{"OEBPS/Text/title.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><img src="../Images/cover.jpg"/><h1>Title</h1></body></html>`},
+		testFile{"OEBPS/Styles/style.css", `body.cover { background: url("../Images/cover.jpg") no-repeat; }`})
+
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.SetCover(testPNG, "image/png")
+	r2 := saveAndReopen(t, r)
+
+	if zipEntryNames(r2)["OEBPS/Images/cover.jpg"] {
+		t.Error("Old cover file left as orphan")
+	}
+	for name, want := range map[string]string{
+		"OEBPS/Text/cover.xhtml": `src="../Images/cover.png"`,
+		"OEBPS/Text/title.xhtml": `src="../Images/cover.png"`,
+		"OEBPS/Styles/style.css": `url("../Images/cover.png")`,
+	} {
+		data, _ := r2.readFile(name)
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s not updated:\n%s", name, data)
+		}
+	}
+}
+
+func TestSetCover_ManifestError(t *testing.T) {
+	files := coverTestFiles()
+	files[1].Content = strings.Replace(files[1].Content, `href="Images/cover.jpg"`, `href="../../etc/passwd"`, 1)
+	r, err := OpenWithOptions(writeTestEPUB(t, files), OpenOptions{MetadataOnly: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.SetCover(testPNG, "image/png")
+	if len(r.Replacements) != 0 {
+		t.Errorf("Expected the cover to be left unchanged, got %v", r.Replacements)
+	}
+	out := filepath.Join(t.TempDir(), "out.epub")
+	if err := r.Save(out); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("Expected Save to fail with ErrUnsafePath, got %v", err)
+	}
+}
+
+func TestHasCover_MetadataOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(opf string) string
+		removed string
+		want    bool
+	}{
+		{"meta cover", func(opf string) string { return opf }, "", true},
+		{"cover-image property", func(opf string) string {
+			return strings.Replace(opf, `<meta name="cover" content="cover-img"/>`, "", 1)
+		}, "", true},
+		{"cover id", func(opf string) string {
+			opf = strings.Replace(opf, `<meta name="cover" content="cover-img"/>`, "", 1)
+			opf = strings.Replace(opf, ` properties="cover-image"`, "", 1)
+			return strings.Replace(opf, `id="cover-img"`, `id="cover-image"`, 1)
+		}, "", true},
+		{"meta names missing item", func(opf string) string {
+			return strings.Replace(opf, `content="cover-img"`, `content="nothing"`, 1)
+		}, "", false},
+		{"no cover item", func(opf string) string {
+			opf = strings.Replace(opf, `<meta name="cover" content="cover-img"/>`, "", 1)
+			return strings.Replace(opf, ` properties="cover-image"`, "", 1)
+		}, "", false},
+		{"missing file", func(opf string) string { return opf }, "OEBPS/Images/cover.jpg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []testFile
+			for _, f := range coverTestFiles() {
+				if f.Name == tt.removed {
+					continue
+				}
+				if f.Name == "OEBPS/content.opf" {
+					f.Content = tt.edit(f.Content)
+				}
+				files = append(files, f)
+			}
+			path := writeTestEPUB(t, files)
+
+			r, err := OpenWithOptions(path, OpenOptions{MetadataOnly: true})
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+			if got := r.HasCover(); got != tt.want {
+				t.Errorf("HasCover() = %v, want %v", got, tt.want)
+			}
+			if len(r.Package.Manifest.Items) != 0 {
+				t.Error("HasCover loaded the manifest")
+			}
+
+			// Same answer as a fully parsed package
+			full, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer full.Close()
+			if got := full.HasCover(); got != tt.want {
+				t.Errorf("HasCover() on full package = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCover_AvoidsPathCollision(t *testing.T) {
+	files := coverTestFiles()
+	files[1].Content = strings.Replace(files[1].Content,
+		`<item id="ch1"`,
+		`<item id="diagram" href="Images/cover.png" media-type="image/png"/>
+    <item id="ch1"`, 1)
+	files = append(files, testFile{"OEBPS/Images/cover.png", "diagram"})
+
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.SetCover(testPNG, "image/png")
+	r2 := saveAndReopen(t, r)
+
+	diagram, _ := r2.readFile("OEBPS/Images/cover.png")
+	if string(diagram) != "diagram" {
+		t.Error("Existing resource was overwritten by the new cover")
+	}
+	item, err := r2.findCoverItem()
+	if err != nil {
+		t.Fatalf("Cover not found: %v", err)
+	}
+	if item.Href != "Images/cover-1.png" {
+		t.Errorf("Expected unique cover path, got %s", item.Href)
+	}
+	data, _ := r2.readFile("OEBPS/Images/cover-1.png")
+	if string(data) != string(testPNG) {
+		t.Error("New cover content missing")
+	}
+}
+
+func TestSetCover_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub2/215584.epub",
+		"../cmd/test-suite/testdata/samples/epub2/215591.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215864.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215714.epub",
+	}
+	for _, sample := range samples {
+		for _, mediaType := range []string{"image/jpeg", "image/png"} {
+			t.Run(sample+"/"+mediaType, func(t *testing.T) {
+				if _, err := os.Stat(sample); err != nil {
+					t.Skip("sample not available")
+				}
+				r, err := Open(sample)
+				if err != nil {
+					t.Fatalf("Open failed: %v", err)
+				}
+				defer r.Close()
+
+				oldItem, err := r.findCoverItem()
+				if err != nil {
+					t.Skipf("sample has no cover: %v", err)
+				}
+				oldPath := r.resolveHref(oldItem.Href)
+				entriesBefore := len(zipEntryNames(r))
+
+				data := append([]byte(mediaType), 0x00)
+				r.SetCover(data, mediaType)
+				r2 := saveAndReopen(t, r)
+
+				rc, gotType, err := r2.GetCoverImage()
+				if err != nil {
+					t.Fatalf("Cover not found after SetCover: %v", err)
+				}
+				got, _ := io.ReadAll(rc)
+				rc.Close()
+				if string(got) != string(data) || gotType != mediaType {
+					t.Errorf("Cover mismatch: type=%s", gotType)
+				}
+
+				names := zipEntryNames(r2)
+				newItem, _ := r2.findCoverItem()
+				newPath := r2.resolveHref(newItem.Href)
+				if newPath != oldPath && names[oldPath] && !r2.isManifestPath(oldPath) {
+					t.Errorf("Old cover %s left as orphan", oldPath)
+				}
+				if len(names) != entriesBefore {
+					t.Errorf("Entry count changed: before=%d after=%d", entriesBefore, len(names))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package epub
+
+import (
+	"archive/zip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is one difference found by Package.Diff. Old or New is empty when
+// the value is missing on that side.
+type Change struct {
+	// Field names the value: a metadata field ("title", "authors", ...), an
+	// identifier ("identifiers.isbn"), a manifest item ("manifest.<id>"),
+	// "spine", "version" or "unique-identifier".
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Diff compares pkg with other and returns the changes from pkg to other, in
+// a stable order: package attributes, metadata, identifiers by scheme,
+// manifest items by id, then the spine.
+func (pkg *Package) Diff(other *Package) []Change {
+	var changes []Change
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, Change{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("version", pkg.Version, other.Version)
+	add("unique-identifier", pkg.UniqueIdentifier, other.UniqueIdentifier)
+
+	// Metadata, named like the fields of `golibri meta --json`
+	add("title", pkg.GetTitle(), other.GetTitle())
+	add("authors", strings.Join(pkg.GetAuthors(), " & "), strings.Join(other.GetAuthors(), " & "))
+	add("publisher", pkg.GetPublisher(), other.GetPublisher())
+	add("published", pkg.GetPublishDate(), other.GetPublishDate())
+	add("language", pkg.GetLanguage(), other.GetLanguage())
+	add("series", pkg.GetSeries(), other.GetSeries())
+	add("series_index", pkg.GetSeriesIndex(), other.GetSeriesIndex())
+	add("tags", strings.Join(pkg.GetSubjects(), ", "), strings.Join(other.GetSubjects(), ", "))
+	add("rating", formatRating(pkg.GetRating()), formatRating(other.GetRating()))
+	add("producer", pkg.GetProducer(), other.GetProducer())
+	add("comments", pkg.GetDescription(), other.GetDescription())
+
+	oldIDs, newIDs := pkg.identifiersByScheme(), other.identifiersByScheme()
+	for _, scheme := range sortedKeys(oldIDs, newIDs) {
+		add("identifiers."+scheme, oldIDs[scheme], newIDs[scheme])
+	}
+
+	oldItems, newItems := pkg.manifestSummary(), other.manifestSummary()
+	for _, id := range sortedKeys(oldItems, newItems) {
+		add("manifest."+id, oldItems[id], newItems[id])
+	}
+
+	add("spine", pkg.spineSummary(), other.spineSummary())
+	return changes
+}
+
+// File change statuses reported in FileChange.Status.
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+// FileChange is a zip entry that differs between two books, compared by the
+// CRC-32 and size recorded in the zip headers.
+type FileChange struct {
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	OldCRC32 uint32 `json:"old_crc32,omitempty"`
+	NewCRC32 uint32 `json:"new_crc32,omitempty"`
+	OldSize  uint64 `json:"old_size,omitempty"`
+	NewSize  uint64 `json:"new_size,omitempty"`
+}
+
+// BookDiff is the result of Reader.Diff.
+type BookDiff struct {
+	Package []Change     `json:"package"`
+	Files   []FileChange `json:"files"`
+}
+
+// Empty reports whether the books are the same.
+func (d *BookDiff) Empty() bool {
+	return len(d.Package) == 0 && len(d.Files) == 0
+}
+
+// Diff compares the book with other: the packages with Package.Diff, and the
+// archive entries by the zip headers, sorted by path. Pending changes are not
+// part of the file comparison.
+func (r *Reader) Diff(other *Reader) (*BookDiff, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := other.fullPackage(); err != nil {
+		return nil, err
+	}
+	d := &BookDiff{Package: pkg.Diff(other.Package)}
+
+	oldFiles, newFiles := r.fileHeaders(), other.fileHeaders()
+	paths := make([]string, 0, len(oldFiles)+len(newFiles))
+	for name := range oldFiles {
+		paths = append(paths, name)
+	}
+	for name := range newFiles {
+		if _, ok := oldFiles[name]; !ok {
+			paths = append(paths, name)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, name := range paths {
+		o, inOld := oldFiles[name]
+		n, inNew := newFiles[name]
+		switch {
+		case !inNew:
+			d.Files = append(d.Files, FileChange{Path: name, Status: FileRemoved, OldCRC32: o.CRC32, OldSize: o.UncompressedSize64})
+		case !inOld:
+			d.Files = append(d.Files, FileChange{Path: name, Status: FileAdded, NewCRC32: n.CRC32, NewSize: n.UncompressedSize64})
+		case o.CRC32 != n.CRC32 || o.UncompressedSize64 != n.UncompressedSize64:
+			d.Files = append(d.Files, FileChange{Path: name, Status: FileModified,
+				OldCRC32: o.CRC32, NewCRC32: n.CRC32, OldSize: o.UncompressedSize64, NewSize: n.UncompressedSize64})
+		}
+	}
+	return d, nil
+}
+
+// fileHeaders returns the headers of the archive's file entries by name.
+func (r *Reader) fileHeaders() map[string]*zip.FileHeader {
+	headers := make(map[string]*zip.FileHeader, len(r.zipReader.File))
+	for _, f := range r.zipReader.File {
+		if f.FileInfo().IsDir() || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		headers[f.Name] = &f.FileHeader
+	}
+	return headers
+}
+
+// identifiersByScheme returns every identifier value, UUIDs included, keyed by
+// scheme. Several values of one scheme are joined with ", ".
+func (pkg *Package) identifiersByScheme() map[string]string {
+	ids := make(map[string]string)
+	for _, id := range pkg.Metadata.Identifiers {
+		scheme, value := pkg.parseIdentifierMeta(id)
+		if scheme == "" {
+			scheme = "unknown"
+		}
+		if ids[scheme] != "" {
+			value = ids[scheme] + ", " + value
+		}
+		ids[scheme] = value
+	}
+	return ids
+}
+
+// manifestSummary describes each manifest item as "href media-type",
+// followed by its properties.
+func (pkg *Package) manifestSummary() map[string]string {
+	items := make(map[string]string, len(pkg.Manifest.Items))
+	for _, item := range pkg.Manifest.Items {
+		desc := item.Href + " " + item.MediaType
+		if item.Properties != "" {
+			desc += " [" + item.Properties + "]"
+		}
+		items[item.ID] = desc
+	}
+	return items
+}
+
+// spineSummary lists the spine idrefs in reading order; non-linear ones are
+// marked with a trailing "*".
+func (pkg *Package) spineSummary() string {
+	refs := make([]string, 0, len(pkg.Spine.ItemRefs))
+	for _, ref := range pkg.Spine.ItemRefs {
+		if ref.Linear == "no" {
+			refs = append(refs, ref.IDRef+"*")
+		} else {
+			refs = append(refs, ref.IDRef)
+		}
+	}
+	return strings.Join(refs, " ")
+}
+
+func formatRating(rating int) string {
+	if rating <= 0 {
+		return ""
+	}
+	return strconv.Itoa(rating)
+}
+
+// sortedKeys returns the keys of both maps, sorted.
+func sortedKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]string{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackageDiff(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	orig, err := r.readPackage(r.OpfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes := orig.Diff(r.Package); changes != nil {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+
+	pkg := r.Package
+	pkg.SetTitle("New Title")
+	pkg.SetSubjects([]string{"Fiction", "Classic"})
+	pkg.SetIdentifier("douban", "7654321")
+	pkg.SetRating(4)
+	if err := r.RemoveResource("ch2"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Change{
+		{Field: "title", Old: "Upgrade Test", New: "New Title"},
+		{Field: "tags", Old: "", New: "Fiction, Classic"},
+		{Field: "rating", Old: "", New: "4"},
+		{Field: "identifiers.douban", Old: "1234567", New: "7654321"},
+		{Field: "manifest.ch2", Old: "Text/ch2.xhtml application/xhtml+xml", New: ""},
+		{Field: "spine", Old: "cover ch1 ch2", New: "cover ch1"},
+	}
+	if got := orig.Diff(pkg); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected changes:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReaderDiff(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.Package.SetTitle("Resent")
+	if err := r.ReplaceResource("ch1", []byte("<html>revised</html>")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RemoveResource("ch2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.AddResource("Text/ch3.xhtml", "application/xhtml+xml", []byte("<html>new</html>"), ""); err != nil {
+		t.Fatal(err)
+	}
+	r2 := saveAndReopen(t, r)
+
+	orig, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer orig.Close()
+
+	d, err := orig.Diff(r2)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	statuses := make(map[string]string)
+	for _, f := range d.Files {
+		statuses[f.Path] = f.Status
+	}
+	want := map[string]string{
+		"OEBPS/content.opf":    FileModified,
+		"OEBPS/Text/ch1.xhtml": FileModified,
+		"OEBPS/Text/ch2.xhtml": FileRemoved,
+		"OEBPS/Text/ch3.xhtml": FileAdded,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("Unexpected file changes: %v", statuses)
+	}
+	if len(d.Package) == 0 || d.Package[0].Field != "title" {
+		t.Errorf("Expected the title change first, got %+v", d.Package)
+	}
+
+	if d, _ := orig.Diff(orig); !d.Empty() {
+		t.Errorf("Expected no differences, got %+v", d)
+	}
+}
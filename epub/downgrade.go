@@ -0,0 +1,386 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// landmarkToGuide maps EPUB 3 landmarks back to EPUB 2 guide types.
+var landmarkToGuide = func() map[string]string {
+	m := make(map[string]string, len(guideToLandmark))
+	for guide, landmark := range guideToLandmark {
+		m[landmark] = guide
+	}
+	return m
+}()
+
+// DowngradeToEPUB2 converts an EPUB 3 package to EPUB 2 in place.
+//
+// It generates toc.ncx from the navigation document (unless an NCX exists),
+// converts refines meta to opf: attributes, converts belongs-to-collection
+// series to calibre:series, builds a guide from landmarks and strips EPUB 3-only
+// manifest and spine properties. Content that EPUB 2 cannot represent (media
+// overlays, fixed layout, HTML5 content documents, ...) is reported in the
+// returned warnings; content documents are not converted to XHTML 1.1.
+// Call Save to write the result.
+func DowngradeToEPUB2(r *Reader) ([]string, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+	if !pkg.isEPUB3() {
+		return nil, fmt.Errorf("package is not EPUB 3 (version %s)", pkg.Version)
+	}
+	spine, err := r.Spine()
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+
+	// 1. Read the navigation document before its properties are stripped
+	var toc, landmarks, pages []navPoint
+	if nav := r.navItem(); nav != nil {
+		var err error
+		toc, landmarks, pages, err = r.readNav(r.resolveHref(nav.Href))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nav document: %w", err)
+		}
+	}
+
+	// 2. NCX
+	if ncx := r.ncxItem(); ncx != nil {
+		pkg.Spine.Toc = ncx.ID
+	} else {
+		if len(toc) == 0 {
+			toc = r.spineTOC(spine)
+		}
+		if err := r.addNCX(toc, pages); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Guide from landmarks
+	r.addGuideFromLandmarks(landmarks)
+
+	// 4. Fixed layout and other package-level EPUB 3 features
+	warnings = append(warnings, pkg.fixedLayoutWarnings()...)
+	if pkg.Spine.PageProg == "rtl" {
+		warnings = append(warnings, "page-progression-direction=\"rtl\" is not supported in EPUB 2 and was removed")
+	}
+	pkg.Spine.PageProg = ""
+	pkg.Prefix = ""
+	pkg.Dir = ""
+
+	// 5. Manifest and spine properties (remember the cover-image first)
+	coverID := ""
+	if cover, err := r.findCoverItem(); err == nil {
+		coverID = cover.ID
+	}
+	warnings = append(warnings, r.stripEPUB3Properties()...)
+
+	// 6. Metadata
+	dropped := pkg.convertRefinesToAttributes()
+	if len(dropped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("metadata without an EPUB 2 equivalent was removed: %s", strings.Join(dropped, ", ")))
+	}
+	if coverID != "" {
+		pkg.setLegacyMeta("cover", coverID)
+	}
+
+	// 7. Content documents
+	warnings = append(warnings, r.html5Warnings(spine)...)
+	return warnings, nil
+}
+
+// html5Elements are the HTML5 elements XHTML 1.1 does not have.
+var html5Elements = map[string]bool{
+	"article": true, "aside": true, "audio": true, "canvas": true, "details": true,
+	"figcaption": true, "figure": true, "footer": true, "header": true, "main": true,
+	"mark": true, "nav": true, "section": true, "summary": true, "time": true, "video": true,
+}
+
+// html5Warnings reports the XHTML spine documents that are HTML5 rather than
+// XHTML 1.1, which EPUB 2 requires.
+func (r *Reader) html5Warnings(spine []SpineEntry) []string {
+	var warnings []string
+	for _, entry := range spine {
+		if !strings.EqualFold(entry.Item.MediaType, "application/xhtml+xml") {
+			continue
+		}
+		data, err := r.readFile(entry.Path)
+		if err != nil {
+			continue
+		}
+		if features := html5Features(data); len(features) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s is HTML5 (%s), not XHTML 1.1; EPUB 2 reading systems may not display it",
+				entry.Path, strings.Join(features, ", ")))
+		}
+	}
+	return warnings
+}
+
+// html5Features lists the HTML5 doctype and elements found in an XHTML
+// document, each once, in document order.
+func html5Features(data []byte) []string {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = charsetReader
+
+	var features []string
+	seen := make(map[string]bool)
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			features = append(features, f)
+		}
+	}
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			return features
+		}
+		switch t := tok.(type) {
+		case xml.Directive:
+			// <!DOCTYPE html> has no public identifier
+			if f := strings.Fields(string(t)); len(f) == 2 && strings.EqualFold(f[0], "DOCTYPE") && strings.EqualFold(f[1], "html") {
+				add("<!DOCTYPE html>")
+			}
+		case xml.StartElement:
+			if name := strings.ToLower(t.Name.Local); html5Elements[name] {
+				add("<" + name + ">")
+			}
+		}
+	}
+}
+
+// addNCX writes toc.ncx next to the OPF and references it from the spine.
+func (r *Reader) addNCX(toc, pages []navPoint) error {
+	ncxPath := r.uniqueFilePath(path.Dir(r.OpfPath), "toc", ".ncx")
+	uid := ""
+	for _, id := range r.Package.Metadata.Identifiers {
+		if id.ID == r.Package.UniqueIdentifier {
+			uid = id.Value
+		}
+	}
+	data, err := buildNCX(ncxPath, uid, r.Package.GetTitle(), r.Package.GetLanguage(), toc, pages)
+	if err != nil {
+		return fmt.Errorf("failed to build NCX: %w", err)
+	}
+	id, err := r.AddResource(encodedHref(path.Dir(r.OpfPath), ncxPath), "application/x-dtbncx+xml", data, "")
+	if err != nil {
+		return fmt.Errorf("failed to add NCX: %w", err)
+	}
+	r.Package.Spine.Toc = id
+	return nil
+}
+
+// addGuideFromLandmarks adds guide references for landmarks whose type is not
+// already in the guide.
+func (r *Reader) addGuideFromLandmarks(landmarks []navPoint) {
+	if len(landmarks) == 0 {
+		return
+	}
+	if r.Package.Guide == nil {
+		r.Package.Guide = &Guide{}
+	}
+	existing := make(map[string]bool)
+	for _, ref := range r.Package.Guide.References {
+		existing[strings.ToLower(ref.Type)] = true
+	}
+	opfDir := path.Dir(r.OpfPath)
+	for _, l := range landmarks {
+		var guideType string
+		for _, t := range strings.Fields(l.Type) {
+			if gt, ok := landmarkToGuide[t]; ok {
+				guideType = gt
+				break
+			}
+		}
+		if guideType == "" || l.Path == "" || existing[guideType] {
+			continue
+		}
+		existing[guideType] = true
+		r.Package.Guide.References = append(r.Package.Guide.References, Reference{
+			Type:  guideType,
+			Title: l.Title,
+			Href:  encodedHref(opfDir, l.Path) + l.Fragment,
+		})
+	}
+}
+
+// fixedLayoutWarnings reports pre-paginated (fixed layout) rendition metadata.
+func (pkg *Package) fixedLayoutWarnings() []string {
+	fixed := false
+	for _, m := range pkg.Metadata.Meta {
+		if m.Property == "rendition:layout" && m.Refines == "" && strings.TrimSpace(m.Value) == "pre-paginated" {
+			fixed = true
+		}
+	}
+	for _, ref := range pkg.Spine.ItemRefs {
+		if hasProperty(ref.Properties, "rendition:layout-pre-paginated") {
+			fixed = true
+		}
+	}
+	if fixed {
+		return []string{"fixed-layout (pre-paginated) rendition is not supported in EPUB 2; pages will reflow"}
+	}
+	return nil
+}
+
+// stripEPUB3Properties removes manifest properties, media overlays and spine
+// itemref properties, which do not exist in EPUB 2.
+func (r *Reader) stripEPUB3Properties() []string {
+	var warnings []string
+	overlays := 0
+	for i := range r.Package.Manifest.Items {
+		item := &r.Package.Manifest.Items[i]
+		item.Properties = ""
+		if item.MediaOverlay != "" {
+			overlays++
+			item.MediaOverlay = ""
+		}
+	}
+	if overlays > 0 {
+		warnings = append(warnings, fmt.Sprintf("media overlays on %d documents are not supported in EPUB 2 and were detached (SMIL files are kept)", overlays))
+	}
+	for i := range r.Package.Spine.ItemRefs {
+		r.Package.Spine.ItemRefs[i].Properties = ""
+	}
+	return warnings
+}
+
+// convertRefinesToAttributes replaces EPUB 3 property meta with EPUB 2
+// equivalents: refines on creators and identifiers become opf: attributes,
+// series collections become calibre:series and calibre:* properties become
+// name/content meta. The package version is set to 2.0.
+// It returns the properties that could not be represented.
+func (pkg *Package) convertRefinesToAttributes() []string {
+	// 1. Collect values while the EPUB 3 structures still exist
+	series, seriesIndex := pkg.GetSeries(), pkg.GetSeriesIndex()
+
+	consumed := make(map[int]bool)
+	refinementIndex := func(id, property string) (int, string) {
+		if id == "" {
+			return -1, ""
+		}
+		for i, m := range pkg.Metadata.Meta {
+			if m.Refines == "#"+id && m.Property == property {
+				return i, strings.TrimSpace(m.Value)
+			}
+		}
+		return -1, ""
+	}
+
+	for _, people := range [][]AuthorMeta{pkg.Metadata.Creators, pkg.Metadata.Contributors} {
+		for i := range people {
+			p := &people[i]
+			if idx, role := refinementIndex(p.ID, "role"); idx >= 0 {
+				if p.Role == "" {
+					p.Role = role
+				}
+				consumed[idx] = true
+			}
+			if idx, fileAs := refinementIndex(p.ID, "file-as"); idx >= 0 {
+				if p.FileAs == "" {
+					p.FileAs = fileAs
+				}
+				consumed[idx] = true
+			}
+		}
+	}
+	for i := range pkg.Metadata.Identifiers {
+		id := &pkg.Metadata.Identifiers[i]
+		idx, value := refinementIndex(id.ID, "identifier-type")
+		if idx < 0 {
+			continue
+		}
+		consumed[idx] = true
+		if id.Scheme != "" {
+			continue
+		}
+		if pkg.Metadata.Meta[idx].Scheme == "onix:codelist5" {
+			value = strings.ToUpper(onixIdentifierTypes[value])
+		}
+		id.Scheme = value
+		// "isbn:978..." (EPUB 3 / Calibre style) -> opf:scheme="ISBN" 978...
+		// The unique identifier keeps its value: reading systems and font
+		// obfuscation depend on it.
+		prefix := strings.ToLower(value) + ":"
+		if id.ID != pkg.UniqueIdentifier && value != "" && strings.HasPrefix(strings.ToLower(id.Value), prefix) {
+			id.Value = id.Value[len(prefix):]
+		}
+	}
+
+	// 2. Rebuild meta: keep name/content meta, translate calibre:* properties
+	var kept []Meta
+	var calibreProps []Meta
+	droppedSet := make(map[string]bool)
+	for i, m := range pkg.Metadata.Meta {
+		switch {
+		case m.Property == "":
+			kept = append(kept, m)
+		case consumed[i]:
+		case m.Property == "belongs-to-collection" || m.Property == "collection-type" || m.Property == "group-position":
+			// Carried over as calibre:series below
+		case m.Property == "dcterms:modified" || strings.HasPrefix(m.Property, "rendition:"):
+			// No EPUB 2 equivalent; fixed layout is reported separately
+		case strings.HasPrefix(m.Property, "calibre:") && m.Refines == "":
+			calibreProps = append(calibreProps, m)
+		default:
+			droppedSet[m.Property] = true
+		}
+	}
+	pkg.Metadata.Meta = kept
+	pkg.Version = "2.0"
+
+	for _, m := range calibreProps {
+		if m.Property == calibreUserMetadata {
+			pkg.expandCalibreUserMetadata(m.Value)
+			continue
+		}
+		if m.Property == "calibre:series" || m.Property == "calibre:series_index" {
+			continue
+		}
+		pkg.setLegacyMeta(m.Property, strings.TrimSpace(m.Value))
+	}
+	if series != "" {
+		pkg.setLegacyMeta("calibre:series", series)
+	}
+	if seriesIndex != "" {
+		pkg.setLegacyMeta("calibre:series_index", seriesIndex)
+	}
+
+	var dropped []string
+	for p := range droppedSet {
+		dropped = append(dropped, p)
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// expandCalibreUserMetadata splits the EPUB 3 calibre:user_metadata object into
+// one EPUB 2 meta per column.
+func (pkg *Package) expandCalibreUserMetadata(value string) {
+	columns := make(map[string]map[string]interface{})
+	if err := json.Unmarshal([]byte(value), &columns); err != nil {
+		return
+	}
+	labels := make([]string, 0, len(columns))
+	for label := range columns {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		data, err := marshalCalibreJSON(columns[label])
+		if err != nil {
+			continue
+		}
+		pkg.setLegacyMeta(calibreUserMetadata+":"+label, data)
+	}
+}
@@ -0,0 +1,281 @@
+package epub
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func epub3TestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid" prefix="rendition: http://www.idpf.org/vocab/rendition/#">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title id="t1">Downgrade Test</dc:title>
+    <meta refines="#t1" property="title-type">main</meta>
+    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">aut</meta>
+    <meta refines="#c1" property="file-as">Doe, Jane</meta>
+    <dc:identifier id="uid">urn:uuid:0b2c7c1e-1111-2222-3333-444455556666</dc:identifier>
+    <dc:identifier id="isbn">isbn:9787020002207</dc:identifier>
+    <meta refines="#isbn" property="identifier-type" scheme="onix:codelist5">15</meta>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">2024-01-01T00:00:00Z</meta>
+    <meta property="belongs-to-collection" id="s1">The Series</meta>
+    <meta refines="#s1" property="collection-type">series</meta>
+    <meta refines="#s1" property="group-position">2</meta>
+    <meta property="calibre:rating">8</meta>
+    <meta property="calibre:user_metadata">{"#pages": {"datatype": "int", "#value#": 42}}</meta>
+    <meta property="rendition:layout">pre-paginated</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="Text/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="cover-img" href="Images/cover.jpg" media-type="image/jpeg" properties="cover-image"/>
+    <item id="ch1" href="Text/ch1.xhtml" media-type="application/xhtml+xml" media-overlay="ch1-smil" properties="scripted"/>
+    <item id="ch1-smil" href="Audio/ch1.smil" media-type="application/smil+xml"/>
+    <item id="ch2" href="Text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine page-progression-direction="rtl">
+    <itemref idref="ch1" properties="page-spread-left"/>
+    <itemref idref="ch2"/>
+  </spine>
+</package>`},
+		{"OEBPS/Text/nav.xhtml", `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><head><title>Nav</title></head>
+<body>
+<nav epub:type="toc"><ol>
+  <li><span>Part One</span>
+    <ol>
+      <li><a href="ch1.xhtml">Chapter 1</a></li>
+      <li><a href="ch1.xhtml#s1">Section 1.1</a></li>
+    </ol>
+  </li>
+  <li><a href="ch2.xhtml">Chapter 2</a></li>
+</ol></nav>
+<nav epub:type="landmarks"><ol>
+  <li><a epub:type="toc" href="nav.xhtml">Contents</a></li>
+  <li><a epub:type="bodymatter" href="ch1.xhtml">Start</a></li>
+</ol></nav>
+<nav epub:type="page-list"><ol><li><a href="ch1.xhtml#p1">1</a></li></ol></nav>
+</body></html>`},
+		{"OEBPS/Images/cover.jpg", "\xFF\xD8\xFF\xE0"},
+		{"OEBPS/Text/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter 1</h1><h2 id="s1">Section</h2></body></html>`},
+		{"OEBPS/Audio/ch1.smil", `<smil xmlns="http://www.w3.org/ns/SMIL" version="3.0"/>`},
+		{"OEBPS/Text/ch2.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter 2</h1></body></html>`},
+	}
+}
+
+func TestDowngradeToEPUB2(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub3TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	warnings, err := DowngradeToEPUB2(r)
+	if err != nil {
+		t.Fatalf("DowngradeToEPUB2 failed: %v", err)
+	}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"media overlays", "fixed-layout", "page-progression-direction", "title-type"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Missing warning about %s in:\n%s", want, joined)
+		}
+	}
+
+	r2 := saveAndReopen(t, r)
+	pkg := r2.Package
+
+	if pkg.Version != "2.0" || pkg.Prefix != "" || pkg.Spine.PageProg != "" {
+		t.Errorf("Package attributes not downgraded: version=%s prefix=%q ppd=%q", pkg.Version, pkg.Prefix, pkg.Spine.PageProg)
+	}
+
+	// Metadata
+	c := pkg.Metadata.Creators[0]
+	if c.Role != "aut" || c.FileAs != "Doe, Jane" {
+		t.Errorf("Creator refines not converted: %+v", c)
+	}
+	if pkg.GetISBN() != "9787020002207" {
+		t.Errorf("ISBN not converted: %q", pkg.GetISBN())
+	}
+	for _, id := range pkg.Metadata.Identifiers {
+		if id.ID == "isbn" && (id.Scheme != "ISBN" || id.Value != "9787020002207") {
+			t.Errorf("Unexpected ISBN identifier: %+v", id)
+		}
+		if id.ID == "uid" && id.Value != "urn:uuid:0b2c7c1e-1111-2222-3333-444455556666" {
+			t.Errorf("Unique identifier changed: %+v", id)
+		}
+	}
+	if pkg.GetSeries() != "The Series" || pkg.GetSeriesIndex() != "2" {
+		t.Errorf("Series not converted: %q %q", pkg.GetSeries(), pkg.GetSeriesIndex())
+	}
+	if pkg.GetRating() != 4 {
+		t.Errorf("Rating not converted: %d", pkg.GetRating())
+	}
+	if v, ok := pkg.GetCalibreColumn("#pages"); !ok || v != float64(42) {
+		t.Errorf("User metadata not converted: %v", v)
+	}
+	for _, m := range pkg.Metadata.Meta {
+		if m.Property != "" {
+			t.Errorf("EPUB 3 meta left behind: %+v", m)
+		}
+	}
+	cover := false
+	for _, m := range pkg.Metadata.Meta {
+		if m.Name == "cover" && m.Content == "cover-img" {
+			cover = true
+		}
+	}
+	if !cover {
+		t.Error("Missing EPUB 2 cover meta")
+	}
+
+	// Manifest and spine
+	for _, item := range pkg.Manifest.Items {
+		if item.Properties != "" || item.MediaOverlay != "" {
+			t.Errorf("EPUB 3 attributes left on item: %+v", item)
+		}
+	}
+	if pkg.Spine.ItemRefs[0].Properties != "" {
+		t.Error("Spine properties not stripped")
+	}
+
+	// NCX
+	ncx := r2.ncxItem()
+	if ncx == nil || pkg.Spine.Toc != ncx.ID {
+		t.Fatal("NCX not added to spine")
+	}
+	toc, pages, err := r2.readNCX(r2.resolveHref(ncx.Href))
+	if err != nil {
+		t.Fatalf("readNCX failed: %v", err)
+	}
+	if len(toc) != 2 || toc[0].Title != "Part One" || toc[0].Path != "OEBPS/Text/ch1.xhtml" || len(toc[0].Children) != 2 {
+		t.Fatalf("Unexpected NCX toc: %+v", toc)
+	}
+	if len(pages) != 1 || pages[0].Fragment != "#p1" {
+		t.Errorf("Unexpected page list: %+v", pages)
+	}
+	data, _ := r2.readFile(r2.resolveHref(ncx.Href))
+	if !strings.Contains(string(data), `content="urn:uuid:0b2c7c1e-1111-2222-3333-444455556666"`) {
+		t.Error("NCX dtb:uid does not match the unique identifier")
+	}
+
+	// Guide
+	if pkg.Guide == nil || len(pkg.Guide.References) != 2 {
+		t.Fatalf("Guide not built from landmarks: %+v", pkg.Guide)
+	}
+	if ref := pkg.Guide.References[1]; ref.Type != "text" || ref.Href != "Text/ch1.xhtml" {
+		t.Errorf("Unexpected guide reference: %+v", ref)
+	}
+	if n := brokenLinks(t, r2); n != 0 {
+		t.Errorf("%d broken links after downgrade", n)
+	}
+
+	if _, err := DowngradeToEPUB2(r2); err == nil {
+		t.Error("Expected error when downgrading an EPUB 2 package")
+	}
+}
+
+func TestDowngradeToEPUB2_HTML5Warnings(t *testing.T) {
+	files := epub3TestFiles()
+	for i := range files {
+		if files[i].Name == "OEBPS/Text/ch1.xhtml" {
+			files[i].Content = `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml"><body><section><h1>Chapter 1</h1><nav><p>Jump</p></nav></section><section/></body></html>`
+		}
+	}
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	warnings, err := DowngradeToEPUB2(r)
+	if err != nil {
+		t.Fatalf("DowngradeToEPUB2 failed: %v", err)
+	}
+	var html5 []string
+	for _, w := range warnings {
+		if strings.Contains(w, "XHTML 1.1") {
+			html5 = append(html5, w)
+		}
+	}
+	want := "OEBPS/Text/ch1.xhtml is HTML5 (<!DOCTYPE html>, <section>, <nav>), not XHTML 1.1; EPUB 2 reading systems may not display it"
+	if len(html5) != 1 || html5[0] != want {
+		t.Errorf("Expected only ch1 reported:\n%s\ngot:\n%s", want, strings.Join(html5, "\n"))
+	}
+}
+
+func TestUpgradeDowngradeRoundTrip(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	before := r.Package.GetIdentifiers()
+	if err := UpgradeToEPUB3(r); err != nil {
+		t.Fatalf("UpgradeToEPUB3 failed: %v", err)
+	}
+	if _, err := DowngradeToEPUB2(r); err != nil {
+		t.Fatalf("DowngradeToEPUB2 failed: %v", err)
+	}
+	r2 := saveAndReopen(t, r)
+
+	c := r2.Package.Metadata.Creators[0]
+	if c.Role != "aut" || c.FileAs != "Doe, Jane" {
+		t.Errorf("Creator attributes lost in round trip: %+v", c)
+	}
+	after := r2.Package.GetIdentifiers()
+	for k, v := range before {
+		if after[k] != v {
+			t.Errorf("Identifier %s changed: %q -> %q", k, v, after[k])
+		}
+	}
+	// The original NCX is reused
+	if ncx := r2.ncxItem(); ncx == nil || ncx.ID != "ncx" {
+		t.Errorf("Original NCX not kept: %+v", ncx)
+	}
+}
+
+func TestDowngradeToEPUB2_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub3-pure/215714.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215864.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			title, author := r.Package.GetTitle(), r.Package.GetAuthor()
+			if _, err := DowngradeToEPUB2(r); err != nil {
+				t.Fatalf("DowngradeToEPUB2 failed: %v", err)
+			}
+			r2 := saveAndReopen(t, r)
+
+			if r2.Package.GetTitle() != title || r2.Package.GetAuthor() != author {
+				t.Errorf("Metadata changed: %q / %q", r2.Package.GetTitle(), r2.Package.GetAuthor())
+			}
+			ncx := r2.ncxItem()
+			if ncx == nil {
+				t.Fatal("No NCX after downgrade")
+			}
+			toc, _, err := r2.readNCX(r2.resolveHref(ncx.Href))
+			if err != nil || len(toc) == 0 {
+				t.Errorf("Empty NCX: %v", err)
+			}
+			if n := brokenLinks(t, r2); n != 0 {
+				t.Errorf("%d broken links after downgrade", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package epub
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Well-known paths of encryption and rights information in the container.
+const (
+	encryptionPath = "META-INF/encryption.xml"
+	rightsPath     = "META-INF/rights.xml"
+	lcpLicensePath = "META-INF/license.lcpl"
+	sinfPath       = "META-INF/sinf.xml"
+)
+
+// Font obfuscation algorithms (encryption.xml EncryptionMethod).
+const (
+	AlgorithmIDPFObfuscation  = "http://www.idpf.org/2008/embedding"
+	AlgorithmAdobeObfuscation = "http://ns.adobe.com/pdf/enc#RC"
+)
+
+// EncryptionType classifies how a resource is protected.
+type EncryptionType string
+
+const (
+	EncryptionIDPFObfuscation  EncryptionType = "idpf-obfuscation"
+	EncryptionAdobeObfuscation EncryptionType = "adobe-obfuscation"
+	EncryptionAdobeADEPT       EncryptionType = "adobe-adept"
+	EncryptionReadiumLCP       EncryptionType = "readium-lcp"
+	EncryptionAppleFairPlay    EncryptionType = "apple-fairplay"
+	EncryptionUnknown          EncryptionType = "unknown"
+)
+
+// IsObfuscation reports whether t is font obfuscation, which any reading
+// system can undo, as opposed to DRM.
+func (t EncryptionType) IsObfuscation() bool {
+	return t == EncryptionIDPFObfuscation || t == EncryptionAdobeObfuscation
+}
+
+// EncryptedResource is a resource listed in META-INF/encryption.xml.
+type EncryptedResource struct {
+	Path      string         `json:"path"` // full zip path
+	Algorithm string         `json:"algorithm"`
+	Type      EncryptionType `json:"type"`
+}
+
+// EncryptionInfo describes the encryption of a book.
+type EncryptionInfo struct {
+	Resources []EncryptedResource `json:"resources,omitempty"`
+	// DRM is the DRM scheme protecting the book, "" when the book has none
+	// (font obfuscation alone is not DRM).
+	DRM EncryptionType `json:"drm,omitempty"`
+}
+
+// ObfuscatedFonts returns the resources protected by font obfuscation.
+func (e *EncryptionInfo) ObfuscatedFonts() []EncryptedResource {
+	var fonts []EncryptedResource
+	for _, res := range e.Resources {
+		if res.Type.IsObfuscation() {
+			fonts = append(fonts, res)
+		}
+	}
+	return fonts
+}
+
+// Encryption parses META-INF/encryption.xml and classifies every encrypted
+// resource. Books without encryption.xml return an empty EncryptionInfo.
+// The result is cached until one of the files it is based on is changed.
+func (r *Reader) Encryption() (*EncryptionInfo, error) {
+	if r.encryptionPending() {
+		return r.parseEncryption()
+	}
+	if r.encryption == nil {
+		info, err := r.parseEncryption()
+		if err != nil {
+			return nil, err
+		}
+		r.encryption = info
+	}
+	info := *r.encryption
+	info.Resources = slices.Clone(info.Resources)
+	return &info, nil
+}
+
+// encryptionPending reports whether a file Encryption reads has a pending
+// replacement or removal; the cache only holds the original archive's answer.
+func (r *Reader) encryptionPending() bool {
+	for _, name := range []string{encryptionPath, rightsPath, lcpLicensePath, sinfPath} {
+		if _, ok := r.replacementSource(name); ok || r.removed[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reader) parseEncryption() (*EncryptionInfo, error) {
+	info := &EncryptionInfo{}
+	if r.fileExists(sinfPath) {
+		info.DRM = EncryptionAppleFairPlay
+	}
+	if !r.fileExists(encryptionPath) {
+		return info, nil
+	}
+
+	doc, err := r.readXMLDocument(encryptionPath)
+	if err != nil {
+		return nil, fmt.Errorf("malformed encryption.xml: %w", err)
+	}
+
+	// The DRM scheme is identified by its license/rights documents or by the
+	// key reference of each EncryptedData.
+	adept := false
+	if data, err := r.readFile(rightsPath); err == nil && strings.Contains(string(data), "http://ns.adobe.com/adept") {
+		adept = true
+	}
+	lcp := r.fileExists(lcpLicensePath)
+
+	for _, ed := range doc.FindElements("//EncryptedData") {
+		res := EncryptedResource{}
+		if method := ed.SelectElement("EncryptionMethod"); method != nil {
+			res.Algorithm = method.SelectAttrValue("Algorithm", "")
+		}
+		if ref := ed.FindElement("./CipherData/CipherReference"); ref != nil {
+			res.Path = ref.SelectAttrValue("URI", "")
+			if unescaped, err := url.PathUnescape(res.Path); err == nil {
+				res.Path = unescaped
+			}
+		}
+
+		switch res.Algorithm {
+		case AlgorithmIDPFObfuscation:
+			res.Type = EncryptionIDPFObfuscation
+		case AlgorithmAdobeObfuscation:
+			res.Type = EncryptionAdobeObfuscation
+		default:
+			keyRef := ""
+			if rm := ed.FindElement("./KeyInfo//RetrievalMethod"); rm != nil {
+				keyRef = rm.SelectAttrValue("URI", "") + " " + rm.SelectAttrValue("Type", "")
+			}
+			switch {
+			case adept || ed.FindElement("./KeyInfo//resource") != nil:
+				res.Type = EncryptionAdobeADEPT
+			case lcp || strings.Contains(keyRef, "lcp"):
+				res.Type = EncryptionReadiumLCP
+			case info.DRM == EncryptionAppleFairPlay:
+				res.Type = EncryptionAppleFairPlay
+			default:
+				res.Type = EncryptionUnknown
+			}
+			if info.DRM == "" {
+				info.DRM = res.Type
+			}
+		}
+		info.Resources = append(info.Resources, res)
+	}
+	return info, nil
+}
+
+// IsDRMProtected reports whether the book is protected by DRM (Adobe ADEPT,
+// Readium LCP, Apple FairPlay or an unknown encryption scheme). Font
+// obfuscation alone is not DRM. An unreadable encryption.xml is treated as
+// DRM so that callers err on the side of caution.
+func (r *Reader) IsDRMProtected() bool {
+	info, err := r.Encryption()
+	if err != nil {
+		return true
+	}
+	return info.DRM != ""
+}
@@ -0,0 +1,140 @@
+package epub
+
+import (
+	"testing"
+)
+
+func encryptionXML(entries string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#" xmlns:ds="http://www.w3.org/2000/09/xmldsig#">` + entries + `</encryption>`
+}
+
+const (
+	idpfFontEntry  = `<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"/><enc:CipherData><enc:CipherReference URI="OEBPS/Fonts/My%20Font.otf"/></enc:CipherData></enc:EncryptedData>`
+	adobeFontEntry = `<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://ns.adobe.com/pdf/enc#RC"/><enc:CipherData><enc:CipherReference URI="OEBPS/Fonts/b.ttf"/></enc:CipherData></enc:EncryptedData>`
+	adeptEntry     = `<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/><ds:KeyInfo><resource xmlns="http://ns.adobe.com/adept">urn:uuid:1234</resource></ds:KeyInfo><enc:CipherData><enc:CipherReference URI="OEBPS/Text/ch1.xhtml"/></enc:CipherData></enc:EncryptedData>`
+	lcpEntry       = `<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes256-cbc"/><ds:KeyInfo><ds:RetrievalMethod URI="license.lcpl#/encryption/content_key" Type="http://readium.org/2014/01/lcp#EncryptedContentKey"/></ds:KeyInfo><enc:CipherData><enc:CipherReference URI="OEBPS/Text/ch1.xhtml"/></enc:CipherData></enc:EncryptedData>`
+	unknownEntry   = `<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes256-cbc"/><enc:CipherData><enc:CipherReference URI="OEBPS/Text/ch1.xhtml"/></enc:CipherData></enc:EncryptedData>`
+)
+
+func TestEncryption(t *testing.T) {
+	tests := []struct {
+		name      string
+		extra     []testFile
+		drm       EncryptionType
+		types     []EncryptionType
+		firstPath string
+	}{
+		{name: "none"},
+		{
+			name:      "font obfuscation",
+			extra:     []testFile{{"META-INF/encryption.xml", encryptionXML(idpfFontEntry + adobeFontEntry)}},
+			types:     []EncryptionType{EncryptionIDPFObfuscation, EncryptionAdobeObfuscation},
+			firstPath: "OEBPS/Fonts/My Font.otf",
+		},
+		{
+			name: "adobe adept",
+			extra: []testFile{
+				{"META-INF/encryption.xml", encryptionXML(idpfFontEntry + adeptEntry)},
+				{"META-INF/rights.xml", `<adept:rights xmlns:adept="http://ns.adobe.com/adept"/>`},
+			},
+			drm:   EncryptionAdobeADEPT,
+			types: []EncryptionType{EncryptionIDPFObfuscation, EncryptionAdobeADEPT},
+		},
+		{
+			name: "readium lcp",
+			extra: []testFile{
+				{"META-INF/encryption.xml", encryptionXML(lcpEntry)},
+				{"META-INF/license.lcpl", `{"id": "lcp"}`},
+			},
+			drm:   EncryptionReadiumLCP,
+			types: []EncryptionType{EncryptionReadiumLCP},
+		},
+		{
+			name:  "unknown scheme",
+			extra: []testFile{{"META-INF/encryption.xml", encryptionXML(unknownEntry)}},
+			drm:   EncryptionUnknown,
+			types: []EncryptionType{EncryptionUnknown},
+		},
+		{
+			name:  "apple fairplay",
+			extra: []testFile{{"META-INF/sinf.xml", `<fairplay:sinf xmlns:fairplay="http://itunes.apple.com/ns/epub"/>`}},
+			drm:   EncryptionAppleFairPlay,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Open(writeTestEPUB(t, append(coverTestFiles(), tt.extra...)))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			info, err := r.Encryption()
+			if err != nil {
+				t.Fatalf("Encryption failed: %v", err)
+			}
+			if info.DRM != tt.drm {
+				t.Errorf("DRM: expected %q, got %q", tt.drm, info.DRM)
+			}
+			if r.IsDRMProtected() != (tt.drm != "") {
+				t.Errorf("IsDRMProtected: expected %v", tt.drm != "")
+			}
+			if len(info.Resources) != len(tt.types) {
+				t.Fatalf("Expected %d resources, got %+v", len(tt.types), info.Resources)
+			}
+			for i, typ := range tt.types {
+				if info.Resources[i].Type != typ {
+					t.Errorf("Resource %d: expected %s, got %s", i, typ, info.Resources[i].Type)
+				}
+			}
+			if tt.firstPath != "" && info.Resources[0].Path != tt.firstPath {
+				t.Errorf("Expected decoded path %q, got %q", tt.firstPath, info.Resources[0].Path)
+			}
+		})
+	}
+}
+
+func TestEncryption_Malformed(t *testing.T) {
+	files := append(coverTestFiles(), testFile{"META-INF/encryption.xml", "<encryption><EncryptedData>"})
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Encryption(); err == nil {
+		t.Error("Expected error for malformed encryption.xml")
+	}
+	if !r.IsDRMProtected() {
+		t.Error("Unreadable encryption.xml should be treated as DRM")
+	}
+}
+
+func TestEncryption_Cache(t *testing.T) {
+	files := append(coverTestFiles(), testFile{"META-INF/encryption.xml", encryptionXML(idpfFontEntry)})
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	first, err := r.Encryption()
+	if err != nil || len(first.Resources) != 1 {
+		t.Fatalf("Unexpected encryption info %+v: %v", first, err)
+	}
+	first.Resources[0].Path = "changed by the caller"
+	if second, _ := r.Encryption(); r.encryption == nil || second.Resources[0].Path != "OEBPS/Fonts/My Font.otf" {
+		t.Errorf("Expected a cached, unshared result, got %+v", second)
+	}
+
+	r.setReplacement(encryptionPath, []byte(encryptionXML(idpfFontEntry+adeptEntry)))
+	if info, _ := r.Encryption(); len(info.Resources) != 2 || info.DRM != EncryptionAdobeADEPT {
+		t.Errorf("Replaced encryption.xml not seen: %+v", info)
+	}
+	r.removeFile(encryptionPath)
+	if info, _ := r.Encryption(); len(info.Resources) != 0 {
+		t.Errorf("Removed encryption.xml still reported: %+v", info)
+	}
+}
@@ -0,0 +1,190 @@
+package epub
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileEntry describes a zip entry and its role in the package.
+type FileEntry struct {
+	Path           string `json:"path"`
+	Size           uint64 `json:"size"`
+	CompressedSize uint64 `json:"compressed_size"`
+	Method         string `json:"method"` // "store", "deflate" or "method N"
+	CRC32          uint32 `json:"crc32"`
+
+	// Manifest item of the opened rendition, if any
+	ManifestID string `json:"manifest_id,omitempty"`
+	MediaType  string `json:"media_type,omitempty"`
+	// SpinePosition is the 1-based position in the spine, 0 when the item
+	// is not in the spine.
+	SpinePosition int `json:"spine_position,omitempty"`
+
+	// Orphan is set for files that no rendition's manifest lists, apart
+	// from mimetype, META-INF and the OPF files themselves.
+	Orphan bool `json:"orphan,omitempty"`
+}
+
+// Entries lists the file entries of the archive in their zip order.
+func (r *Reader) Entries() ([]FileEntry, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*Item)
+	for i := range pkg.Manifest.Items {
+		item := &pkg.Manifest.Items[i]
+		if isRemoteHref(item.Href) {
+			continue
+		}
+		items[r.itemPath(item.Href)] = item
+	}
+	spine := make(map[string]int)
+	for i, ref := range pkg.Spine.ItemRefs {
+		if _, ok := spine[ref.IDRef]; !ok {
+			spine[ref.IDRef] = i + 1
+		}
+	}
+	referenced := r.referencedPaths()
+
+	var entries []FileEntry
+	for _, f := range r.zipReader.File {
+		if f.FileInfo().IsDir() || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		e := FileEntry{
+			Path:           f.Name,
+			Size:           f.UncompressedSize64,
+			CompressedSize: f.CompressedSize64,
+			Method:         methodName(f.Method),
+			CRC32:          f.CRC32,
+			Orphan:         !referenced[f.Name],
+		}
+		if item, ok := items[f.Name]; ok {
+			e.ManifestID = item.ID
+			e.MediaType = item.MediaType
+			e.SpinePosition = spine[item.ID]
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// referencedPaths returns the container files and every file listed by the
+// manifest of any rendition.
+func (r *Reader) referencedPaths() map[string]bool {
+	referenced := map[string]bool{"mimetype": true}
+	for _, f := range r.zipReader.File {
+		if strings.HasPrefix(f.Name, "META-INF/") {
+			referenced[f.Name] = true
+		}
+	}
+	for _, rf := range r.RootFiles {
+		referenced[rf.FullPath] = true
+		pkg := r.Package
+		if rf.FullPath != r.OpfPath {
+			other, err := r.readPackage(rf.FullPath)
+			if err != nil {
+				continue // a broken rendition references nothing
+			}
+			pkg = other
+		}
+		for _, item := range pkg.Manifest.Items {
+			if isRemoteHref(item.Href) {
+				continue
+			}
+			referenced[resolveRelative(path.Dir(rf.FullPath), item.Href)] = true
+			if p, _ := splitRef(item.Href); p != "" {
+				referenced[path.Join(path.Dir(rf.FullPath), p)] = true
+			}
+		}
+	}
+	return referenced
+}
+
+// Extract writes the original archive entries below dir, creating it if
+// needed. Entries whose path is absolute or escapes dir are rejected with
+// ErrUnsafePath before anything is written.
+func (r *Reader) Extract(dir string) error {
+	targets := make(map[*zip.File]string, len(r.zipReader.File))
+	for _, f := range r.zipReader.File {
+		target, err := extractPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		targets[f] = target
+	}
+
+	for _, f := range r.zipReader.File {
+		target := targets[f]
+		if f.FileInfo().IsDir() || strings.HasSuffix(f.Name, "/") {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractFile(f, target); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// extractPath returns the file path of entry name below dir.
+func extractPath(dir, name string) (string, error) {
+	if isUnsafePath(name) || strings.ContainsRune(name, 0) {
+		return "", &UnsafePathError{Path: name, Source: "zip entry"}
+	}
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &UnsafePathError{Path: name, Source: "zip entry"}
+	}
+	return target, nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Replace rather than write through an existing file or symlink
+	os.Remove(target)
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func methodName(method uint16) string {
+	switch method {
+	case zip.Store:
+		return "store"
+	case zip.Deflate:
+		return "deflate"
+	}
+	return fmt.Sprintf("method %d", method)
+}
+
+// isRemoteHref reports whether href is a URL rather than a container path.
+func isRemoteHref(href string) bool {
+	u, err := url.Parse(href)
+	return err == nil && u.Scheme != ""
+}
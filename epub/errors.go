@@ -0,0 +1,72 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by Open, GetCoverImage and Save. Match them with
+// errors.Is; *MalformedOPFError, *LimitError and *UnsafePathError carry the
+// details.
+var (
+	ErrNotZip       = errors.New("not a zip archive")
+	ErrNoContainer  = errors.New("no usable META-INF/container.xml")
+	ErrNoRootfile   = errors.New("no usable rootfile")
+	ErrMalformedOPF = errors.New("malformed OPF")
+	ErrNoCover      = errors.New("no cover")
+
+	// Archives that are unsafe to process
+	ErrLimitExceeded  = errors.New("limit exceeded")
+	ErrUnsafePath     = errors.New("unsafe path")
+	ErrDuplicateEntry = errors.New("duplicate zip entry")
+)
+
+// MalformedOPFError reports an OPF that cannot be parsed. Line and Column are
+// 1-based, 0 when the error has no position (e.g. no package element).
+type MalformedOPFError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *MalformedOPFError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s %s:%d:%d: %v", ErrMalformedOPF, e.Path, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", ErrMalformedOPF, e.Path, e.Err)
+}
+
+func (e *MalformedOPFError) Is(target error) bool { return target == ErrMalformedOPF }
+
+func (e *MalformedOPFError) Unwrap() error { return e.Err }
+
+// LimitError reports an archive exceeding one of the OpenOptions limits.
+type LimitError struct {
+	Limit string // "opf-size", "total-size", "compression-ratio" or "entries"
+	Name  string // zip entry the limit applies to, "" for the whole archive
+	Value int64
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s %s: %s %d exceeds %d", ErrLimitExceeded, e.Name, e.Limit, e.Value, e.Max)
+	}
+	return fmt.Sprintf("%s: %s %d exceeds %d", ErrLimitExceeded, e.Limit, e.Value, e.Max)
+}
+
+func (e *LimitError) Unwrap() error { return ErrLimitExceeded }
+
+// UnsafePathError reports an absolute path or a path escaping the container
+// root.
+type UnsafePathError struct {
+	Path   string
+	Source string // "zip entry", "rootfile" or "manifest item"
+}
+
+func (e *UnsafePathError) Error() string {
+	return fmt.Sprintf("%s in %s: %q", ErrUnsafePath, e.Source, e.Path)
+}
+
+func (e *UnsafePathError) Unwrap() error { return ErrUnsafePath }
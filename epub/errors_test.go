@@ -0,0 +1,93 @@
+package epub
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenErrors(t *testing.T) {
+	withFile := func(name, content string) func() []testFile {
+		return func() []testFile {
+			files := epub2TestFiles()
+			for i := range files {
+				if files[i].Name == name {
+					files[i].Content = content
+				}
+			}
+			return files
+		}
+	}
+	tests := []struct {
+		name   string
+		files  func() []testFile
+		target error
+	}{
+		{"no container", func() []testFile { return epub2TestFiles()[1:] }, ErrNoContainer},
+		{"malformed container", withFile("META-INF/container.xml", "<container"), ErrNoContainer},
+		{"no rootfile", withFile("META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles/></container>`), ErrNoRootfile},
+		{"missing OPF", withFile("META-INF/container.xml", strings.Replace(testContainerXML, "content.opf", "missing.opf", 1)), ErrNoRootfile},
+		{"malformed OPF", withFile("OEBPS/content.opf", "<?xml version=\"1.0\"?>\n<package>\n  <metadata></manifest>\n</package>"), ErrMalformedOPF},
+		{"no package element", withFile("OEBPS/content.opf", `<?xml version="1.0"?><opf/>`), ErrMalformedOPF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(writeTestEPUB(t, tt.files()))
+			if !errors.Is(err, tt.target) {
+				t.Fatalf("Expected %v, got %v", tt.target, err)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "not-a-zip.epub")
+	os.WriteFile(path, []byte("plain text"), 0644)
+	if _, err := Open(path); !errors.Is(err, ErrNotZip) {
+		t.Errorf("Expected ErrNotZip, got %v", err)
+	}
+	if _, err := OpenRendition(writeTestEPUB(t, epub2TestFiles()), 5); !errors.Is(err, ErrNoRootfile) {
+		t.Errorf("Expected ErrNoRootfile for a missing rendition, got %v", err)
+	}
+}
+
+func TestMalformedOPFPosition(t *testing.T) {
+	files := epub2TestFiles()
+	files[1].Content = "<?xml version=\"1.0\"?>\n<package>\n  <metadata></manifest>\n</package>"
+	_, err := Open(writeTestEPUB(t, files))
+
+	var opfErr *MalformedOPFError
+	if !errors.As(err, &opfErr) {
+		t.Fatalf("Expected MalformedOPFError, got %v", err)
+	}
+	if opfErr.Path != "OEBPS/content.opf" || opfErr.Line != 3 || opfErr.Column == 0 {
+		t.Errorf("Unexpected position: %+v", opfErr)
+	}
+	if !strings.Contains(err.Error(), "OEBPS/content.opf:3:") {
+		t.Errorf("Position missing from message: %v", err)
+	}
+}
+
+func TestGetCoverImageErrors(t *testing.T) {
+	files := epub2TestFiles()
+	for i := range files {
+		if files[i].Name == "OEBPS/Images/cover.jpg" {
+			files = append(files[:i], files[i+1:]...)
+			break
+		}
+	}
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	if _, _, err := r.GetCoverImage(); !errors.Is(err, ErrNoCover) {
+		t.Errorf("Expected ErrNoCover for a missing cover file, got %v", err)
+	}
+
+	r.Package.Metadata.Meta = nil
+	r.Package.Manifest.Items = nil
+	if _, _, err := r.GetCoverImage(); !errors.Is(err, ErrNoCover) || err.Error() != "no cover" {
+		t.Errorf("Expected ErrNoCover, got %v", err)
+	}
+}
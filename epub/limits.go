@@ -0,0 +1,166 @@
+package epub
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Default limits applied by OpenWithOptions to zero OpenOptions fields. They
+// are far above what real books need. There is no default total size limit:
+// audiobooks and image-heavy books can legitimately be several gigabytes.
+const (
+	DefaultMaxOPFSize          = 16 << 20 // 16 MiB
+	DefaultMaxTotalSize        = 0        // unlimited
+	DefaultMaxCompressionRatio = 200
+	DefaultMaxEntries          = 20000
+)
+
+// Entries smaller than this are not subject to the compression ratio limit;
+// small, repetitive files legitimately compress very well.
+const compressionRatioMinSize = 1 << 20
+
+// OpenOptions limits the resources OpenWithOptions spends on an archive, to
+// process untrusted uploads safely. Zero values use the defaults; negative
+// values disable a limit.
+type OpenOptions struct {
+	MaxOPFSize          int64 // uncompressed size of the OPF
+	MaxTotalSize        int64 // total uncompressed size of all entries
+	MaxCompressionRatio int   // uncompressed/compressed size of an entry
+	MaxEntries          int   // number of zip entries
+
+	// CaseInsensitive matches entry names case-insensitively when no entry
+	// matches exactly, for archives whose hrefs and entry names differ in case.
+	CaseInsensitive bool
+
+	// MetadataOnly parses only the package attributes and metadata, leaving
+	// Manifest, Spine and Guide empty until Reader.LoadPackage is called.
+	// Use it when only the metadata is needed, e.g. to index a library.
+	MetadataOnly bool
+}
+
+// DefaultOpenOptions returns the limits OpenWithOptions applies to zero
+// fields.
+func DefaultOpenOptions() OpenOptions {
+	return OpenOptions{
+		MaxOPFSize:          DefaultMaxOPFSize,
+		MaxTotalSize:        DefaultMaxTotalSize,
+		MaxCompressionRatio: DefaultMaxCompressionRatio,
+		MaxEntries:          DefaultMaxEntries,
+	}
+}
+
+// noLimits disables every size, ratio and entry limit; Open uses it, so only
+// the path safety and duplicate entry checks apply.
+var noLimits = OpenOptions{
+	MaxOPFSize:          -1,
+	MaxTotalSize:        -1,
+	MaxCompressionRatio: -1,
+	MaxEntries:          -1,
+}
+
+// withDefaults replaces zero values with the default limits.
+func (o OpenOptions) withDefaults() OpenOptions {
+	d := DefaultOpenOptions()
+	if o.MaxOPFSize == 0 {
+		o.MaxOPFSize = d.MaxOPFSize
+	}
+	if o.MaxTotalSize == 0 {
+		o.MaxTotalSize = d.MaxTotalSize
+	}
+	if o.MaxCompressionRatio == 0 {
+		o.MaxCompressionRatio = d.MaxCompressionRatio
+	}
+	if o.MaxEntries == 0 {
+		o.MaxEntries = d.MaxEntries
+	}
+	return o
+}
+
+// checkArchive validates the zip entries before anything is decompressed.
+// Declared sizes can be trusted as upper bounds: archive/zip fails reads that
+// go past an entry's UncompressedSize64.
+func checkArchive(z *zip.Reader, opts OpenOptions) error {
+	if opts.MaxEntries > 0 && len(z.File) > opts.MaxEntries {
+		return &LimitError{Limit: "entries", Value: int64(len(z.File)), Max: int64(opts.MaxEntries)}
+	}
+
+	seen := make(map[string]bool, len(z.File))
+	var total uint64
+	for _, f := range z.File {
+		if isUnsafePath(f.Name) {
+			return &UnsafePathError{Path: f.Name, Source: "zip entry"}
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("%w: %s", ErrDuplicateEntry, f.Name)
+		}
+		seen[f.Name] = true
+
+		total += f.UncompressedSize64
+		if opts.MaxTotalSize > 0 && total > uint64(opts.MaxTotalSize) {
+			return &LimitError{Limit: "total-size", Value: int64(total), Max: opts.MaxTotalSize}
+		}
+		if opts.MaxCompressionRatio > 0 && f.UncompressedSize64 >= compressionRatioMinSize {
+			ratio := f.UncompressedSize64 / max(f.CompressedSize64, 1)
+			if ratio > uint64(opts.MaxCompressionRatio) {
+				return &LimitError{Limit: "compression-ratio", Name: f.Name, Value: int64(ratio), Max: int64(opts.MaxCompressionRatio)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRootFiles validates the rootfile paths and the size of the OPF to open.
+func (r *Reader) checkRootFiles(opts OpenOptions) error {
+	for _, rf := range r.RootFiles {
+		if isUnsafePath(rf.FullPath) {
+			return &UnsafePathError{Path: rf.FullPath, Source: "rootfile"}
+		}
+	}
+	if opts.MaxOPFSize > 0 {
+		if f, ok := r.zipEntry(r.OpfPath); ok && f.UncompressedSize64 > uint64(opts.MaxOPFSize) {
+			return &LimitError{Limit: "opf-size", Name: f.Name, Value: int64(f.UncompressedSize64), Max: opts.MaxOPFSize}
+		}
+	}
+	return nil
+}
+
+// checkManifestPaths rejects manifest hrefs that are absolute or resolve
+// outside the container root. Remote resources (URLs) are allowed.
+func (r *Reader) checkManifestPaths() error {
+	for _, item := range r.Package.Manifest.Items {
+		if isRemoteHref(item.Href) {
+			continue
+		}
+		if strings.HasPrefix(item.Href, "/") || isUnsafePath(r.resolveHref(item.Href)) {
+			return &UnsafePathError{Path: item.Href, Source: "manifest item"}
+		}
+	}
+	return nil
+}
+
+// isUnsafePath reports whether a container path is absolute or escapes the
+// container root. Backslashes are treated as separators, as some tools
+// write them.
+func isUnsafePath(name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return true
+	}
+	// Windows drive letters ("C:/..." or "C:"), not names such as "a:b.xhtml"
+	if len(name) >= 2 && name[1] == ':' && isASCIILetter(name[0]) && (len(name) == 2 || name[2] == '/') {
+		return true
+	}
+	for _, seg := range strings.Split(path.Clean(name), "/") {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// isASCIILetter reports whether c is an ASCII letter.
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,132 @@
+package epub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOpenLimits(t *testing.T) {
+	bigOPF := strings.Replace(epub2TestFiles()[1].Content, "</metadata>",
+		"<dc:description>"+strings.Repeat("x", 4096)+"</dc:description></metadata>", 1)
+
+	tests := []struct {
+		name  string
+		files func() []testFile
+		opts  OpenOptions
+		limit string
+	}{
+		{"entries", epub2TestFiles, OpenOptions{MaxEntries: 3}, "entries"},
+		{"total size", epub2TestFiles, OpenOptions{MaxTotalSize: 512}, "total-size"},
+		{"opf size", func() []testFile {
+			files := epub2TestFiles()
+			files[1].Content = bigOPF
+			return files
+		}, OpenOptions{MaxOPFSize: 4096}, "opf-size"},
+		{"compression ratio", func() []testFile {
+			return append(epub2TestFiles(), testFile{"OEBPS/bomb.bin", strings.Repeat("\x00", 2<<20)})
+		}, OpenOptions{}, "compression-ratio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestEPUB(t, tt.files())
+			_, err := OpenWithOptions(path, tt.opts)
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("Expected LimitError, got %v", err)
+			}
+			if limitErr.Limit != tt.limit || limitErr.Value <= limitErr.Max {
+				t.Errorf("Unexpected limit error: %+v", limitErr)
+			}
+
+			// Negative values disable every limit
+			r, err := OpenWithOptions(path, OpenOptions{MaxOPFSize: -1, MaxTotalSize: -1, MaxCompressionRatio: -1, MaxEntries: -1})
+			if err != nil {
+				t.Fatalf("Open without limits failed: %v", err)
+			}
+			r.Close()
+
+			// Open applies no limits
+			r, err = Open(path)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			r.Close()
+		})
+	}
+}
+
+func TestOpenRejectsUnsafeArchives(t *testing.T) {
+	withOPF := func(replace, with string) func() []testFile {
+		return func() []testFile {
+			files := epub2TestFiles()
+			files[1].Content = strings.Replace(files[1].Content, replace, with, 1)
+			return files
+		}
+	}
+	tests := []struct {
+		name   string
+		files  func() []testFile
+		target error
+	}{
+		{"traversing entry", func() []testFile {
+			return append(epub2TestFiles(), testFile{"../evil.txt", "x"})
+		}, ErrUnsafePath},
+		{"absolute entry", func() []testFile {
+			return append(epub2TestFiles(), testFile{"/etc/evil.txt", "x"})
+		}, ErrUnsafePath},
+		{"backslash traversal", func() []testFile {
+			return append(epub2TestFiles(), testFile{`OEBPS\..\..\evil.txt`, "x"})
+		}, ErrUnsafePath},
+		{"duplicate entry", func() []testFile {
+			return append(epub2TestFiles(), testFile{"OEBPS/Text/ch1.xhtml", "duplicate"})
+		}, ErrDuplicateEntry},
+		{"traversing rootfile", func() []testFile {
+			files := epub2TestFiles()
+			files[0].Content = strings.Replace(testContainerXML, "OEBPS/content.opf", "../content.opf", 1)
+			return files
+		}, ErrUnsafePath},
+		{"traversing href", withOPF(`href="Text/ch2.xhtml"`, `href="../../etc/passwd"`), ErrUnsafePath},
+		{"absolute href", withOPF(`href="Text/ch2.xhtml"`, `href="/etc/passwd"`), ErrUnsafePath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(writeTestEPUB(t, tt.files()))
+			if !errors.Is(err, tt.target) {
+				t.Fatalf("Expected %v, got %v", tt.target, err)
+			}
+		})
+	}
+}
+
+func TestOpenAllowsRemoteHrefs(t *testing.T) {
+	files := epub2TestFiles()
+	files[1].Content = strings.Replace(files[1].Content, `href="Text/ch2.xhtml"`, `href="https://example.com/ch2.xhtml"`, 1)
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	r.Close()
+}
+
+func TestIsUnsafePath(t *testing.T) {
+	for name, want := range map[string]bool{
+		"OEBPS/content.opf":    false,
+		"OEBPS/../content.opf": false,
+		"a..b/c":               false,
+		"../content.opf":       true,
+		"OEBPS/../../x":        true,
+		"/etc/passwd":          true,
+		`\windows\x`:           true,
+		"C:/x":                 true,
+		`d:\x`:                 true,
+		"C:":                   true,
+		"a:b.xhtml":            false,
+		"1:/x":                 false,
+		"..":                   true,
+	} {
+		if got := isUnsafePath(name); got != want {
+			t.Errorf("isUnsafePath(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
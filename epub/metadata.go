@@ -13,6 +13,12 @@ func (pkg *Package) isEPUB3() bool {
 	return strings.HasPrefix(v, "3")
 }
 
+// IsOEBPS1 reports whether the package is an OEBPS 1.x publication: either
+// the declared version is 1.x or the OPF uses the 1.x structure.
+func (pkg *Package) IsOEBPS1() bool {
+	return pkg.oeb1Layout || strings.HasPrefix(strings.TrimSpace(pkg.Version), "1")
+}
+
 // GetTitle returns the first title found.
 func (pkg *Package) GetTitle() string {
 	if len(pkg.Metadata.Titles) > 0 {
@@ -157,13 +163,67 @@ func deduplicateStrings(items []string) []string {
 }
 
 // GetAuthorSort returns the sortable author name from the first creator.
+// EPUB 3 stores it as <meta refines="#id" property="file-as">.
 func (pkg *Package) GetAuthorSort() string {
 	if len(pkg.Metadata.Creators) > 0 {
-		return pkg.Metadata.Creators[0].FileAs
+		c := pkg.Metadata.Creators[0]
+		if c.FileAs != "" {
+			return c.FileAs
+		}
+		return pkg.refinement(c.ID, "file-as")
+	}
+	return ""
+}
+
+// refinement returns the value of <meta refines="#id" property="..."> for an element id.
+func (pkg *Package) refinement(id, property string) string {
+	if id == "" {
+		return ""
+	}
+	for _, m := range pkg.Metadata.Meta {
+		if m.Refines == "#"+id && m.Property == property {
+			return strings.TrimSpace(m.Value)
+		}
+	}
+	return ""
+}
+
+// onixIdentifierTypes maps ONIX codelist 5 codes used in identifier-type refines to schemes.
+var onixIdentifierTypes = map[string]string{
+	"02": "isbn",
+	"06": "doi",
+	"15": "isbn",
+}
+
+// refinedIdentifierType returns the scheme declared by an identifier-type refines meta.
+func (pkg *Package) refinedIdentifierType(id string) string {
+	if id == "" {
+		return ""
+	}
+	for _, m := range pkg.Metadata.Meta {
+		if m.Refines != "#"+id || m.Property != "identifier-type" {
+			continue
+		}
+		value := strings.TrimSpace(m.Value)
+		if m.Scheme == "onix:codelist5" {
+			return onixIdentifierTypes[value]
+		}
+		return value
 	}
 	return ""
 }
 
+// parseIdentifierMeta is parseIdentifier with EPUB 3 identifier-type refines as fallback.
+func (pkg *Package) parseIdentifierMeta(id IDMeta) (string, string) {
+	scheme, value := parseIdentifier(id.Scheme, id.Value)
+	if scheme == "unknown" {
+		if refined := pkg.refinedIdentifierType(id.ID); refined != "" {
+			return normalizeScheme(refined), value
+		}
+	}
+	return scheme, value
+}
+
 // SetAuthor sets the author.
 func (pkg *Package) SetAuthor(name string) {
 	// Standard practice: role="aut"
@@ -515,7 +575,7 @@ func (pkg *Package) SetSubjects(tags []string) {
 func (pkg *Package) GetIdentifiers() map[string]string {
 	result := make(map[string]string)
 	for _, id := range pkg.Metadata.Identifiers {
-		scheme, value := parseIdentifier(id.Scheme, id.Value)
+		scheme, value := pkg.parseIdentifierMeta(id)
 		// Skip UUID identifiers (not useful for users)
 		if scheme != "" && scheme != "uuid" && scheme != "unknown" {
 			result[scheme] = value
@@ -629,7 +689,7 @@ func isISBN(s string) bool {
 // GetISBN returns the ISBN identifier if it exists.
 func (pkg *Package) GetISBN() string {
 	for _, id := range pkg.Metadata.Identifiers {
-		scheme, value := parseIdentifier(id.Scheme, id.Value)
+		scheme, value := pkg.parseIdentifierMeta(id)
 		if scheme == "isbn" {
 			return value
 		}
@@ -640,7 +700,7 @@ func (pkg *Package) GetISBN() string {
 // GetASIN returns the ASIN identifier if it exists.
 func (pkg *Package) GetASIN() string {
 	for _, id := range pkg.Metadata.Identifiers {
-		scheme, value := parseIdentifier(id.Scheme, id.Value)
+		scheme, value := pkg.parseIdentifierMeta(id)
 		if scheme == "asin" || scheme == "mobi-asin" {
 			return value
 		}
@@ -653,7 +713,11 @@ func (pkg *Package) GetASIN() string {
 func (pkg *Package) SetIdentifier(scheme, value string) {
 	// Find and update existing identifier with the same scheme
 	for i, id := range pkg.Metadata.Identifiers {
-		if normalizeScheme(id.Scheme) == normalizeScheme(scheme) {
+		idScheme := id.Scheme
+		if idScheme == "" {
+			idScheme = pkg.refinedIdentifierType(id.ID)
+		}
+		if normalizeScheme(idScheme) == normalizeScheme(scheme) {
 			pkg.Metadata.Identifiers[i].Value = value
 			return
 		}
@@ -698,10 +762,15 @@ func (pkg *Package) SetISBN(isbn string) {
 		if parsedScheme != "isbn" {
 			continue
 		}
+		// Keep the id the package unique-identifier refers to
+		isUID := pkg.Metadata.Identifiers[i].ID != "" && pkg.Metadata.Identifiers[i].ID == pkg.UniqueIdentifier
 
 		if pkg.isEPUB3() {
 			// EPUB 3: Use "isbn:" prefix for Calibre compatibility + meta refines for standard compliance
 			pkg.Metadata.Identifiers[i].Scheme = ""
+			if isUID {
+				isbnID = pkg.Metadata.Identifiers[i].ID
+			}
 			pkg.Metadata.Identifiers[i].ID = isbnID
 			if inputHasSep {
 				pkg.Metadata.Identifiers[i].Value = "isbn:" + trimmed
@@ -713,7 +782,9 @@ func (pkg *Package) SetISBN(isbn string) {
 		} else {
 			// EPUB 2: use opf:scheme="ISBN"
 			pkg.Metadata.Identifiers[i].Scheme = "ISBN"
-			pkg.Metadata.Identifiers[i].ID = ""
+			if !isUID {
+				pkg.Metadata.Identifiers[i].ID = ""
+			}
 			if inputHasSep {
 				pkg.Metadata.Identifiers[i].Value = trimmed
 			} else {
@@ -833,7 +904,7 @@ func (pkg *Package) GetPublisher() string {
 func (pkg *Package) GetProducer() string {
 	// Check contributors with role 'bkp'
 	for _, c := range pkg.Metadata.Contributors {
-		if c.Role == "bkp" {
+		if c.Role == "bkp" || pkg.refinement(c.ID, "role") == "bkp" {
 			return c.Value
 		}
 	}
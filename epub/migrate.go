@@ -0,0 +1,101 @@
+package epub
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// oeb1MediaTypes maps OEBPS 1.x media types to their EPUB 2 equivalents.
+var oeb1MediaTypes = map[string]string{
+	"text/x-oeb1-document": "application/xhtml+xml",
+	"text/x-oeb1-css":      "text/css",
+}
+
+// MigrateToEPUB2 converts an OEBPS 1.x package to EPUB 2 in place.
+//
+// The OPF is rewritten with the OPF 2.0 namespace and flat metadata (Save
+// always writes that layout), OEB 1 media types are replaced, the unique
+// identifier and dc:language required by EPUB 2 are ensured and an NCX is
+// generated from the spine when the book has none. Call Save to write the
+// result.
+func MigrateToEPUB2(r *Reader) error {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return err
+	}
+	if !pkg.IsOEBPS1() {
+		return fmt.Errorf("package is not OEBPS 1.x (version %s)", pkg.Version)
+	}
+
+	// 1. Media types
+	for i := range pkg.Manifest.Items {
+		item := &pkg.Manifest.Items[i]
+		if mt, ok := oeb1MediaTypes[strings.ToLower(item.MediaType)]; ok {
+			item.MediaType = mt
+		}
+	}
+
+	// 2. Required metadata
+	if err := pkg.ensureUniqueIdentifier(); err != nil {
+		return err
+	}
+	if len(pkg.Metadata.Languages) == 0 {
+		pkg.Metadata.Languages = []SimpleMeta{{Value: "und"}}
+	}
+
+	// 3. NCX (required by EPUB 2)
+	if ncx := r.ncxItem(); ncx != nil {
+		pkg.Spine.Toc = ncx.ID
+	} else {
+		spine, err := r.Spine()
+		if err != nil {
+			return err
+		}
+		if err := r.addNCX(r.spineTOC(spine), nil); err != nil {
+			return err
+		}
+	}
+
+	pkg.Version = "2.0"
+	pkg.oeb1Layout = false
+	return nil
+}
+
+// ensureUniqueIdentifier makes the package unique-identifier reference a
+// dc:identifier, generating a urn:uuid identifier when the book has none.
+func (pkg *Package) ensureUniqueIdentifier() error {
+	for _, id := range pkg.Metadata.Identifiers {
+		if id.ID != "" && id.ID == pkg.UniqueIdentifier {
+			return nil
+		}
+	}
+	if len(pkg.Metadata.Identifiers) == 0 {
+		uuid, err := newUUID()
+		if err != nil {
+			return fmt.Errorf("failed to generate identifier: %w", err)
+		}
+		pkg.Metadata.Identifiers = []IDMeta{{Value: "urn:uuid:" + uuid}}
+	}
+	id := &pkg.Metadata.Identifiers[0]
+	if id.ID == "" {
+		used := pkg.usedIDs()
+		id.ID = "BookId"
+		for i := 2; used[id.ID]; i++ {
+			id.ID = fmt.Sprintf("BookId%d", i)
+		}
+	}
+	pkg.UniqueIdentifier = id.ID
+	return nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,198 @@
+package epub
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func oeb1TestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<?xml version="1.0"?>
+<!DOCTYPE package PUBLIC "+//ISBN 0-9673008-1-9//DTD OEB 1.2 Package//EN" "http://openebook.org/dtds/oeb-1.2/oebpkg12.dtd">
+<package unique-identifier="isbn" xmlns="http://openebook.org/namespaces/oeb-package/1.0/">
+  <metadata>
+    <dc-metadata xmlns:dc="http://purl.org/dc/elements/1.0/" xmlns:oebpackage="http://openebook.org/namespaces/oeb-package/1.0/">
+      <dc:Title>Legacy Book</dc:Title>
+      <dc:Creator role="aut" file-as="Doe, Jane">Jane Doe</dc:Creator>
+      <dc:Identifier id="isbn" scheme="ISBN">9787020002207</dc:Identifier>
+      <dc:Publisher>Old Press</dc:Publisher>
+      <dc:Description>An OEB 1.2 publication.</dc:Description>
+      <dc:Subject>Fiction</dc:Subject>
+    </dc-metadata>
+    <x-metadata>
+      <meta name="cover" content="cover-img"/>
+    </x-metadata>
+  </metadata>
+  <manifest>
+    <item id="cover-img" href="cover.jpg" media-type="image/jpeg"/>
+    <item id="ch1" href="ch1.html" media-type="text/x-oeb1-document"/>
+    <item id="ch2" href="ch2.html" media-type="text/x-oeb1-document"/>
+    <item id="css" href="style.css" media-type="text/x-oeb1-css"/>
+  </manifest>
+  <spine>
+    <itemref idref="ch1"/>
+    <itemref idref="ch2"/>
+  </spine>
+  <guide>
+    <reference type="text" title="Start" href="ch1.html"/>
+  </guide>
+</package>`},
+		{"OEBPS/cover.jpg", "\xFF\xD8\xFF\xE0"},
+		{"OEBPS/ch1.html", `<html xmlns="http://www.w3.org/1999/xhtml"><head><link rel="stylesheet" href="style.css" type="text/css"/></head><body><h1>Chapter 1</h1></body></html>`},
+		{"OEBPS/ch2.html", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter 2</h1></body></html>`},
+		{"OEBPS/style.css", `body { margin: 0 }`},
+	}
+}
+
+func TestParseOEBPS1(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, oeb1TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	pkg := r.Package
+	if !pkg.IsOEBPS1() {
+		t.Error("Expected OEBPS 1.x package")
+	}
+	if pkg.GetTitle() != "Legacy Book" {
+		t.Errorf("Title: %q", pkg.GetTitle())
+	}
+	if pkg.GetAuthor() != "Jane Doe" || pkg.GetAuthorSort() != "Doe, Jane" {
+		t.Errorf("Author: %q (%q)", pkg.GetAuthor(), pkg.GetAuthorSort())
+	}
+	if pkg.GetISBN() != "9787020002207" {
+		t.Errorf("ISBN: %q", pkg.GetISBN())
+	}
+	if pkg.GetPublisher() != "Old Press" || pkg.GetDescription() != "An OEB 1.2 publication." {
+		t.Errorf("Publisher/description: %q / %q", pkg.GetPublisher(), pkg.GetDescription())
+	}
+	if subjects := pkg.GetSubjects(); len(subjects) != 1 || subjects[0] != "Fiction" {
+		t.Errorf("Subjects: %v", subjects)
+	}
+	if item, err := r.findCoverItem(); err != nil || item.ID != "cover-img" {
+		t.Errorf("Cover from x-metadata not found: %v", err)
+	}
+}
+
+func TestMigrateToEPUB2(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, oeb1TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := MigrateToEPUB2(r); err != nil {
+		t.Fatalf("MigrateToEPUB2 failed: %v", err)
+	}
+	r2 := saveAndReopen(t, r)
+	pkg := r2.Package
+
+	if pkg.Version != "2.0" || pkg.IsOEBPS1() {
+		t.Errorf("Package not migrated: version=%q", pkg.Version)
+	}
+	if pkg.GetTitle() != "Legacy Book" || pkg.GetISBN() != "9787020002207" {
+		t.Errorf("Metadata lost: %q %q", pkg.GetTitle(), pkg.GetISBN())
+	}
+	if c := pkg.Metadata.Creators[0]; c.Role != "aut" || c.FileAs != "Doe, Jane" {
+		t.Errorf("Creator attributes lost: %+v", c)
+	}
+	if pkg.GetLanguage() != "und" {
+		t.Errorf("Expected placeholder language, got %q", pkg.GetLanguage())
+	}
+	if pkg.UniqueIdentifier != "isbn" {
+		t.Errorf("Unique identifier changed: %q", pkg.UniqueIdentifier)
+	}
+	if mt := r2.findItem("ch1").MediaType; mt != "application/xhtml+xml" {
+		t.Errorf("Document media type not migrated: %s", mt)
+	}
+	if mt := r2.findItem("css").MediaType; mt != "text/css" {
+		t.Errorf("CSS media type not migrated: %s", mt)
+	}
+
+	opf, err := r2.readFile(r2.OpfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unwanted := range []string{"dc-metadata", "x-metadata", NsOEB1, "dc:Title"} {
+		if strings.Contains(string(opf), unwanted) {
+			t.Errorf("OPF still contains %q", unwanted)
+		}
+	}
+
+	ncx := r2.ncxItem()
+	if ncx == nil || pkg.Spine.Toc != ncx.ID {
+		t.Fatal("NCX not added")
+	}
+	toc, _, err := r2.readNCX(r2.resolveHref(ncx.Href))
+	if err != nil || len(toc) != 2 || toc[0].Title != "Chapter 1" {
+		t.Errorf("Unexpected NCX toc: %+v (%v)", toc, err)
+	}
+	if n := brokenLinks(t, r2); n != 0 {
+		t.Errorf("%d broken links after migration", n)
+	}
+
+	if err := MigrateToEPUB2(r2); err == nil {
+		t.Error("Expected error when migrating an EPUB 2 package")
+	}
+}
+
+func TestMigrateToEPUB2_NoIdentifier(t *testing.T) {
+	files := oeb1TestFiles()
+	files[1].Content = strings.Replace(files[1].Content, `<dc:Identifier id="isbn" scheme="ISBN">9787020002207</dc:Identifier>`, "", 1)
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := MigrateToEPUB2(r); err != nil {
+		t.Fatalf("MigrateToEPUB2 failed: %v", err)
+	}
+	ids := r.Package.Metadata.Identifiers
+	if len(ids) != 1 || ids[0].ID != r.Package.UniqueIdentifier || !strings.HasPrefix(ids[0].Value, "urn:uuid:") {
+		t.Errorf("Expected generated unique identifier, got %+v (unique-identifier=%q)", ids, r.Package.UniqueIdentifier)
+	}
+}
+
+func TestMigrateToEPUB2_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/oebps1/219021.epub",
+		"../cmd/test-suite/testdata/samples/oebps1/219075.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			if !r.Package.IsOEBPS1() {
+				t.Fatalf("Expected OEBPS 1.x package, got version %q", r.Package.Version)
+			}
+			title, identifiers := r.Package.GetTitle(), r.Package.GetIdentifiers()
+			if err := MigrateToEPUB2(r); err != nil {
+				t.Fatalf("MigrateToEPUB2 failed: %v", err)
+			}
+			r2 := saveAndReopen(t, r)
+
+			if r2.Package.Version != "2.0" || r2.Package.GetTitle() != title {
+				t.Errorf("Unexpected result: version=%q title=%q", r2.Package.Version, r2.Package.GetTitle())
+			}
+			for k, v := range identifiers {
+				if got := r2.Package.GetIdentifiers()[k]; got != v {
+					t.Errorf("Identifier %s changed: %q -> %q", k, v, got)
+				}
+			}
+			if r2.ncxItem() == nil {
+				t.Error("No NCX after migration")
+			}
+		})
+	}
+}
@@ -0,0 +1,457 @@
+package epub
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// Number of leading bytes covered by each obfuscation algorithm.
+const (
+	idpfObfuscatedLength  = 1040
+	adobeObfuscatedLength = 1024
+)
+
+const nsXMLEnc = "http://www.w3.org/2001/04/xmlenc#"
+
+// IDPFObfuscationKey derives the IDPF font obfuscation key: the SHA-1 digest
+// of the unique identifier with all whitespace removed.
+func IDPFObfuscationKey(uid string) []byte {
+	uid = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, uid)
+	sum := sha1.Sum([]byte(uid))
+	return sum[:]
+}
+
+// AdobeObfuscationKey derives the Adobe font obfuscation key: the 16 bytes of
+// a UUID identifier ("urn:uuid:..." or a bare UUID).
+func AdobeObfuscationKey(uid string) ([]byte, error) {
+	s := strings.TrimSpace(strings.ToLower(uid))
+	s = strings.TrimPrefix(s, "urn:")
+	s = strings.TrimPrefix(s, "uuid:")
+	s = strings.ReplaceAll(s, "-", "")
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != 16 {
+		return nil, fmt.Errorf("identifier %q is not a UUID", uid)
+	}
+	return key, nil
+}
+
+// ObfuscateFont applies the font obfuscation algorithm to data with key and
+// returns the result. Both algorithms XOR the start of the font with the key,
+// so the same call also removes the obfuscation (see DeobfuscateFont).
+func ObfuscateFont(data []byte, algorithm string, key []byte) ([]byte, error) {
+	var n int
+	switch algorithm {
+	case AlgorithmIDPFObfuscation:
+		n = idpfObfuscatedLength
+	case AlgorithmAdobeObfuscation:
+		n = adobeObfuscatedLength
+	default:
+		return nil, fmt.Errorf("unsupported obfuscation algorithm: %s", algorithm)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty obfuscation key")
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	for i := 0; i < n && i < len(out); i++ {
+		out[i] ^= key[i%len(key)]
+	}
+	return out, nil
+}
+
+// DeobfuscateFont removes font obfuscation from data. See ObfuscateFont.
+func DeobfuscateFont(data []byte, algorithm string, key []byte) ([]byte, error) {
+	return ObfuscateFont(data, algorithm, key)
+}
+
+// uniqueIdentifierValue returns the value of the dc:identifier referenced by
+// the package unique-identifier attribute.
+func (pkg *Package) uniqueIdentifierValue() string {
+	for _, id := range pkg.Metadata.Identifiers {
+		if id.ID != "" && id.ID == pkg.UniqueIdentifier {
+			return id.Value
+		}
+	}
+	return ""
+}
+
+// adobeKeyIdentifier returns the identifier Adobe obfuscation keys are derived
+// from: the unique identifier when it is a UUID, otherwise the first UUID
+// identifier.
+func (pkg *Package) adobeKeyIdentifier() string {
+	if uid := pkg.uniqueIdentifierValue(); uid != "" {
+		if _, err := AdobeObfuscationKey(uid); err == nil {
+			return uid
+		}
+	}
+	for _, id := range pkg.Metadata.Identifiers {
+		if _, err := AdobeObfuscationKey(id.Value); err == nil {
+			return id.Value
+		}
+	}
+	return ""
+}
+
+// obfuscationKey returns the key for algorithm derived from the identifier uid.
+func obfuscationKey(algorithm, uid string) ([]byte, error) {
+	if uid == "" {
+		return nil, fmt.Errorf("no identifier to derive the obfuscation key from")
+	}
+	if algorithm == AlgorithmAdobeObfuscation {
+		return AdobeObfuscationKey(uid)
+	}
+	return IDPFObfuscationKey(uid), nil
+}
+
+// obfuscationIDs records the key-deriving identifiers of the content as it is
+// stored in the archive, so that Save can detect identifier changes.
+type obfuscationIDs struct {
+	idpf  string
+	adobe string
+}
+
+func (pkg *Package) currentObfuscationIDs() obfuscationIDs {
+	return obfuscationIDs{idpf: pkg.uniqueIdentifierValue(), adobe: pkg.adobeKeyIdentifier()}
+}
+
+// storedObfuscationKey returns the key for algorithm that the fonts in the
+// archive are obfuscated with. Identifier changes take effect on Save.
+func (r *Reader) storedObfuscationKey(algorithm string) ([]byte, error) {
+	uid := r.obfuscation.idpf
+	if algorithm == AlgorithmAdobeObfuscation {
+		uid = r.obfuscation.adobe
+	}
+	return obfuscationKey(algorithm, uid)
+}
+
+// ReadFont returns the content of the font at fullPath with any obfuscation
+// listed in encryption.xml removed.
+func (r *Reader) ReadFont(fullPath string) ([]byte, error) {
+	data, err := r.readFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := r.Encryption()
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range info.ObfuscatedFonts() {
+		if res.Path == fullPath {
+			key, err := r.storedObfuscationKey(res.Algorithm)
+			if err != nil {
+				return nil, err
+			}
+			return DeobfuscateFont(data, res.Algorithm, key)
+		}
+	}
+	return data, nil
+}
+
+// FontResource is a font listed in the manifest.
+type FontResource struct {
+	Path      string `json:"path"` // full zip path
+	MediaType string `json:"media_type"`
+	// Encryption is the protection of the font, "" when it is stored as is.
+	Encryption EncryptionType `json:"encryption,omitempty"`
+}
+
+// Fonts returns the fonts in the manifest with their encryption.
+func (r *Reader) Fonts() ([]FontResource, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+	info, err := r.Encryption()
+	if err != nil {
+		return nil, err
+	}
+	encrypted := make(map[string]EncryptionType)
+	for _, res := range info.Resources {
+		encrypted[res.Path] = res.Type
+	}
+
+	var fonts []FontResource
+	for _, item := range pkg.Manifest.Items {
+		if !isFontMediaType(item.MediaType) {
+			continue
+		}
+		fullPath := r.resolveHref(item.Href)
+		fonts = append(fonts, FontResource{
+			Path:       fullPath,
+			MediaType:  item.MediaType,
+			Encryption: encrypted[fullPath],
+		})
+	}
+	return fonts, nil
+}
+
+// DeobfuscateFonts removes the obfuscation from every obfuscated font and
+// drops their entries from encryption.xml (the file is removed when no entry
+// remains). It returns the number of fonts changed.
+func (r *Reader) DeobfuscateFonts() (int, error) {
+	info, err := r.Encryption()
+	if err != nil {
+		return 0, err
+	}
+	fonts := info.ObfuscatedFonts()
+	if len(fonts) == 0 {
+		return 0, nil
+	}
+	paths := make(map[string]bool)
+	for _, res := range fonts {
+		data, err := r.ReadFont(res.Path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to deobfuscate %s: %w", res.Path, err)
+		}
+		r.setReplacement(res.Path, data)
+		paths[res.Path] = true
+	}
+	if err := r.updateEncryptionXML(paths, ""); err != nil {
+		return 0, err
+	}
+	return len(fonts), nil
+}
+
+// ObfuscateFonts obfuscates every font in the manifest that is not already
+// encrypted with algorithm (AlgorithmIDPFObfuscation or
+// AlgorithmAdobeObfuscation) and lists them in encryption.xml.
+// It returns the number of fonts changed.
+func (r *Reader) ObfuscateFonts(algorithm string) (int, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return 0, err
+	}
+	key, err := r.storedObfuscationKey(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	info, err := r.Encryption()
+	if err != nil {
+		return 0, err
+	}
+	encrypted := make(map[string]bool)
+	for _, res := range info.Resources {
+		encrypted[res.Path] = true
+	}
+
+	paths := make(map[string]bool)
+	for _, item := range pkg.Manifest.Items {
+		fullPath := r.resolveHref(item.Href)
+		if !isFontMediaType(item.MediaType) || encrypted[fullPath] || paths[fullPath] {
+			continue
+		}
+		data, err := r.readFile(fullPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read font %s: %w", fullPath, err)
+		}
+		obfuscated, err := ObfuscateFont(data, algorithm, key)
+		if err != nil {
+			return 0, err
+		}
+		r.setReplacement(fullPath, obfuscated)
+		paths[fullPath] = true
+	}
+	if len(paths) == 0 {
+		return 0, nil
+	}
+	if err := r.updateEncryptionXML(paths, algorithm); err != nil {
+		return 0, err
+	}
+	return len(paths), nil
+}
+
+// rekeyObfuscatedFonts re-obfuscates fonts when the identifier their key is
+// derived from changed since the book was opened (or last saved). Fonts whose
+// new key cannot be derived (e.g. no UUID identifier left for Adobe
+// obfuscation) are stored deobfuscated instead of becoming unreadable.
+func (r *Reader) rekeyObfuscatedFonts() error {
+	current := r.Package.currentObfuscationIDs()
+	changes, err := r.pendingRekeys(current)
+	if err != nil {
+		return err
+	}
+	r.applyRekeys(changes)
+	r.obfuscation = current
+	return nil
+}
+
+// applyRekeys stores the files returned by rekeyedFonts.
+func (r *Reader) applyRekeys(changes map[string][]byte) {
+	for name, data := range changes {
+		if data == nil {
+			r.removeFile(name)
+		} else {
+			r.setReplacement(name, data)
+		}
+	}
+}
+
+// pendingRekeys returns the files rekeyObfuscatedFonts changes for the opened
+// rendition when its identifiers become current. Fonts listed only in other
+// renditions' manifests are keyed by those renditions (see EditRenditions).
+func (r *Reader) pendingRekeys(current obfuscationIDs) (map[string][]byte, error) {
+	if current == r.obfuscation {
+		return nil, nil
+	}
+	others := r.otherRenditionPaths()
+	return r.rekeyedFonts(r.obfuscation, current, func(fullPath string) bool { return !others[fullPath] })
+}
+
+// rekeyedFonts returns the files that change when the identifiers of a
+// rendition move from old to current: the re-keyed fonts for which owns
+// reports true and, if any font is stored deobfuscated, encryption.xml (nil
+// when it is removed). It does not change r.
+func (r *Reader) rekeyedFonts(old, current obfuscationIDs, owns func(fullPath string) bool) (map[string][]byte, error) {
+	if current == old || !r.fileExists(encryptionPath) {
+		return nil, nil
+	}
+	info, err := r.Encryption()
+	if err != nil {
+		// Nothing can be re-keyed in an unreadable encryption.xml
+		return nil, nil
+	}
+
+	changes := make(map[string][]byte)
+	plain := make(map[string]bool)
+	for _, res := range info.ObfuscatedFonts() {
+		if !owns(res.Path) {
+			continue
+		}
+		oldID, newID := old.idpf, current.idpf
+		if res.Algorithm == AlgorithmAdobeObfuscation {
+			oldID, newID = old.adobe, current.adobe
+		}
+		if oldID == newID {
+			continue
+		}
+		oldKey, err := obfuscationKey(res.Algorithm, oldID)
+		if err != nil {
+			// The font was not readable to begin with
+			continue
+		}
+		data, err := r.readFile(res.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", res.Path, err)
+		}
+		if data, err = DeobfuscateFont(data, res.Algorithm, oldKey); err != nil {
+			return nil, err
+		}
+		if newKey, err := obfuscationKey(res.Algorithm, newID); err == nil {
+			if data, err = ObfuscateFont(data, res.Algorithm, newKey); err != nil {
+				return nil, err
+			}
+		} else {
+			plain[res.Path] = true
+		}
+		changes[res.Path] = data
+	}
+	if len(plain) > 0 {
+		data, err := r.encryptionXML(plain, "")
+		if err != nil {
+			return nil, err
+		}
+		changes[encryptionPath] = data
+	}
+	return changes, nil
+}
+
+// updateEncryptionXML removes the EncryptedData entries of paths, then adds
+// new entries for them with algorithm when algorithm is not empty.
+func (r *Reader) updateEncryptionXML(paths map[string]bool, algorithm string) error {
+	data, err := r.encryptionXML(paths, algorithm)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		r.removeFile(encryptionPath)
+	} else {
+		r.setReplacement(encryptionPath, data)
+	}
+	return nil
+}
+
+// encryptionXML returns encryption.xml as updateEncryptionXML writes it, nil
+// when no entry is left.
+func (r *Reader) encryptionXML(paths map[string]bool, algorithm string) ([]byte, error) {
+	var doc *etree.Document
+	if r.fileExists(encryptionPath) {
+		var err error
+		if doc, err = r.readXMLDocument(encryptionPath); err != nil {
+			return nil, fmt.Errorf("malformed encryption.xml: %w", err)
+		}
+	} else {
+		doc = etree.NewDocument()
+		doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+		root := doc.CreateElement("encryption")
+		root.CreateAttr("xmlns", "urn:oasis:names:tc:opendocument:xmlns:container")
+	}
+	root := doc.Root()
+
+	for _, ed := range doc.FindElements("//EncryptedData") {
+		ref := ed.FindElement("./CipherData/CipherReference")
+		if ref == nil {
+			continue
+		}
+		uri := ref.SelectAttrValue("URI", "")
+		if unescaped, err := url.PathUnescape(uri); err == nil {
+			uri = unescaped
+		}
+		if paths[uri] {
+			ed.Parent().RemoveChild(ed)
+		}
+	}
+
+	if algorithm != "" {
+		// Reuse the document's prefix for the XML Encryption namespace
+		prefix := ""
+		for _, a := range root.Attr {
+			if a.Space == "xmlns" && a.Value == nsXMLEnc {
+				prefix = a.Key
+			}
+		}
+		if prefix == "" {
+			prefix = "enc"
+			root.CreateAttr("xmlns:enc", nsXMLEnc)
+		}
+		sorted := make([]string, 0, len(paths))
+		for p := range paths {
+			sorted = append(sorted, p)
+		}
+		sort.Strings(sorted)
+		for _, p := range sorted {
+			ed := root.CreateElement(prefix + ":EncryptedData")
+			ed.CreateElement(prefix+":EncryptionMethod").CreateAttr("Algorithm", algorithm)
+			ed.CreateElement(prefix+":CipherData").CreateElement(prefix+":CipherReference").
+				CreateAttr("URI", (&url.URL{Path: p}).EscapedPath())
+		}
+	}
+
+	if len(doc.FindElements("//EncryptedData")) == 0 {
+		return nil, nil
+	}
+	doc.Indent(2)
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize encryption.xml: %w", err)
+	}
+	return data, nil
+}
+
+// isFontMediaType reports whether the resource is a font.
+func isFontMediaType(mediaType string) bool {
+	mt := strings.ToLower(mediaType)
+	return strings.HasPrefix(mt, "font/") || strings.Contains(mt, "font") ||
+		mt == "application/vnd.ms-opentype"
+}
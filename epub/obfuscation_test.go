@@ -0,0 +1,250 @@
+package epub
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"strings"
+	"testing"
+)
+
+const fontTestUUID = "urn:uuid:0b2c7c1e-1111-2222-3333-444455556666"
+
+// testFont returns fake font data longer than the obfuscated prefix.
+func testFont(seed byte) []byte {
+	data := make([]byte, 2000)
+	for i := range data {
+		data[i] = seed + byte(i*7)
+	}
+	return data
+}
+
+func mustObfuscate(t *testing.T, data []byte, algorithm, uid string) []byte {
+	t.Helper()
+	key, err := obfuscationKey(algorithm, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ObfuscateFont(data, algorithm, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// fontTestFiles returns an EPUB 3 whose unique identifier is the ISBN, with
+// an IDPF obfuscated font, an Adobe obfuscated font and a plain font.
+func fontTestFiles(t *testing.T) []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"META-INF/encryption.xml", encryptionXML(
+			strings.Replace(idpfFontEntry, "My%20Font.otf", "a.otf", 1) + adobeFontEntry)},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="isbn">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Font Test</dc:title>
+    <dc:identifier id="isbn">isbn:9787020002207</dc:identifier>
+    <dc:identifier id="uuid">` + fontTestUUID + `</dc:identifier>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="font-a" href="Fonts/a.otf" media-type="font/otf"/>
+    <item id="font-b" href="Fonts/b.ttf" media-type="application/x-font-ttf"/>
+    <item id="font-c" href="Fonts/c.woff" media-type="font/woff"/>
+  </manifest>
+  <spine>
+    <itemref idref="ch1"/>
+  </spine>
+</package>`},
+		{"OEBPS/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Text</p></body></html>`},
+		{"OEBPS/Fonts/a.otf", string(mustObfuscate(t, testFont(1), AlgorithmIDPFObfuscation, "isbn:9787020002207"))},
+		{"OEBPS/Fonts/b.ttf", string(mustObfuscate(t, testFont(2), AlgorithmAdobeObfuscation, fontTestUUID))},
+		{"OEBPS/Fonts/c.woff", string(testFont(3))},
+	}
+}
+
+func checkFonts(t *testing.T, r *Reader) {
+	t.Helper()
+	for i, name := range []string{"OEBPS/Fonts/a.otf", "OEBPS/Fonts/b.ttf", "OEBPS/Fonts/c.woff"} {
+		data, err := r.ReadFont(name)
+		if err != nil {
+			t.Errorf("ReadFont(%s) failed: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(data, testFont(byte(i+1))) {
+			t.Errorf("ReadFont(%s) does not return the original font", name)
+		}
+	}
+}
+
+func TestObfuscationKeys(t *testing.T) {
+	want := sha1.Sum([]byte("urn:uuid:abc"))
+	if !bytes.Equal(IDPFObfuscationKey(" urn:uuid:abc\n"), want[:]) {
+		t.Error("IDPF key must be the SHA-1 of the identifier without whitespace")
+	}
+
+	key, err := AdobeObfuscationKey("urn:uuid:0B2C7C1E-1111-2222-3333-444455556666")
+	if err != nil {
+		t.Fatalf("AdobeObfuscationKey failed: %v", err)
+	}
+	if len(key) != 16 || key[0] != 0x0b || key[15] != 0x66 {
+		t.Errorf("Unexpected Adobe key: %x", key)
+	}
+	if _, err := AdobeObfuscationKey("isbn:9787020002207"); err == nil {
+		t.Error("Expected error for a non-UUID identifier")
+	}
+}
+
+func TestObfuscateFont(t *testing.T) {
+	font := testFont(0)
+	for alg, n := range map[string]int{AlgorithmIDPFObfuscation: 1040, AlgorithmAdobeObfuscation: 1024} {
+		key, err := obfuscationKey(alg, fontTestUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		obf, err := ObfuscateFont(font, alg, key)
+		if err != nil {
+			t.Fatalf("ObfuscateFont failed: %v", err)
+		}
+		if bytes.Equal(obf[:n], font[:n]) || !bytes.Equal(obf[n:], font[n:]) {
+			t.Errorf("%s: expected only the first %d bytes to change", alg, n)
+		}
+		plain, err := DeobfuscateFont(obf, alg, key)
+		if err != nil || !bytes.Equal(plain, font) {
+			t.Errorf("%s: round trip failed (%v)", alg, err)
+		}
+	}
+	if _, err := ObfuscateFont(font, "http://example.com/unknown", []byte{1}); err == nil {
+		t.Error("Expected error for an unsupported algorithm")
+	}
+}
+
+func TestReadFont(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, fontTestFiles(t)))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	checkFonts(t, r)
+}
+
+func TestDeobfuscateFonts(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, fontTestFiles(t)))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	n, err := r.DeobfuscateFonts()
+	if err != nil || n != 2 {
+		t.Fatalf("DeobfuscateFonts = %d, %v", n, err)
+	}
+	r2 := saveAndReopen(t, r)
+	if r2.fileExists(encryptionPath) {
+		t.Error("encryption.xml should be removed when no entry remains")
+	}
+	data, _ := r2.readFile("OEBPS/Fonts/a.otf")
+	if !bytes.Equal(data, testFont(1)) {
+		t.Error("Font still obfuscated in the archive")
+	}
+	checkFonts(t, r2)
+}
+
+func TestObfuscateFonts(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, fontTestFiles(t)))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	n, err := r.ObfuscateFonts(AlgorithmIDPFObfuscation)
+	if err != nil || n != 1 {
+		t.Fatalf("ObfuscateFonts = %d, %v", n, err)
+	}
+	r2 := saveAndReopen(t, r)
+
+	info, err := r2.Encryption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fonts := info.ObfuscatedFonts(); len(fonts) != 3 || info.DRM != "" {
+		t.Errorf("Unexpected encryption info: %+v", info)
+	}
+	data, _ := r2.readFile("OEBPS/Fonts/c.woff")
+	if bytes.Equal(data, testFont(3)) {
+		t.Error("Font not obfuscated in the archive")
+	}
+	checkFonts(t, r2)
+
+	if n, err := r2.ObfuscateFonts(AlgorithmIDPFObfuscation); err != nil || n != 0 {
+		t.Errorf("Expected no font left to obfuscate, got %d (%v)", n, err)
+	}
+}
+
+func TestRekeyObfuscatedFonts(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, fontTestFiles(t)))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	// Both key-deriving identifiers change
+	r.Package.SetISBN("9787111111111")
+	for i, id := range r.Package.Metadata.Identifiers {
+		if id.ID == "uuid" {
+			r.Package.Metadata.Identifiers[i].Value = "urn:uuid:99999999-8888-7777-6666-555555555555"
+		}
+	}
+	if r.Package.uniqueIdentifierValue() != "isbn:9787111111111" {
+		t.Fatalf("SetISBN did not update the unique identifier: %+v", r.Package.Metadata.Identifiers)
+	}
+	// Reading before Save still works
+	checkFonts(t, r)
+
+	r2 := saveAndReopen(t, r)
+	checkFonts(t, r2)
+	data, _ := r2.readFile("OEBPS/Fonts/a.otf")
+	if !bytes.Equal(data, mustObfuscate(t, testFont(1), AlgorithmIDPFObfuscation, "isbn:9787111111111")) {
+		t.Error("IDPF font not re-obfuscated with the new key")
+	}
+	data, _ = r2.readFile("OEBPS/Fonts/b.ttf")
+	if !bytes.Equal(data, mustObfuscate(t, testFont(2), AlgorithmAdobeObfuscation, "urn:uuid:99999999-8888-7777-6666-555555555555")) {
+		t.Error("Adobe font not re-obfuscated with the new key")
+	}
+
+	// Saving again without changes leaves the fonts alone
+	r3 := saveAndReopen(t, r2)
+	checkFonts(t, r3)
+}
+
+func TestRekeyObfuscatedFonts_NoUUID(t *testing.T) {
+	files := fontTestFiles(t)
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	// No UUID left to derive the Adobe key from
+	for i, id := range r.Package.Metadata.Identifiers {
+		if id.ID == "uuid" {
+			r.Package.Metadata.Identifiers[i].Value = "calibre:1234"
+		}
+	}
+	r2 := saveAndReopen(t, r)
+	checkFonts(t, r2)
+
+	info, err := r2.Encryption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range info.Resources {
+		if res.Path == "OEBPS/Fonts/b.ttf" {
+			t.Error("Adobe font should be stored deobfuscated")
+		}
+	}
+	enc, _ := r2.readFile(encryptionPath)
+	if !strings.Contains(string(enc), "OEBPS/Fonts/a.otf") {
+		t.Error("IDPF entry lost")
+	}
+}
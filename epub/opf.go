@@ -9,6 +9,7 @@ const (
 	NsXML     = "http://www.w3.org/XML/1998/namespace"
 	NsDCTerms = "http://purl.org/dc/terms/"
 	NsCalibre = "http://calibre.kovidgoyal.net/2009/metadata"
+	NsOEB1    = "http://openebook.org/namespaces/oeb-package/1.0/"
 )
 
 // Package is the root element of the OPF file.
@@ -25,6 +26,10 @@ type Package struct {
 	Manifest Manifest `xml:"manifest"`
 	Spine    Spine    `xml:"spine"`
 	Guide    *Guide   `xml:"guide,omitempty"` // Deprecated in EPUB 3, but widely used
+
+	// oeb1Layout is set when the OPF uses the OEBPS 1.x structure
+	// (dc-metadata/x-metadata, OEB package namespace).
+	oeb1Layout bool
 }
 
 // PackageLoose is used for parsing OPF files without namespace constraints.
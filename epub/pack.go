@@ -0,0 +1,240 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PackOptions controls PackDirWithOptions.
+type PackOptions struct {
+	// Deterministic gives every entry the ModTime timestamp and normalized
+	// permissions, so that the same directory always packs to the same bytes.
+	Deterministic bool
+	// ModTime is the entry timestamp in deterministic mode; zero uses
+	// 1980-01-01, the earliest time a zip header can hold.
+	ModTime time.Time
+}
+
+// PackDir packs an unpacked EPUB directory into the archive out.
+func PackDir(dir, out string) error {
+	return PackDirWithOptions(dir, out, PackOptions{})
+}
+
+// PackDirWithOptions packs an unpacked EPUB directory into the archive out.
+// The mimetype entry is written first and stored, followed by META-INF, the
+// OPF files in container order and every other file sorted by path, deflated.
+// META-INF/container.xml must point to existing, parseable OPF files. A
+// mimetype file in dir is replaced by the standard one. Dotfiles,
+// dot-directories, Thumbs.db, desktop.ini and "*~" backups are not packed.
+func PackDirWithOptions(dir, out string, opts PackOptions) error {
+	files, err := packFiles(dir, out)
+	if err != nil {
+		return err
+	}
+	rootFiles, err := checkPackDir(dir, files)
+	if err != nil {
+		return err
+	}
+
+	// META-INF first (container.xml leading), then the OPFs, then the rest
+	rank := func(name string) int {
+		switch {
+		case name == "META-INF/container.xml":
+			return 0
+		case strings.HasPrefix(name, "META-INF/"):
+			return 1
+		}
+		for i, rf := range rootFiles {
+			if name == path.Clean(rf.FullPath) {
+				return 2 + i
+			}
+		}
+		return 2 + len(rootFiles)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		ri, rj := rank(files[i]), rank(files[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return files[i] < files[j]
+	})
+
+	modTime := opts.ModTime
+	if modTime.IsZero() {
+		modTime = zipEpoch
+	}
+
+	tempDir := filepath.Dir(out)
+	if tempDir == "." {
+		tempDir = ""
+	}
+	tmpF, err := os.CreateTemp(tempDir, "golibri-pack-*.epub")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpF.Name()
+	success := false
+	defer func() {
+		tmpF.Close()
+		if !success {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	var normalize headerFunc
+	if opts.Deterministic {
+		normalize = func(h *zip.FileHeader) { normalizeHeader(h, modTime) }
+	}
+
+	w := zip.NewWriter(tmpF)
+	if err := writeMimetype(w, normalize); err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		if err := packFile(w, dir, name, normalize); err != nil {
+			return fmt.Errorf("failed to pack %s: %w", name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close zip writer: %w", err)
+	}
+	tmpF.Close()
+	if err := os.Rename(tmpPath, out); err != nil {
+		return fmt.Errorf("failed to move temp file to output: %w", err)
+	}
+	success = true
+	return nil
+}
+
+// packFiles lists the regular files below dir as slash-separated paths,
+// leaving out the mimetype file, the output archive itself and editor or OS
+// debris (see isJunkFile).
+func packFiles(dir, out string) ([]string, error) {
+	outInfo, _ := os.Stat(out)
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && isJunkFile(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := os.Stat(p) // follows symlinks
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || (outInfo != nil && os.SameFile(info, outInfo)) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); name != "mimetype" {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// isJunkFile reports whether a file or directory name is left behind by
+// editors, the OS or version control rather than part of the book: dotfiles
+// and dot-directories (.DS_Store, .git), Thumbs.db, desktop.ini and backup
+// files ending in "~".
+func isJunkFile(name string) bool {
+	switch {
+	case strings.HasPrefix(name, "."), strings.HasSuffix(name, "~"):
+		return true
+	case strings.EqualFold(name, "Thumbs.db"), strings.EqualFold(name, "desktop.ini"):
+		return true
+	}
+	return false
+}
+
+// checkPackDir validates META-INF/container.xml and the OPF files it points
+// to, and returns its rootfiles.
+func checkPackDir(dir string, files []string) ([]RootFile, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "META-INF", "container.xml"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: container.xml missing: %w", ErrNoContainer, err)
+	}
+	var c Container
+	if err := xml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed container.xml: %w", ErrNoContainer, err)
+	}
+	if len(c.RootFiles) == 0 {
+		return nil, fmt.Errorf("%w: no rootfile found in container.xml", ErrNoRootfile)
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, name := range files {
+		present[name] = true
+	}
+	for _, rf := range c.RootFiles {
+		if isUnsafePath(rf.FullPath) {
+			return nil, &UnsafePathError{Path: rf.FullPath, Source: "rootfile"}
+		}
+		opfPath := path.Clean(rf.FullPath)
+		if !present[opfPath] {
+			return nil, fmt.Errorf("%w: %s listed in container.xml does not exist", ErrNoRootfile, rf.FullPath)
+		}
+		opf, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(opfPath)))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parsePackageData(opfPath, opf, nil); err != nil {
+			return nil, err
+		}
+	}
+	return c.RootFiles, nil
+}
+
+func packFile(w *zip.Writer, dir, name string, normalize headerFunc) error {
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	if normalize != nil {
+		normalize(header)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fw, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, f)
+	return err
+}
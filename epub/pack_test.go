@@ -0,0 +1,148 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestDir unpacks files into a new directory.
+func writeTestDir(t *testing.T, files []testFile) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, tf := range files {
+		p := filepath.Join(dir, filepath.FromSlash(tf.Name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(tf.Content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPackDir(t *testing.T) {
+	files := append(epub2TestFiles(), testFile{"mimetype", "application/epub+zip\n"}, testFile{"Aardvark.txt", "first by name"})
+	dir := writeTestDir(t, files)
+	out := filepath.Join(t.TempDir(), "packed.epub")
+	if err := PackDir(dir, out); err != nil {
+		t.Fatalf("PackDir failed: %v", err)
+	}
+
+	r, err := Open(out)
+	if err != nil {
+		t.Fatalf("Open packed failed: %v", err)
+	}
+	defer r.Close()
+	if got := r.Package.GetTitle(); got != "Upgrade Test" {
+		t.Errorf("Expected title, got %q", got)
+	}
+
+	var names []string
+	for _, f := range r.zipReader.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"mimetype", "META-INF/container.xml", "OEBPS/content.opf", "Aardvark.txt"}
+	for i, name := range want {
+		if i >= len(names) || names[i] != name {
+			t.Fatalf("Expected order to start with %v, got %v", want, names)
+		}
+	}
+	if m := r.zipReader.File[0]; m.Method != 0 || len(m.Extra) != 0 {
+		t.Errorf("Expected mimetype stored without extra fields, got method %d extra %d", m.Method, len(m.Extra))
+	}
+	if data, _ := r.ReadFile("mimetype"); string(data) != "application/epub+zip" {
+		t.Errorf("Unexpected mimetype %q", data)
+	}
+	if r.zipReader.File[3].Method != 8 {
+		t.Errorf("Expected content deflated, got method %d", r.zipReader.File[3].Method)
+	}
+}
+
+func TestPackDir_SkipsJunk(t *testing.T) {
+	files := append(epub2TestFiles(),
+		testFile{".DS_Store", "x"},
+		testFile{"OEBPS/.DS_Store", "x"},
+		testFile{".git/HEAD", "ref: refs/heads/main"},
+		testFile{"OEBPS/Images/Thumbs.db", "x"},
+		testFile{"OEBPS/Text/ch1.xhtml~", "backup"},
+		testFile{"OEBPS/Text/notes.xhtml", "<html/>"},
+	)
+	out := filepath.Join(t.TempDir(), "packed.epub")
+	if err := PackDir(writeTestDir(t, files), out); err != nil {
+		t.Fatalf("PackDir failed: %v", err)
+	}
+	r, err := Open(out)
+	if err != nil {
+		t.Fatalf("Open packed failed: %v", err)
+	}
+	defer r.Close()
+
+	names := zipEntryNames(r)
+	for _, junk := range []string{".DS_Store", "OEBPS/.DS_Store", ".git/HEAD", "OEBPS/Images/Thumbs.db", "OEBPS/Text/ch1.xhtml~"} {
+		if names[junk] {
+			t.Errorf("Unexpected entry %s", junk)
+		}
+	}
+	if !names["OEBPS/Text/notes.xhtml"] {
+		t.Error("Expected OEBPS/Text/notes.xhtml to be packed")
+	}
+}
+
+func TestPackDir_Deterministic(t *testing.T) {
+	dir := writeTestDir(t, epub2TestFiles())
+	outDir := t.TempDir()
+	opts := PackOptions{Deterministic: true, ModTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	first := filepath.Join(outDir, "a.epub")
+	if err := PackDirWithOptions(dir, first, opts); err != nil {
+		t.Fatalf("PackDir failed: %v", err)
+	}
+	// Timestamps and permissions of the sources must not matter
+	opf := filepath.Join(dir, "OEBPS", "content.opf")
+	os.Chtimes(opf, time.Now(), time.Now().Add(time.Hour))
+	os.Chmod(opf, 0755)
+	second := filepath.Join(outDir, "b.epub")
+	if err := PackDirWithOptions(dir, second, opts); err != nil {
+		t.Fatalf("PackDir failed: %v", err)
+	}
+
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+	if !bytes.Equal(a, b) {
+		t.Error("Expected byte-identical archives")
+	}
+
+	r, err := Open(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if f, _ := r.zipEntry("OEBPS/content.opf"); !f.Modified.Equal(opts.ModTime) {
+		t.Errorf("Expected timestamp %v, got %v", opts.ModTime, f.Modified)
+	}
+}
+
+func TestPackDir_MissingOPF(t *testing.T) {
+	var files []testFile
+	for _, tf := range epub2TestFiles() {
+		if tf.Name != "OEBPS/content.opf" {
+			files = append(files, tf)
+		}
+	}
+	out := filepath.Join(t.TempDir(), "packed.epub")
+	if err := PackDir(writeTestDir(t, files), out); !errors.Is(err, ErrNoRootfile) {
+		t.Fatalf("Expected ErrNoRootfile, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("Expected no output for an invalid directory")
+	}
+
+	if err := PackDir(t.TempDir(), out); !errors.Is(err, ErrNoContainer) {
+		t.Errorf("Expected ErrNoContainer, got %v", err)
+	}
+}
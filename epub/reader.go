@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/beevik/etree"
 )
@@ -22,6 +23,13 @@ type Container struct {
 type RootFile struct {
 	FullPath  string `xml:"full-path,attr"`
 	MediaType string `xml:"media-type,attr"`
+
+	// Rendition selection attributes (EPUB Multiple-Rendition Publications)
+	Media      string `xml:"http://www.idpf.org/2013/rendition media,attr,omitempty"`
+	Layout     string `xml:"http://www.idpf.org/2013/rendition layout,attr,omitempty"`
+	Language   string `xml:"http://www.idpf.org/2013/rendition language,attr,omitempty"`
+	AccessMode string `xml:"http://www.idpf.org/2013/rendition accessMode,attr,omitempty"`
+	Label      string `xml:"http://www.idpf.org/2013/rendition label,attr,omitempty"`
 }
 
 // Reader is the main entry point for reading an EPUB file.
@@ -29,22 +37,83 @@ type Reader struct {
 	zipReader *zip.Reader
 	closer    io.Closer
 
+	// entries indexes the zip entries by name; foldedEntries by lower-case
+	// name, only with OpenOptions.CaseInsensitive.
+	entries       map[string]*zip.File
+	foldedEntries map[string]*zip.File
+
 	// file is the underlying file, needed for raw access
 	file *os.File
 
 	// OpfPath is the location of the OPF file relative to root
 	OpfPath string
 
+	// RootFiles lists every rootfile in container.xml, one per rendition.
+	// OpfPath is the one that was opened.
+	RootFiles []RootFile
+
 	// Package is the parsed OPF structure
 	Package *Package
 
 	// Replacements maps full paths to new content (for added/modified files).
 	// Used by Save() to inject content.
 	Replacements map[string][]byte
+
+	// sources maps full paths to streamed content (see SetReplacementSource).
+	sources map[string]Source
+
+	// spills are the ReaderSources set on the Reader, whose temporary files
+	// Close removes.
+	spills []*readerSource
+
+	// removed tracks full paths that Save() must drop from the archive.
+	removed map[string]bool
+
+	// obfuscation holds the identifiers the stored font obfuscation keys are
+	// derived from (see rekeyObfuscatedFonts).
+	obfuscation obfuscationIDs
+
+	// encryption caches Encryption for the original archive.
+	encryption *EncryptionInfo
+
+	// warnings lists the problems tolerated while opening (see Warnings).
+	warnings []Warning
+
+	// metadataOnly is set while Package holds only the metadata (see
+	// OpenOptions.MetadataOnly and LoadPackage).
+	metadataOnly bool
+
+	// loadErr records why the manifest of a metadata-only Reader could not
+	// be loaded; every later load, Save included, returns it.
+	loadErr error
 }
 
-// Open opens an EPUB file for reading.
+// Open opens an EPUB file for reading. It rejects unsafe paths and duplicate
+// entries but applies no size limits; use OpenWithOptions for untrusted files.
+// Multi-rendition books open their default (first) rendition.
 func Open(filepath string) (*Reader, error) {
+	return openReader(filepath, -1, noLimits)
+}
+
+// OpenWithOptions opens an EPUB file for reading with the given limits.
+func OpenWithOptions(filepath string, opts OpenOptions) (*Reader, error) {
+	return openReader(filepath, -1, opts)
+}
+
+// OpenRendition opens an EPUB file using the rootfile at index in
+// container.xml (see Reader.RootFiles). A negative index selects the default
+// rendition.
+func OpenRendition(filepath string, index int) (*Reader, error) {
+	return openReader(filepath, index, noLimits)
+}
+
+// OpenRenditionWithOptions is OpenRendition with the limits and modes of
+// opts, as in OpenWithOptions.
+func OpenRenditionWithOptions(filepath string, index int, opts OpenOptions) (*Reader, error) {
+	return openReader(filepath, index, opts)
+}
+
+func openReader(filepath string, index int, opts OpenOptions) (*Reader, error) {
 	f, err := os.Open(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -55,11 +124,22 @@ func Open(filepath string) (*Reader, error) {
 		f.Close()
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
+	return newReader(f, info.Size(), index, opts)
+}
+
+// newReader opens the archive stored in the first size bytes of f. The
+// Reader takes ownership of f.
+func newReader(f *os.File, size int64, index int, opts OpenOptions) (*Reader, error) {
+	opts = opts.withDefaults()
 
-	z, err := zip.NewReader(f, info.Size())
+	z, err := zip.NewReader(f, size)
 	if err != nil {
 		f.Close()
-		return nil, fmt.Errorf("failed to open zip reader: %w", err)
+		return nil, fmt.Errorf("failed to open zip reader: %w: %w", ErrNotZip, err)
+	}
+	if err := checkArchive(z, opts); err != nil {
+		f.Close()
+		return nil, err
 	}
 
 	r := &Reader{
@@ -67,22 +147,37 @@ func Open(filepath string) (*Reader, error) {
 		closer:    f,
 		file:      f,
 	}
+	r.indexEntries(opts.CaseInsensitive)
 
-	if err := r.parseContainer(); err != nil {
+	if err := r.parseContainer(index); err != nil {
 		r.Close()
 		return nil, fmt.Errorf("failed to parse container: %w", err)
 	}
+	if err := r.checkRootFiles(opts); err != nil {
+		r.Close()
+		return nil, err
+	}
 
-	if err := r.parseOPF(); err != nil {
+	if err := r.parseOPF(opts.MetadataOnly); err != nil {
 		r.Close()
 		return nil, fmt.Errorf("failed to parse OPF: %w", err)
 	}
+	if err := r.checkManifestPaths(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	r.obfuscation = r.Package.currentObfuscationIDs()
 
 	return r, nil
 }
 
-// Close closes the underlying zip file.
+// Close closes the underlying zip file and removes the temporary files of
+// the ReaderSources set on the Reader.
 func (r *Reader) Close() error {
+	for _, s := range r.spills {
+		s.remove()
+	}
+	r.spills = nil
 	if r.closer != nil {
 		return r.closer.Close()
 	}
@@ -90,20 +185,30 @@ func (r *Reader) Close() error {
 }
 
 // parseContainer reads META-INF/container.xml to find the OPF file.
-func (r *Reader) parseContainer() error {
+// index selects a rootfile; a negative index selects the default one.
+func (r *Reader) parseContainer(index int) error {
 	f, err := r.openFile("META-INF/container.xml")
 	if err != nil {
-		return fmt.Errorf("container.xml missing: %w", err)
+		return fmt.Errorf("%w: container.xml missing: %w", ErrNoContainer, err)
 	}
 	defer f.Close()
 
 	var c Container
 	if err := xml.NewDecoder(f).Decode(&c); err != nil {
-		return fmt.Errorf("malformed container.xml: %w", err)
+		return fmt.Errorf("%w: malformed container.xml: %w", ErrNoContainer, err)
 	}
 
 	if len(c.RootFiles) == 0 {
-		return fmt.Errorf("no rootfile found in container.xml")
+		return fmt.Errorf("%w: no rootfile found in container.xml", ErrNoRootfile)
+	}
+	r.RootFiles = c.RootFiles
+
+	if index >= 0 {
+		if index >= len(c.RootFiles) {
+			return fmt.Errorf("%w: rendition %d not found (%d rootfiles)", ErrNoRootfile, index, len(c.RootFiles))
+		}
+		r.OpfPath = c.RootFiles[index].FullPath
+		return nil
 	}
 
 	// Normally we take the first one with correct mimetype, or just the first one.
@@ -117,69 +222,283 @@ func (r *Reader) parseContainer() error {
 
 	// Fallback: take the first one
 	r.OpfPath = c.RootFiles[0].FullPath
+	r.warn(WarnFallbackRootfile, "META-INF/container.xml", "no rootfile has media-type application/oebps-package+xml; using %s (%q)",
+		r.OpfPath, c.RootFiles[0].MediaType)
 	return nil
 }
 
 // parseOPF reads and parses the OPF file using the path found in container.xml.
-func (r *Reader) parseOPF() error {
-	f, err := r.openFile(r.OpfPath)
+// With metadataOnly, only the package attributes and metadata are parsed.
+func (r *Reader) parseOPF(metadataOnly bool) error {
+	warn := func(code, message string) {
+		r.warn(code, r.OpfPath, "%s", message)
+	}
+	var pkg *Package
+	var err error
+	if metadataOnly {
+		pkg, err = r.parseMetadata(r.OpfPath, warn)
+	} else {
+		pkg, err = r.parsePackage(r.OpfPath, warn)
+	}
 	if err != nil {
-		return fmt.Errorf("OPF file %s missing: %w", r.OpfPath, err)
+		return err
 	}
-	defer f.Close()
+	r.Package = pkg
+	r.metadataOnly = metadataOnly
+	r.checkPackage()
+	return nil
+}
+
+// LoadPackage parses the manifest, spine and guide of a Reader opened with
+// OpenOptions.MetadataOnly, keeping the metadata and its pending changes. It
+// does nothing for a fully parsed package. Methods that need the manifest,
+// including Save, load it themselves.
+func (r *Reader) LoadPackage() error {
+	_, err := r.fullPackage()
+	return err
+}
 
-	// Read entire OPF content
-	data, err := io.ReadAll(f)
+// fullPackage returns the package with its manifest, spine and guide,
+// parsing them on first use for a Reader opened with MetadataOnly. Methods
+// that need the manifest get the package through it.
+func (r *Reader) fullPackage() (*Package, error) {
+	if r.loadErr != nil {
+		return nil, r.loadErr
+	}
+	if !r.metadataOnly {
+		return r.Package, nil
+	}
+	full, err := r.readPackage(r.OpfPath)
 	if err != nil {
-		return fmt.Errorf("failed to read OPF: %w", err)
+		r.loadErr = fmt.Errorf("failed to parse OPF: %w", err)
+		return nil, r.loadErr
+	}
+	r.Package.Manifest = full.Manifest
+	r.Package.Spine = full.Spine
+	r.Package.Guide = full.Guide
+	r.metadataOnly = false
+
+	if err := r.checkManifestPaths(); err != nil {
+		r.loadErr = err
+		return nil, err
+	}
+	r.checkSpine()
+	return r.Package, nil
+}
+
+// readPackage reads and parses the OPF file at opfPath, including pending
+// replacements.
+func (r *Reader) readPackage(opfPath string) (*Package, error) {
+	return r.parsePackage(opfPath, nil)
+}
+
+// parsePackage reads and parses the OPF file at opfPath, reporting the fixes
+// applied to warn when it is not nil.
+func (r *Reader) parsePackage(opfPath string, warn func(code, message string)) (*Package, error) {
+	data, err := r.readFile(opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: OPF file %s missing: %w", ErrNoRootfile, opfPath, err)
 	}
 
 	// Preprocess XML to fix common issues
-	data = preprocessOPF(data)
+	data = preprocessOPF(data, warn)
+	return parsePackageData(opfPath, data, warn)
+}
 
+// parsePackageData parses preprocessed OPF data read from opfPath.
+func parsePackageData(opfPath string, data []byte, warn func(code, message string)) (*Package, error) {
 	// Parse with etree (more tolerant than encoding/xml)
 	doc := etree.NewDocument()
 	doc.ReadSettings.CharsetReader = charsetReader
+	if warn != nil {
+		doc.ReadSettings.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+			switch {
+			case !isKnownCharset(charset):
+				warn(WarnUnknownEncoding, fmt.Sprintf("unknown encoding %q read as UTF-8", charset))
+			case isWindows1252(charset):
+				warn(WarnApproximateEncoding, fmt.Sprintf("encoding %q read as ISO-8859-1", charset))
+			}
+			return charsetReader(charset, input)
+		}
+	}
 	if err := doc.ReadFromBytes(data); err != nil {
-		return fmt.Errorf("malformed OPF: %w", err)
+		line, column := xmlErrorPosition(data)
+		return nil, &MalformedOPFError{Path: opfPath, Line: line, Column: column, Err: err}
 	}
 
 	// Convert etree document to Package structure
 	pkg, err := parsePackageFromEtree(doc)
 	if err != nil {
-		return fmt.Errorf("failed to parse OPF structure: %w", err)
+		return nil, &MalformedOPFError{Path: opfPath, Err: err}
 	}
+	return pkg, nil
+}
 
-	r.Package = pkg
-	return nil
+// parseMetadata is parsePackage for the package attributes and metadata only:
+// the OPF is cut after </metadata> so that the manifest is never parsed.
+// OPFs that cannot be cut safely are parsed in full and their manifest,
+// spine and guide dropped.
+func (r *Reader) parseMetadata(opfPath string, warn func(code, message string)) (*Package, error) {
+	data, err := r.readFile(opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: OPF file %s missing: %w", ErrNoRootfile, opfPath, err)
+	}
+	data = preprocessOPF(data, warn)
+	if head, ok := cutAfterMetadata(data); ok {
+		data = head
+	}
+
+	pkg, err := parsePackageData(opfPath, data, warn)
+	if err != nil {
+		return nil, err
+	}
+	pkg.Manifest = Manifest{}
+	pkg.Spine = Spine{}
+	pkg.Guide = nil
+	return pkg, nil
 }
 
-// openFile helps find a file in the zip by name.
-func (r *Reader) openFile(name string) (io.ReadCloser, error) {
-	// Standard zip names are forward slash, case sensitive usually.
-	// However, Windows paths might be mixed. We stick to forward slash.
-	// "container.xml" vs "META-INF/container.xml"
+// cutAfterMetadata returns the OPF up to the end of the package's metadata
+// element, closed with the package end tag. It reports false when the
+// metadata end cannot be located in the raw bytes, e.g. for encodings other
+// than UTF-8.
+func cutAfterMetadata(data []byte) ([]byte, bool) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	converted := false
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Offsets would count converted bytes
+		converted = true
+		return charsetReader(charset, input)
+	}
+
+	var root string
+	depth := 0
+	for {
+		tok, err := dec.RawToken()
+		if err != nil || converted {
+			return nil, false
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				root = t.Name.Local
+				if t.Name.Space != "" {
+					root = t.Name.Space + ":" + root
+				}
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 1 && strings.EqualFold(t.Name.Local, "metadata") {
+				end := dec.InputOffset()
+				head := make([]byte, 0, end+int64(len(root))+3)
+				head = append(head, data[:end]...)
+				return append(head, "</"+root+">"...), true
+			}
+			if depth <= 0 {
+				return nil, false
+			}
+		}
+	}
+}
+
+// xmlErrorPosition returns the line and column of the first syntax error in
+// data, 0, 0 when encoding/xml finds none.
+func xmlErrorPosition(data []byte) (line, column int) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charsetReader
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err == io.EOF {
+				return 0, 0
+			}
+			return dec.InputPos()
+		}
+	}
+}
 
+// indexEntries builds the entry name index used by zipEntry.
+func (r *Reader) indexEntries(caseInsensitive bool) {
+	r.entries = make(map[string]*zip.File, len(r.zipReader.File))
 	for _, f := range r.zipReader.File {
-		if f.Name == name {
-			return f.Open()
+		r.entries[f.Name] = f
+	}
+	if !caseInsensitive {
+		return
+	}
+	r.foldedEntries = make(map[string]*zip.File, len(r.zipReader.File))
+	for _, f := range r.zipReader.File {
+		key := strings.ToLower(f.Name)
+		// The first of several names differing only in case wins
+		if _, ok := r.foldedEntries[key]; !ok {
+			r.foldedEntries[key] = f
 		}
 	}
+}
+
+// zipEntry returns the original archive entry called name. With
+// OpenOptions.CaseInsensitive, an entry whose name differs only in case is
+// returned when none matches exactly.
+func (r *Reader) zipEntry(name string) (*zip.File, bool) {
+	if f, ok := r.entries[name]; ok {
+		return f, true
+	}
+	if r.foldedEntries != nil {
+		f, ok := r.foldedEntries[strings.ToLower(name)]
+		return f, ok
+	}
+	return nil, false
+}
+
+// openFile helps find a file in the zip by name.
+func (r *Reader) openFile(name string) (io.ReadCloser, error) {
+	// Standard zip names are forward slash and case sensitive; see zipEntry
+	// for the case-insensitive fallback.
+	if f, ok := r.zipEntry(name); ok {
+		return f.Open()
+	}
 	return nil, fmt.Errorf("file not found: %s", name)
 }
 
+// readFile returns the current content of a file by full path.
+// Pending replacements take precedence over the original archive entry.
+func (r *Reader) readFile(name string) ([]byte, error) {
+	if content, ok := r.Replacements[name]; ok {
+		return content, nil
+	}
+	src, ok := r.currentSource(name)
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", name)
+	}
+	f, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// removeFile marks a file for deletion on Save and drops any pending replacement.
+func (r *Reader) removeFile(name string) {
+	if r.removed == nil {
+		r.removed = make(map[string]bool)
+	}
+	r.removed[name] = true
+	delete(r.Replacements, name)
+	delete(r.sources, name)
+}
+
 // charsetReader implements a simple fallback for non-UTF-8 encodings.
-// Used for "Zero-dependency" requirements.
+// Used for "Zero-dependency" requirements. Charset names are matched
+// case-insensitively.
 func charsetReader(charset string, input io.Reader) (io.Reader, error) {
-	// Standard library only supports UTF-8.
-	// We can implement a simple pass-through for "us-ascii", "iso-8859-1" (latin1)
-	// which is strictly not correct (latin1 maps 1:1 to unicode points 0-255),
-	// but for now we might just support basic ones.
-
-	switch charset {
-	case "UTF-8", "utf-8", "UTF8", "utf8":
+	// Standard library only supports UTF-8; ASCII is a subset of it.
+	// ISO-8859-1 (latin1) maps 1:1 to unicode points 0-255.
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
 		return input, nil
-	case "ISO-8859-1", "iso-8859-1", "Latin1", "latin1", "Windows-1252", "windows-1252":
+	case "iso-8859-1", "latin1", "windows-1252":
 		// This creates a reader that converts Latin1/Windows-1252 to UTF-8.
 		// Windows-1252 is a superset of ISO-8859-1.
 		return &latin1Reader{r: input}, nil
@@ -188,6 +507,22 @@ func charsetReader(charset string, input io.Reader) (io.Reader, error) {
 	}
 }
 
+// isKnownCharset reports whether charsetReader decodes charset (exactly or,
+// for Windows-1252, approximately). ASCII is a subset of UTF-8.
+func isKnownCharset(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii", "iso-8859-1", "latin1", "windows-1252":
+		return true
+	}
+	return false
+}
+
+// isWindows1252 reports whether charset is Windows-1252, which charsetReader
+// decodes as ISO-8859-1 (bytes 0x80-0x9F differ).
+func isWindows1252(charset string) bool {
+	return strings.EqualFold(charset, "windows-1252")
+}
+
 type latin1Reader struct {
 	r       io.Reader
 	buf     []byte // Reused buffer
@@ -250,14 +585,23 @@ func (l *latin1Reader) Read(p []byte) (n int, err error) {
 
 // preprocessOPF fixes common XML issues that prevent parsing.
 // This improves compatibility with real-world EPUB files.
-func preprocessOPF(data []byte) []byte {
+// Each fix is reported to warn when it is not nil.
+func preprocessOPF(data []byte, warn func(code, message string)) []byte {
 	// 1. Remove XML comments containing "--" sequence (invalid per XML spec)
 	//    This fixes ~78% of parsing failures in real-world EPUBs
-	data = removeInvalidComments(data)
+	data, removed := removeInvalidComments(data)
+	if removed > 0 && warn != nil {
+		warn(WarnInvalidComment, fmt.Sprintf("removed %d comment(s) containing \"--\"", removed))
+	}
 
 	// 2. Fix common typos in namespace declaration
 	//    Example: mlns="..." should be xmlns="..."
-	data = bytes.ReplaceAll(data, []byte(" mlns="), []byte(" xmlns="))
+	if n := bytes.Count(data, []byte(" mlns=")); n > 0 {
+		data = bytes.ReplaceAll(data, []byte(" mlns="), []byte(" xmlns="))
+		if warn != nil {
+			warn(WarnNamespaceTypo, fmt.Sprintf("fixed %d namespace declaration(s) written as mlns=", n))
+		}
+	}
 
 	return data
 }
@@ -265,24 +609,27 @@ func preprocessOPF(data []byte) []byte {
 // removeInvalidComments removes XML comments that contain "--" sequences.
 // According to XML spec, "--" is not allowed within comments.
 // This function handles both single-line and multi-line comments.
-func removeInvalidComments(data []byte) []byte {
+// It returns the number of comments removed.
+func removeInvalidComments(data []byte) ([]byte, int) {
 	// Use a more careful approach: find each comment and check if it contains "--"
 	// Pattern: <!-- ... --> where ... contains "--"
 	// We need to match comment content that doesn't include --> to avoid crossing comment boundaries
 	commentRe := regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	removed := 0
 
 	result := commentRe.ReplaceAllFunc(data, func(match []byte) []byte {
 		// Check if this specific comment contains "--" (excluding the comment delimiters)
 		content := string(match[4 : len(match)-3]) // Remove <!-- and -->
 		if containsDoubleHyphen(content) {
 			// This comment has "--", remove it
+			removed++
 			return []byte{}
 		}
 		// Keep the comment as-is
 		return match
 	})
 
-	return result
+	return result, removed
 }
 
 // containsDoubleHyphen checks if a string contains "--" sequence
@@ -308,6 +655,10 @@ func parsePackageFromEtree(doc *etree.Document) (*Package, error) {
 	// Parse metadata
 	if metaElem := root.SelectElement("metadata"); metaElem != nil {
 		pkg.Metadata = parseMetadataFromEtree(metaElem)
+		pkg.oeb1Layout = metaElem.SelectElement("dc-metadata") != nil
+	}
+	if root.NamespaceURI() == NsOEB1 {
+		pkg.oeb1Layout = true
 	}
 
 	// Parse manifest
@@ -329,12 +680,28 @@ func parsePackageFromEtree(doc *etree.Document) (*Package, error) {
 	return pkg, nil
 }
 
+// metadataElements returns the metadata children named name, matched
+// case-insensitively. OEBPS 1.x nests Dublin Core elements in <dc-metadata>
+// and extra meta in <x-metadata>, and capitalizes element names (dc:Title).
+func metadataElements(elem *etree.Element, name string) []*etree.Element {
+	var result []*etree.Element
+	for _, child := range elem.ChildElements() {
+		switch tag := strings.ToLower(child.Tag); tag {
+		case "dc-metadata", "x-metadata":
+			result = append(result, metadataElements(child, name)...)
+		case name:
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
 // parseMetadataFromEtree parses metadata element
 func parseMetadataFromEtree(elem *etree.Element) Metadata {
 	meta := Metadata{}
 
 	// Parse DC elements
-	for _, title := range elem.SelectElements("title") {
+	for _, title := range metadataElements(elem, "title") {
 		meta.Titles = append(meta.Titles, SimpleMeta{
 			Value: title.Text(),
 			ID:    title.SelectAttrValue("id", ""),
@@ -342,7 +709,7 @@ func parseMetadataFromEtree(elem *etree.Element) Metadata {
 		})
 	}
 
-	for _, creator := range elem.SelectElements("creator") {
+	for _, creator := range metadataElements(elem, "creator") {
 		meta.Creators = append(meta.Creators, AuthorMeta{
 			SimpleMeta: SimpleMeta{
 				Value: creator.Text(),
@@ -353,19 +720,19 @@ func parseMetadataFromEtree(elem *etree.Element) Metadata {
 		})
 	}
 
-	for _, subj := range elem.SelectElements("subject") {
+	for _, subj := range metadataElements(elem, "subject") {
 		meta.Subjects = append(meta.Subjects, SimpleMeta{Value: subj.Text()})
 	}
 
-	for _, desc := range elem.SelectElements("description") {
+	for _, desc := range metadataElements(elem, "description") {
 		meta.Descriptions = append(meta.Descriptions, SimpleMeta{Value: desc.Text()})
 	}
 
-	for _, pub := range elem.SelectElements("publisher") {
+	for _, pub := range metadataElements(elem, "publisher") {
 		meta.Publishers = append(meta.Publishers, SimpleMeta{Value: pub.Text()})
 	}
 
-	for _, contrib := range elem.SelectElements("contributor") {
+	for _, contrib := range metadataElements(elem, "contributor") {
 		meta.Contributors = append(meta.Contributors, AuthorMeta{
 			SimpleMeta: SimpleMeta{
 				Value: contrib.Text(),
@@ -376,19 +743,19 @@ func parseMetadataFromEtree(elem *etree.Element) Metadata {
 		})
 	}
 
-	for _, date := range elem.SelectElements("date") {
+	for _, date := range metadataElements(elem, "date") {
 		meta.Dates = append(meta.Dates, SimpleMeta{Value: date.Text()})
 	}
 
-	for _, typ := range elem.SelectElements("type") {
+	for _, typ := range metadataElements(elem, "type") {
 		meta.Types = append(meta.Types, SimpleMeta{Value: typ.Text()})
 	}
 
-	for _, format := range elem.SelectElements("format") {
+	for _, format := range metadataElements(elem, "format") {
 		meta.Formats = append(meta.Formats, SimpleMeta{Value: format.Text()})
 	}
 
-	for _, id := range elem.SelectElements("identifier") {
+	for _, id := range metadataElements(elem, "identifier") {
 		meta.Identifiers = append(meta.Identifiers, IDMeta{
 			Value:  id.Text(),
 			ID:     id.SelectAttrValue("id", ""),
@@ -396,20 +763,20 @@ func parseMetadataFromEtree(elem *etree.Element) Metadata {
 		})
 	}
 
-	for _, src := range elem.SelectElements("source") {
+	for _, src := range metadataElements(elem, "source") {
 		meta.Sources = append(meta.Sources, SimpleMeta{Value: src.Text()})
 	}
 
-	for _, lang := range elem.SelectElements("language") {
+	for _, lang := range metadataElements(elem, "language") {
 		meta.Languages = append(meta.Languages, SimpleMeta{Value: lang.Text()})
 	}
 
-	for _, rights := range elem.SelectElements("rights") {
+	for _, rights := range metadataElements(elem, "rights") {
 		meta.Rights = append(meta.Rights, SimpleMeta{Value: rights.Text()})
 	}
 
-	// Parse meta tags
-	for _, metaTag := range elem.SelectElements("meta") {
+	// Parse meta tags (OEBPS 1.x keeps them in x-metadata)
+	for _, metaTag := range metadataElements(elem, "meta") {
 		meta.Meta = append(meta.Meta, Meta{
 			ID:       metaTag.SelectAttrValue("id", ""),
 			Name:     metaTag.SelectAttrValue("name", ""),
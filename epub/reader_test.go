@@ -2,6 +2,7 @@ package epub
 
 import (
 	"archive/zip"
+	"errors"
 	"os"
 	"testing"
 )
@@ -83,3 +84,111 @@ func TestOpen(t *testing.T) {
          t.Errorf("Meta name wrong: %s", r.Package.Metadata.Meta[0].Name)
     }
 }
+
+func TestOpenMetadataOnly(t *testing.T) {
+	path := writeTestEPUB(t, epub2TestFiles())
+	r, err := OpenWithOptions(path, OpenOptions{MetadataOnly: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if got := r.Package.GetTitle(); got != "Upgrade Test" {
+		t.Errorf("Expected title, got %q", got)
+	}
+	if len(r.Package.Metadata.Identifiers) != 3 {
+		t.Errorf("Expected 3 identifiers, got %d", len(r.Package.Metadata.Identifiers))
+	}
+	if len(r.Package.Manifest.Items) != 0 || len(r.Package.Spine.ItemRefs) != 0 || r.Package.Guide != nil {
+		t.Fatalf("Expected no manifest, spine or guide, got %+v", r.Package)
+	}
+
+	// Metadata changes survive loading the rest of the package
+	r.Package.SetTitle("Lazy Title")
+	rc, _, err := r.GetCoverImage()
+	if err != nil {
+		t.Fatalf("GetCoverImage failed: %v", err)
+	}
+	rc.Close()
+	if len(r.Package.Manifest.Items) != 5 || len(r.Package.Spine.ItemRefs) != 3 || r.Package.Guide == nil {
+		t.Errorf("Package not loaded: %+v", r.Package)
+	}
+	if got := r.Package.GetTitle(); got != "Lazy Title" {
+		t.Errorf("Title change lost: %q", got)
+	}
+}
+
+func TestOpenMetadataOnly_Save(t *testing.T) {
+	r, err := OpenWithOptions(writeTestEPUB(t, epub2TestFiles()), OpenOptions{MetadataOnly: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.Package.SetTitle("Lazy Title")
+	r2 := saveAndReopen(t, r)
+	if got := r2.Package.GetTitle(); got != "Lazy Title" {
+		t.Errorf("Expected saved title, got %q", got)
+	}
+	if len(r2.Package.Manifest.Items) != 5 || len(r2.Package.Spine.ItemRefs) != 3 {
+		t.Errorf("Save dropped the manifest or spine: %+v", r2.Package)
+	}
+}
+
+func TestCutAfterMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		opf  string
+		want string
+	}{
+		{"default namespace",
+			`<package xmlns="http://www.idpf.org/2007/opf"><metadata><title>T</title></metadata><manifest/></package>`,
+			`<package xmlns="http://www.idpf.org/2007/opf"><metadata><title>T</title></metadata></package>`},
+		{"prefixed",
+			`<opf:package xmlns:opf="http://www.idpf.org/2007/opf"><opf:metadata/><opf:manifest/></opf:package>`,
+			`<opf:package xmlns:opf="http://www.idpf.org/2007/opf"><opf:metadata/></opf:package>`},
+		{"nested metadata", // OEBPS 1.x dc-metadata inside metadata
+			`<package><metadata><dc-metadata><x/></dc-metadata></metadata><manifest/></package>`,
+			`<package><metadata><dc-metadata><x/></dc-metadata></metadata></package>`},
+		{"converted encoding", `<?xml version="1.0" encoding="windows-1252"?><package><metadata/></package>`, ""},
+		{"no metadata", `<package><manifest/></package>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cutAfterMetadata([]byte(tt.opf))
+			if ok != (tt.want != "") || string(got) != tt.want {
+				t.Errorf("cutAfterMetadata = %q, %v; want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenCaseInsensitive(t *testing.T) {
+	files := epub2TestFiles()
+	for i := range files {
+		if files[i].Name == "OEBPS/Images/cover.jpg" {
+			files[i].Name = "OEBPS/images/Cover.JPG"
+		}
+	}
+	path := writeTestEPUB(t, files)
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	if _, _, err := r.GetCoverImage(); !errors.Is(err, ErrNoCover) {
+		t.Errorf("Expected ErrNoCover without CaseInsensitive, got %v", err)
+	}
+
+	r2, err := OpenWithOptions(path, OpenOptions{CaseInsensitive: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r2.Close()
+	rc, _, err := r2.GetCoverImage()
+	if err != nil {
+		t.Fatalf("GetCoverImage failed: %v", err)
+	}
+	rc.Close()
+}
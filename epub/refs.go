@@ -0,0 +1,149 @@
+package epub
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Reference rewriting keeps internal links valid when resources move.
+// Documents are scanned with targeted patterns instead of a full XML
+// round-trip so that unrelated bytes (whitespace, entities, malformed
+// markup) are preserved exactly.
+
+var (
+	// tagRefRe matches a start tag; attributes are scanned separately.
+	tagRefRe = regexp.MustCompile(`(?s)<[A-Za-z][^<>]*>`)
+	// attrRefRe matches link-carrying attributes in XHTML, SVG, NCX and SMIL.
+	attrRefRe = regexp.MustCompile(`(?i)\s(?:href|src|xlink:href|poster|data|altimg)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	// cssURLRe matches url(...) in stylesheets and style attributes.
+	cssURLRe = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^"')\s]+))\s*\)`)
+	// cssImportRe matches @import "..." without url().
+	cssImportRe = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)')`)
+	// schemeRe detects absolute URLs such as http:, mailto:, data:.
+	schemeRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// isMarkupMediaType reports whether links are stored in element attributes.
+func isMarkupMediaType(mediaType string) bool {
+	switch strings.ToLower(mediaType) {
+	case "application/xhtml+xml", "text/html", "application/x-dtbncx+xml",
+		"image/svg+xml", "application/smil+xml", "application/xml", "text/xml":
+		return true
+	}
+	return false
+}
+
+// isStyleMediaType reports whether the resource is a CSS stylesheet.
+func isStyleMediaType(mediaType string) bool {
+	return strings.EqualFold(mediaType, "text/css")
+}
+
+// isExternalRef reports whether a link points outside the publication
+// (absolute URL, protocol-relative, root-absolute) or within the same document.
+func isExternalRef(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	return ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "/") || schemeRe.MatchString(ref)
+}
+
+// splitRef splits a link into its path and its "?query#fragment" suffix.
+func splitRef(ref string) (string, string) {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	return ref, ""
+}
+
+// encodeRefPath percent-encodes a path when the original link was encoded.
+func encodeRefPath(p string, encoded bool) string {
+	if !encoded {
+		return p
+	}
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+// rewriteRefs rewrites every internal link in a document.
+// oldDir and newDir are the document's directories before and after the move;
+// moves maps old full paths to new full paths.
+func rewriteRefs(data []byte, mediaType, oldDir, newDir string, moves map[string]string) ([]byte, bool) {
+	rewrite := func(ref string) (string, bool) {
+		if isExternalRef(ref) {
+			return ref, false
+		}
+		p, suffix := splitRef(ref)
+		if p == "" {
+			return ref, false
+		}
+		target := resolveRelative(oldDir, p)
+		newTarget, moved := moves[target]
+		if !moved {
+			if oldDir == newDir {
+				return ref, false
+			}
+			newTarget = target
+		}
+		newRef := encodeRefPath(relativeHref(newDir, newTarget), strings.Contains(p, "%")) + suffix
+		return newRef, newRef != ref
+	}
+
+	changed := false
+	if isMarkupMediaType(mediaType) {
+		data = tagRefRe.ReplaceAllFunc(data, func(tag []byte) []byte {
+			out, ok := replaceSubmatches(tag, attrRefRe, rewrite)
+			if ok {
+				changed = true
+			}
+			return out
+		})
+	}
+	if isMarkupMediaType(mediaType) || isStyleMediaType(mediaType) {
+		var ok bool
+		if data, ok = replaceSubmatches(data, cssURLRe, rewrite); ok {
+			changed = true
+		}
+	}
+	if isStyleMediaType(mediaType) {
+		var ok bool
+		if data, ok = replaceSubmatches(data, cssImportRe, rewrite); ok {
+			changed = true
+		}
+	}
+	return data, changed
+}
+
+// replaceSubmatches applies fn to the first non-empty capture group of every match of re.
+func replaceSubmatches(data []byte, re *regexp.Regexp, fn func(string) (string, bool)) ([]byte, bool) {
+	matches := re.FindAllSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return data, false
+	}
+
+	var out []byte
+	last := 0
+	changed := false
+	for _, m := range matches {
+		start, end := -1, -1
+		for g := 2; g+1 < len(m); g += 2 {
+			if m[g] >= 0 {
+				start, end = m[g], m[g+1]
+				break
+			}
+		}
+		if start < 0 {
+			continue
+		}
+		newRef, ok := fn(string(data[start:end]))
+		if !ok {
+			continue
+		}
+		out = append(out, data[last:start]...)
+		out = append(out, newRef...)
+		last = end
+		changed = true
+	}
+	if !changed {
+		return data, false
+	}
+	out = append(out, data[last:]...)
+	return out, true
+}
@@ -0,0 +1,156 @@
+package epub
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRewriteRefs_XHTML(t *testing.T) {
+	moves := map[string]string{
+		"OEBPS/images/a b.png": "OEBPS/Images/a b.png",
+		"OEBPS/style.css":      "OEBPS/Styles/style.css",
+		"OEBPS/text/ch2.xhtml": "OEBPS/Text/ch2.xhtml",
+	}
+	doc := `<html><head><link rel="stylesheet" href="../style.css"/></head>
+<body style="background: url('../images/a%20b.png')">
+<p>src="../style.css" is text, not a link</p>
+<img src="../images/a%20b.png" alt=""/>
+<a href="ch2.xhtml#sec1">next</a>
+<a href="#local">local</a>
+<a href="http://example.com/ch2.xhtml">web</a>
+<svg><image xlink:href="../images/a%20b.png"/></svg>
+</body></html>`
+
+	out, changed := rewriteRefs([]byte(doc), "application/xhtml+xml", "OEBPS/text", "OEBPS/Text", moves)
+	if !changed {
+		t.Fatal("Expected document to change")
+	}
+	got := string(out)
+	for _, want := range []string{
+		`href="../Styles/style.css"`,
+		`url('../Images/a%20b.png')`,
+		`src="../Images/a%20b.png"`,
+		`href="ch2.xhtml#sec1"`,
+		`href="#local"`,
+		`href="http://example.com/ch2.xhtml"`,
+		`xlink:href="../Images/a%20b.png"`,
+		`<p>src="../style.css" is text, not a link</p>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Missing %s in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRewriteRefs_CSSAndNCX(t *testing.T) {
+	moves := map[string]string{
+		"fonts/f.ttf":  "OEBPS/Fonts/f.ttf",
+		"base.css":     "OEBPS/Styles/base.css",
+		"text/p1.html": "OEBPS/Text/p1.html",
+	}
+
+	css := `@import "base.css"; @font-face { src: url(fonts/f.ttf); }`
+	out, _ := rewriteRefs([]byte(css), "text/css", ".", "OEBPS/Styles", moves)
+	if got := string(out); !strings.Contains(got, `@import "base.css"`) || !strings.Contains(got, `url(../Fonts/f.ttf)`) {
+		t.Errorf("Unexpected CSS: %s", got)
+	}
+
+	ncx := `<navMap><navPoint><content src="text/p1.html#c1"/></navPoint></navMap>`
+	out, _ = rewriteRefs([]byte(ncx), "application/x-dtbncx+xml", ".", "OEBPS", moves)
+	if got := string(out); !strings.Contains(got, `src="Text/p1.html#c1"`) {
+		t.Errorf("Unexpected NCX: %s", got)
+	}
+}
+
+func TestRenameResource_RewritesReferences(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	if err := r.RenameResource("ch1", "Chapters/one.xhtml"); err != nil {
+		t.Fatalf("RenameResource failed: %v", err)
+	}
+	r2 := saveAndReopen(t, r)
+
+	nav, _ := r2.readFile("OEBPS/Text/nav.xhtml")
+	if !strings.Contains(string(nav), `href="../Chapters/one.xhtml"`) {
+		t.Errorf("Nav link not rewritten:\n%s", nav)
+	}
+}
+
+// brokenLinks counts internal links in content documents that resolve to missing files.
+func brokenLinks(t *testing.T, r *Reader) int {
+	t.Helper()
+	broken := 0
+	for _, item := range r.Package.Manifest.Items {
+		if !isMarkupMediaType(item.MediaType) && !isStyleMediaType(item.MediaType) {
+			continue
+		}
+		docPath := r.resolveHref(item.Href)
+		data, err := r.readFile(docPath)
+		if err != nil {
+			continue
+		}
+		check := func(ref string) (string, bool) {
+			if p, _ := splitRef(ref); !isExternalRef(ref) && p != "" {
+				if !r.fileExists(resolveRelative(path.Dir(docPath), p)) {
+					broken++
+				}
+			}
+			return ref, false
+		}
+		tagRefRe.ReplaceAllFunc(data, func(tag []byte) []byte {
+			replaceSubmatches(tag, attrRefRe, check)
+			return tag
+		})
+		replaceSubmatches(data, cssURLRe, check)
+	}
+	return broken
+}
+
+func TestRestructure_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub2/215584.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215714.epub",
+		"../cmd/test-suite/testdata/samples/epub3-hybrid/215589.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			brokenBefore := brokenLinks(t, r)
+			title := r.Package.GetTitle()
+
+			if _, err := r.Restructure(); err != nil {
+				t.Fatalf("Restructure failed: %v", err)
+			}
+			r2 := saveAndReopen(t, r)
+
+			if r2.OpfPath != "OEBPS/content.opf" {
+				t.Errorf("OPF not relocated: %s", r2.OpfPath)
+			}
+			if r2.Package.GetTitle() != title {
+				t.Errorf("Title changed: %s", r2.Package.GetTitle())
+			}
+			for _, item := range r2.Package.Manifest.Items {
+				full := r2.resolveHref(item.Href)
+				if !r2.fileExists(full) {
+					t.Errorf("Manifest item %s points to missing file %s", item.ID, full)
+				}
+				if folder := layoutFolder(item.MediaType); folder != "" && path.Dir(full) != "OEBPS/"+folder {
+					t.Errorf("Item %s not in layout folder: %s", item.ID, full)
+				}
+			}
+			if after := brokenLinks(t, r2); after > brokenBefore {
+				t.Errorf("Broken links increased: before=%d after=%d", brokenBefore, after)
+			}
+		})
+	}
+}
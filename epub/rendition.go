@@ -0,0 +1,95 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+)
+
+// IsPackage reports whether the rootfile is an OPF package document.
+func (rf RootFile) IsPackage() bool {
+	return rf.MediaType == "" || rf.MediaType == "application/oebps-package+xml"
+}
+
+// EditRenditions applies edit to the package of every rendition: the opened
+// one (r.Package) and the other OPF rootfiles listed in container.xml.
+// Edits to other renditions are stored as replacements and written by Save.
+// Only package-level changes are supported; resources such as the cover image
+// belong to a single rendition's manifest.
+//
+// Fonts listed only in another rendition's manifest are re-obfuscated when
+// the edit changes that rendition's identifiers.
+func (r *Reader) EditRenditions(edit func(pkg *Package) error) error {
+	own, err := r.fullPackage()
+	if err != nil {
+		return err
+	}
+	if err := edit(own); err != nil {
+		return err
+	}
+	ownPaths := manifestPaths(own, r.OpfPath)
+	for _, rf := range r.RootFiles {
+		if rf.FullPath == r.OpfPath || !rf.IsPackage() {
+			continue
+		}
+		pkg, err := r.readPackage(rf.FullPath)
+		if err != nil {
+			return fmt.Errorf("failed to read rendition %s: %w", rf.FullPath, err)
+		}
+		old := pkg.currentObfuscationIDs()
+		if err := edit(pkg); err != nil {
+			return fmt.Errorf("rendition %s: %w", rf.FullPath, err)
+		}
+		data, err := pkg.marshalOPFWithEtree()
+		if err != nil {
+			return fmt.Errorf("failed to marshal rendition %s: %w", rf.FullPath, err)
+		}
+		r.setReplacement(rf.FullPath, data)
+
+		paths := manifestPaths(pkg, rf.FullPath)
+		changes, err := r.rekeyedFonts(old, pkg.currentObfuscationIDs(), func(fullPath string) bool {
+			return paths[fullPath] && !ownPaths[fullPath]
+		})
+		if err != nil {
+			return fmt.Errorf("failed to re-obfuscate fonts of rendition %s: %w", rf.FullPath, err)
+		}
+		r.applyRekeys(changes)
+	}
+	return nil
+}
+
+// manifestPaths returns the full paths of the local manifest items of pkg,
+// the package document at opfPath.
+func manifestPaths(pkg *Package, opfPath string) map[string]bool {
+	paths := make(map[string]bool, len(pkg.Manifest.Items))
+	for _, item := range pkg.Manifest.Items {
+		if !isExternalRef(item.Href) {
+			paths[resolveRelative(path.Dir(opfPath), item.Href)] = true
+		}
+	}
+	return paths
+}
+
+// otherRenditionPaths returns the full paths that only the manifests of
+// renditions other than the opened one list.
+func (r *Reader) otherRenditionPaths() map[string]bool {
+	others := make(map[string]bool)
+	if len(r.RootFiles) < 2 {
+		return others
+	}
+	own := manifestPaths(r.Package, r.OpfPath)
+	for _, rf := range r.RootFiles {
+		if rf.FullPath == r.OpfPath || !rf.IsPackage() {
+			continue
+		}
+		pkg, err := r.readPackage(rf.FullPath)
+		if err != nil {
+			continue
+		}
+		for p := range manifestPaths(pkg, rf.FullPath) {
+			if !own[p] {
+				others[p] = true
+			}
+		}
+	}
+	return others
+}
@@ -0,0 +1,185 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func renditionTestFiles() []testFile {
+	opf := func(title, layout string) string {
+		return `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>` + title + `</dc:title>
+    <dc:identifier id="uid">urn:uuid:1234</dc:identifier>
+    <dc:language>en</dc:language>
+    <meta property="rendition:layout">` + layout + `</meta>
+  </metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="ch1"/></spine>
+</package>`
+	}
+	return []testFile{
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:rendition="http://www.idpf.org/2013/rendition">
+  <rootfiles>
+    <rootfile full-path="fixed/content.opf" media-type="application/oebps-package+xml" rendition:layout="pre-paginated" rendition:media="(min-width: 1024px)" rendition:label="Fixed"/>
+    <rootfile full-path="reflow/content.opf" media-type="application/oebps-package+xml" rendition:layout="reflowable" rendition:language="en" rendition:accessMode="textual" rendition:label="Reflowable"/>
+    <rootfile full-path="book.pdf" media-type="application/pdf"/>
+  </rootfiles>
+</container>`},
+		{"fixed/content.opf", opf("Fixed Edition", "pre-paginated")},
+		{"fixed/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Fixed</p></body></html>`},
+		{"reflow/content.opf", opf("Reflowable Edition", "reflowable")},
+		{"reflow/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Reflow</p></body></html>`},
+		{"book.pdf", "%PDF-1.4"},
+	}
+}
+
+func TestRootFiles(t *testing.T) {
+	path := writeTestEPUB(t, renditionTestFiles())
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.OpfPath != "fixed/content.opf" || r.Package.GetTitle() != "Fixed Edition" {
+		t.Errorf("Expected default rendition, got %s (%q)", r.OpfPath, r.Package.GetTitle())
+	}
+	if len(r.RootFiles) != 3 {
+		t.Fatalf("Expected 3 rootfiles, got %d", len(r.RootFiles))
+	}
+	fixed, reflow := r.RootFiles[0], r.RootFiles[1]
+	if fixed.Layout != "pre-paginated" || fixed.Media != "(min-width: 1024px)" || fixed.Label != "Fixed" {
+		t.Errorf("Unexpected fixed rootfile: %+v", fixed)
+	}
+	if reflow.Layout != "reflowable" || reflow.Language != "en" || reflow.AccessMode != "textual" {
+		t.Errorf("Unexpected reflowable rootfile: %+v", reflow)
+	}
+	if r.RootFiles[2].IsPackage() {
+		t.Error("PDF rootfile reported as a package")
+	}
+
+	r2, err := OpenRendition(path, 1)
+	if err != nil {
+		t.Fatalf("OpenRendition failed: %v", err)
+	}
+	defer r2.Close()
+	if r2.OpfPath != "reflow/content.opf" || r2.Package.GetTitle() != "Reflowable Edition" {
+		t.Errorf("Expected reflowable rendition, got %s (%q)", r2.OpfPath, r2.Package.GetTitle())
+	}
+	if data, err := r2.ReadItem("ch1.xhtml"); err != nil || !strings.Contains(string(data), "Reflow") {
+		t.Errorf("Content not resolved against the selected rendition: %v", err)
+	}
+
+	if _, err := OpenRendition(path, 5); err == nil {
+		t.Error("Expected error for a missing rendition")
+	}
+}
+
+func TestEditRenditions(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, renditionTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	err = r.EditRenditions(func(pkg *Package) error {
+		pkg.SetAuthor("Jane Doe")
+		pkg.SetSeries("Shared Series")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EditRenditions failed: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.epub")
+	if err := r.Save(out); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	for i, title := range []string{"Fixed Edition", "Reflowable Edition"} {
+		r2, err := OpenRendition(out, i)
+		if err != nil {
+			t.Fatalf("OpenRendition(%d) failed: %v", i, err)
+		}
+		pkg := r2.Package
+		if pkg.GetTitle() != title || pkg.GetAuthor() != "Jane Doe" || pkg.GetSeries() != "Shared Series" {
+			t.Errorf("Rendition %d not edited: %q %q %q", i, pkg.GetTitle(), pkg.GetAuthor(), pkg.GetSeries())
+		}
+		r2.Close()
+	}
+}
+
+func TestOpenRenditionWithOptions(t *testing.T) {
+	path := writeTestEPUB(t, renditionTestFiles())
+
+	r, err := OpenRenditionWithOptions(path, 1, OpenOptions{MetadataOnly: true})
+	if err != nil {
+		t.Fatalf("OpenRenditionWithOptions failed: %v", err)
+	}
+	defer r.Close()
+	if r.OpfPath != "reflow/content.opf" || r.Package.GetTitle() != "Reflowable Edition" {
+		t.Errorf("Expected reflowable rendition, got %s (%q)", r.OpfPath, r.Package.GetTitle())
+	}
+	if len(r.Package.Manifest.Items) != 0 {
+		t.Error("Expected only the metadata to be parsed")
+	}
+
+	if _, err := OpenRenditionWithOptions(path, 1, OpenOptions{MaxEntries: 3}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected the entry limit to apply, got %v", err)
+	}
+}
+
+func TestEditRenditions_RekeysFonts(t *testing.T) {
+	files := renditionTestFiles()
+	for i := range files {
+		if files[i].Name == "reflow/content.opf" {
+			files[i].Content = strings.Replace(files[i].Content, "<manifest>",
+				`<manifest><item id="font" href="Fonts/a.otf" media-type="font/otf"/>`, 1)
+		}
+	}
+	files = append(files,
+		testFile{"META-INF/encryption.xml", encryptionXML(strings.Replace(idpfFontEntry, "OEBPS/Fonts/My%20Font.otf", "reflow/Fonts/a.otf", 1))},
+		testFile{"reflow/Fonts/a.otf", string(mustObfuscate(t, testFont(1), AlgorithmIDPFObfuscation, "urn:uuid:1234"))},
+	)
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	err = r.EditRenditions(func(pkg *Package) error {
+		for i := range pkg.Metadata.Identifiers {
+			if pkg.Metadata.Identifiers[i].ID == "uid" {
+				pkg.Metadata.Identifiers[i].Value = "urn:uuid:5678"
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EditRenditions failed: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "out.epub")
+	if err := r.Save(out); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	r2, err := OpenRendition(out, 1)
+	if err != nil {
+		t.Fatalf("OpenRendition failed: %v", err)
+	}
+	defer r2.Close()
+	data, err := r2.ReadFont("reflow/Fonts/a.otf")
+	if err != nil {
+		t.Fatalf("ReadFont failed: %v", err)
+	}
+	if !bytes.Equal(data, testFont(1)) {
+		t.Error("Font not re-keyed for the edited rendition")
+	}
+}
@@ -0,0 +1,342 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// AddResource adds a new file to the EPUB and registers it in the manifest.
+// href is relative to the OPF. It returns the generated manifest item id.
+func (r *Reader) AddResource(href, mediaType string, data []byte, properties string) (string, error) {
+	return r.AddResourceFrom(href, mediaType, BytesSource(data), properties)
+}
+
+// AddResourceFrom is like AddResource but streams the content from src during Save.
+func (r *Reader) AddResourceFrom(href, mediaType string, src Source, properties string) (string, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return "", err
+	}
+	fullPath, err := r.resourcePath(href)
+	if err != nil {
+		return "", err
+	}
+	if r.fileExists(fullPath) || r.isManifestPath(fullPath) {
+		return "", fmt.Errorf("resource already exists: %s", fullPath)
+	}
+
+	base := strings.TrimSuffix(path.Base(fullPath), path.Ext(fullPath))
+	id := r.uniqueItemID(sanitizeID(base))
+
+	r.SetReplacementSource(fullPath, src)
+
+	pkg.Manifest.Items = append(pkg.Manifest.Items, Item{
+		ID:         id,
+		Href:       encodedHref(path.Dir(r.OpfPath), fullPath),
+		MediaType:  mediaType,
+		Properties: properties,
+	})
+	return id, nil
+}
+
+// ReplaceResource replaces the content of an existing manifest item.
+func (r *Reader) ReplaceResource(id string, data []byte) error {
+	if _, err := r.fullPackage(); err != nil {
+		return err
+	}
+	item := r.findItem(id)
+	if item == nil {
+		return fmt.Errorf("manifest item %s not found", id)
+	}
+
+	r.setReplacement(r.resolveHref(item.Href), data)
+	return nil
+}
+
+// ReplaceResourceFrom is like ReplaceResource but streams the content from src during Save.
+func (r *Reader) ReplaceResourceFrom(id string, src Source) error {
+	if _, err := r.fullPackage(); err != nil {
+		return err
+	}
+	item := r.findItem(id)
+	if item == nil {
+		return fmt.Errorf("manifest item %s not found", id)
+	}
+
+	r.SetReplacementSource(r.resolveHref(item.Href), src)
+	return nil
+}
+
+// RemoveResource deletes a manifest item and its file.
+// Spine itemrefs, guide references, fallbacks and the NCX/cover pointers
+// that refer to the item are removed as well.
+func (r *Reader) RemoveResource(id string) error {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return err
+	}
+	item := r.findItem(id)
+	if item == nil {
+		return fmt.Errorf("manifest item %s not found", id)
+	}
+	fullPath := r.resolveHref(item.Href)
+
+	// 1. Update Manifest (and fallback chains pointing at the item)
+	var items []Item
+	for _, it := range pkg.Manifest.Items {
+		if it.ID == id {
+			continue
+		}
+		if it.Fallback == id {
+			it.Fallback = ""
+		}
+		if it.MediaOverlay == id {
+			it.MediaOverlay = ""
+		}
+		items = append(items, it)
+	}
+	pkg.Manifest.Items = items
+
+	// 2. Delete the file unless another item still references it
+	if !r.isManifestPath(fullPath) {
+		r.removeFile(fullPath)
+	}
+
+	// 3. Update Spine
+	var itemRefs []ItemRef
+	for _, ref := range pkg.Spine.ItemRefs {
+		if ref.IDRef != id {
+			itemRefs = append(itemRefs, ref)
+		}
+	}
+	pkg.Spine.ItemRefs = itemRefs
+	if pkg.Spine.Toc == id {
+		pkg.Spine.Toc = ""
+	}
+
+	// 4. Update Guide
+	if pkg.Guide != nil {
+		var refs []Reference
+		for _, ref := range pkg.Guide.References {
+			if r.resolveHref(ref.Href) != fullPath {
+				refs = append(refs, ref)
+			}
+		}
+		pkg.Guide.References = refs
+	}
+
+	// 5. Update Metadata (EPUB 2 <meta name="cover">)
+	var metas []Meta
+	for _, m := range pkg.Metadata.Meta {
+		if m.Name == "cover" && m.Content == id {
+			continue
+		}
+		metas = append(metas, m)
+	}
+	pkg.Metadata.Meta = metas
+
+	return nil
+}
+
+// RenameResource moves a manifest item to newHref (relative to the OPF).
+// The file is moved in the archive, manifest/guide hrefs are updated and
+// links in XHTML, CSS, NCX and navigation documents are rewritten.
+func (r *Reader) RenameResource(id, newHref string) error {
+	return r.RenameResources(map[string]string{id: newHref})
+}
+
+// RenameResources moves several manifest items at once, keyed by item id.
+// All references are rewritten in a single pass over the content documents.
+func (r *Reader) RenameResources(renames map[string]string) error {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return err
+	}
+	// 1. Validate and compute full-path moves
+	moves := make(map[string]string)
+	items := make(map[string]*Item)
+	targets := make(map[string]bool)
+	for id, newHref := range renames {
+		item := r.findItem(id)
+		if item == nil {
+			return fmt.Errorf("manifest item %s not found", id)
+		}
+		newPath, err := r.resourcePath(newHref)
+		if err != nil {
+			return err
+		}
+		oldPath := r.resolveHref(item.Href)
+		if newPath == oldPath {
+			continue
+		}
+		if targets[newPath] {
+			return fmt.Errorf("duplicate rename target: %s", newPath)
+		}
+		if !r.fileExists(oldPath) {
+			return fmt.Errorf("resource file not found: %s", oldPath)
+		}
+		targets[newPath] = true
+		moves[oldPath] = newPath
+		items[oldPath] = item
+	}
+	for _, newPath := range moves {
+		if _, movingAway := moves[newPath]; movingAway {
+			continue
+		}
+		if r.fileExists(newPath) || r.isManifestPath(newPath) {
+			return fmt.Errorf("resource already exists: %s", newPath)
+		}
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+
+	// 2. Rewrite links in every content document (from its current location)
+	contents := make(map[string][]byte)
+	for _, item := range pkg.Manifest.Items {
+		if !isMarkupMediaType(item.MediaType) && !isStyleMediaType(item.MediaType) {
+			continue
+		}
+		docPath := r.resolveHref(item.Href)
+		if _, done := contents[docPath]; done {
+			continue
+		}
+		data, err := r.readFile(docPath)
+		if err != nil {
+			continue
+		}
+		newDocPath := docPath
+		if p, ok := moves[docPath]; ok {
+			newDocPath = p
+		}
+		if updated, changed := rewriteRefs(data, item.MediaType, path.Dir(docPath), path.Dir(newDocPath), moves); changed {
+			contents[docPath] = updated
+		}
+	}
+
+	// 3. Move files: capture all sources before removing anything (handles swaps).
+	// Unchanged files are streamed from their current location, not buffered.
+	moved := make(map[string]Source)
+	for oldPath, newPath := range moves {
+		if _, ok := contents[oldPath]; ok {
+			continue
+		}
+		src, ok := r.currentSource(oldPath)
+		if !ok {
+			return fmt.Errorf("resource file not found: %s", oldPath)
+		}
+		moved[newPath] = src
+	}
+	for oldPath := range moves {
+		r.removeFile(oldPath)
+	}
+	for newPath, src := range moved {
+		r.SetReplacementSource(newPath, src)
+	}
+	for docPath, data := range contents {
+		if newPath, ok := moves[docPath]; ok {
+			r.setReplacement(newPath, data)
+		} else {
+			r.setReplacement(docPath, data)
+		}
+	}
+
+	// 4. Update manifest and guide hrefs (guide references keep their fragment)
+	opfDir := path.Dir(r.OpfPath)
+	for oldPath, newPath := range moves {
+		items[oldPath].Href = encodedHref(opfDir, newPath)
+	}
+	if pkg.Guide != nil {
+		for i, ref := range pkg.Guide.References {
+			if newPath, ok := moves[r.resolveHref(ref.Href)]; ok {
+				pkg.Guide.References[i].Href = encodedHref(opfDir, newPath) + hrefFragment(ref.Href)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rewriteBookRefs rewrites the links to moved files in every content
+// document and stylesheet of the manifest. The documents themselves stay in
+// place.
+func (r *Reader) rewriteBookRefs(moves map[string]string) {
+	done := make(map[string]bool)
+	for _, item := range r.Package.Manifest.Items {
+		if !isMarkupMediaType(item.MediaType) && !isStyleMediaType(item.MediaType) {
+			continue
+		}
+		docPath := r.resolveHref(item.Href)
+		if done[docPath] {
+			continue
+		}
+		done[docPath] = true
+		data, err := r.readFile(docPath)
+		if err != nil {
+			continue
+		}
+		if updated, changed := rewriteRefs(data, item.MediaType, path.Dir(docPath), path.Dir(docPath), moves); changed {
+			r.setReplacement(docPath, updated)
+		}
+	}
+}
+
+// findItem returns the manifest item with the given id, or nil.
+func (r *Reader) findItem(id string) *Item {
+	for i := range r.Package.Manifest.Items {
+		if r.Package.Manifest.Items[i].ID == id {
+			return &r.Package.Manifest.Items[i]
+		}
+	}
+	return nil
+}
+
+// resourcePath validates an OPF-relative href and returns its full zip path.
+// Absolute paths, URLs and paths escaping the archive root are rejected.
+func (r *Reader) resourcePath(href string) (string, error) {
+	if href == "" {
+		return "", fmt.Errorf("empty resource href")
+	}
+	if strings.HasPrefix(href, "/") || strings.Contains(href, "://") || strings.Contains(href, "#") {
+		return "", fmt.Errorf("invalid resource href: %s", href)
+	}
+	fullPath := r.resolveHref(href)
+	if fullPath == "." || fullPath == ".." || strings.HasPrefix(fullPath, "../") {
+		return "", fmt.Errorf("resource href escapes the archive: %s", href)
+	}
+	if fullPath == "mimetype" || fullPath == r.OpfPath || strings.HasPrefix(fullPath, "META-INF/") {
+		return "", fmt.Errorf("reserved resource path: %s", fullPath)
+	}
+	return fullPath, nil
+}
+
+// hrefFragment returns the "#fragment" suffix of an href, if any.
+func hrefFragment(href string) string {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		return href[i:]
+	}
+	return ""
+}
+
+// sanitizeID turns a file name into a valid XML id.
+func sanitizeID(s string) string {
+	var b strings.Builder
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9', c == '-', c == '.':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(c)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "item"
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package epub
+
+import (
+	"testing"
+)
+
+func openCoverTestEPUB(t *testing.T) *Reader {
+	t.Helper()
+	r, err := Open(writeTestEPUB(t, coverTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestAddResource(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	id, err := r.AddResource("Styles/extra.css", "text/css", []byte("p{}"), "")
+	if err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+	if id != "extra" {
+		t.Errorf("Unexpected id: %s", id)
+	}
+
+	r2 := saveAndReopen(t, r)
+	item := r2.findItem(id)
+	if item == nil || item.Href != "Styles/extra.css" || item.MediaType != "text/css" {
+		t.Fatalf("Manifest item not added correctly: %+v", item)
+	}
+	data, err := r2.readFile("OEBPS/Styles/extra.css")
+	if err != nil || string(data) != "p{}" {
+		t.Errorf("File content mismatch: %q, %v", data, err)
+	}
+}
+
+func TestAddResource_Rejects(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	for _, href := range []string{"", "/etc/passwd", "../../evil.txt", "../META-INF/x.xml", "Text/ch1.xhtml", "http://example.com/a.css"} {
+		if _, err := r.AddResource(href, "text/plain", nil, ""); err == nil {
+			t.Errorf("Expected error for href %q", href)
+		}
+	}
+}
+
+func TestAddResource_UniqueID(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	id, err := r.AddResource("Misc/ch1.txt", "text/plain", []byte("x"), "")
+	if err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+	if id != "ch1-1" {
+		t.Errorf("Expected id to avoid collision with ch1, got %s", id)
+	}
+}
+
+func TestReplaceResource(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	if err := r.ReplaceResource("ch1", []byte("<html/>")); err != nil {
+		t.Fatalf("ReplaceResource failed: %v", err)
+	}
+	if err := r.ReplaceResource("missing", nil); err == nil {
+		t.Error("Expected error for unknown id")
+	}
+
+	r2 := saveAndReopen(t, r)
+	data, _ := r2.readFile("OEBPS/Text/ch1.xhtml")
+	if string(data) != "<html/>" {
+		t.Errorf("Content not replaced: %q", data)
+	}
+}
+
+func TestRemoveResource(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	if err := r.RemoveResource("ch1"); err != nil {
+		t.Fatalf("RemoveResource failed: %v", err)
+	}
+
+	r2 := saveAndReopen(t, r)
+	if r2.findItem("ch1") != nil {
+		t.Error("Manifest item not removed")
+	}
+	if zipEntryNames(r2)["OEBPS/Text/ch1.xhtml"] {
+		t.Error("File not removed from archive")
+	}
+	for _, ref := range r2.Package.Spine.ItemRefs {
+		if ref.IDRef == "ch1" {
+			t.Error("Spine itemref not removed")
+		}
+	}
+	for _, ref := range r2.Package.Guide.References {
+		if ref.Href == "Text/ch1.xhtml" {
+			t.Error("Guide reference not removed")
+		}
+	}
+}
+
+func TestAddResource_EncodesHref(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	id, err := r.AddResource("Text/chapter%20one.xhtml", "application/xhtml+xml", []byte("<html/>"), "")
+	if err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+	if err := r.RenameResource("cover-page", "Text/cover%23front.xhtml"); err != nil {
+		t.Fatalf("RenameResource failed: %v", err)
+	}
+
+	r2 := saveAndReopen(t, r)
+	if item := r2.findItem(id); item == nil || item.Href != "Text/chapter%20one.xhtml" {
+		t.Errorf("Expected an encoded href, got %+v", item)
+	}
+	if data, err := r2.ReadItem("Text/chapter%20one.xhtml"); err != nil || string(data) != "<html/>" {
+		t.Errorf("Unexpected content %q: %v", data, err)
+	}
+	if !zipEntryNames(r2)["OEBPS/Text/chapter one.xhtml"] {
+		t.Error("Expected the file stored under its decoded name")
+	}
+	if item := r2.findItem("cover-page"); item == nil || item.Href != "Text/cover%23front.xhtml" {
+		t.Errorf("Expected # to be encoded, got %+v", item)
+	}
+	for _, ref := range r2.Package.Guide.References {
+		if ref.Type == "cover" && ref.Href != "Text/cover%23front.xhtml" {
+			t.Errorf("Guide reference not encoded: %s", ref.Href)
+		}
+	}
+}
+
+func TestRenameResource(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	if err := r.RenameResource("cover-page", "Pages/front.xhtml"); err != nil {
+		t.Fatalf("RenameResource failed: %v", err)
+	}
+	if err := r.RenameResource("ch1", "Images/cover.jpg"); err == nil {
+		t.Error("Expected error when renaming onto an existing file")
+	}
+
+	r2 := saveAndReopen(t, r)
+	item := r2.findItem("cover-page")
+	if item == nil || item.Href != "Pages/front.xhtml" {
+		t.Fatalf("Manifest href not updated: %+v", item)
+	}
+	names := zipEntryNames(r2)
+	if names["OEBPS/Text/cover.xhtml"] || !names["OEBPS/Pages/front.xhtml"] {
+		t.Error("File not moved in archive")
+	}
+	found := false
+	for _, ref := range r2.Package.Guide.References {
+		if ref.Type == "cover" {
+			found = ref.Href == "Pages/front.xhtml"
+		}
+	}
+	if !found {
+		t.Errorf("Guide reference not updated: %+v", r2.Package.Guide.References)
+	}
+}
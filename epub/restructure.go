@@ -0,0 +1,171 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Restructure layout (the folder convention used by Sigil and most EPUB tooling).
+const (
+	layoutRoot    = "OEBPS"
+	layoutOPFPath = "OEBPS/content.opf"
+)
+
+// layoutFolder returns the standard folder for a manifest media type.
+// An empty result means the file belongs directly under OEBPS.
+func layoutFolder(mediaType string) string {
+	mt := strings.ToLower(mediaType)
+	switch {
+	case mt == "application/xhtml+xml" || mt == "text/html":
+		return "Text"
+	case mt == "text/css":
+		return "Styles"
+	case mt == "application/x-dtbncx+xml":
+		return ""
+	case strings.HasPrefix(mt, "image/"):
+		return "Images"
+	case isFontMediaType(mt):
+		return "Fonts"
+	case strings.HasPrefix(mt, "audio/"):
+		return "Audio"
+	case strings.HasPrefix(mt, "video/"):
+		return "Video"
+	default:
+		return "Misc"
+	}
+}
+
+// Restructure normalizes the package layout: the OPF moves to OEBPS/content.opf
+// and resources move into OEBPS/Text, Styles, Images, Fonts, Audio, Video or Misc
+// by media type. All internal links are rewritten.
+// It returns the applied moves as old full path -> new full path.
+func (r *Reader) Restructure() (map[string]string, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]string)
+
+	// 1. Relocate the OPF
+	if r.OpfPath != layoutOPFPath {
+		oldOpf := r.OpfPath
+		if err := r.relocatePackage(layoutOPFPath); err != nil {
+			return nil, err
+		}
+		applied[oldOpf] = layoutOPFPath
+	}
+
+	// 2. Plan resource targets. Items already in place reserve their path first.
+	type plan struct {
+		id      string
+		current string
+		folder  string
+	}
+	var pending []plan
+	taken := make(map[string]bool)
+	for _, item := range pkg.Manifest.Items {
+		if isExternalRef(item.Href) {
+			continue
+		}
+		current := r.resolveHref(item.Href)
+		if !r.fileExists(current) {
+			continue
+		}
+		folder := path.Join(layoutRoot, layoutFolder(item.MediaType))
+		if path.Dir(current) == folder {
+			taken[current] = true
+			continue
+		}
+		pending = append(pending, plan{id: item.ID, current: current, folder: folder})
+	}
+
+	moving := make(map[string]bool)
+	for _, p := range pending {
+		moving[p.current] = true
+	}
+
+	renames := make(map[string]string)
+	opfDir := path.Dir(r.OpfPath)
+	for _, p := range pending {
+		name := path.Base(p.current)
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for i := 0; ; i++ {
+			candidate := path.Join(p.folder, name)
+			if i > 0 {
+				candidate = path.Join(p.folder, fmt.Sprintf("%s-%d%s", base, i, ext))
+			}
+			if taken[candidate] || candidate == r.OpfPath || (r.fileExists(candidate) && !moving[candidate]) {
+				continue
+			}
+			taken[candidate] = true
+			renames[p.id] = encodedHref(opfDir, candidate)
+			applied[p.current] = candidate
+			break
+		}
+	}
+
+	// 3. Move resources and rewrite references
+	if err := r.RenameResources(renames); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// relocatePackage moves the OPF to newPath, rebasing every manifest and guide
+// href and updating META-INF/container.xml.
+func (r *Reader) relocatePackage(newPath string) error {
+	oldPath := r.OpfPath
+	if r.fileExists(newPath) {
+		return fmt.Errorf("cannot move OPF: %s already exists", newPath)
+	}
+
+	// Update container.xml
+	const containerPath = "META-INF/container.xml"
+	doc, err := r.readXMLDocument(containerPath)
+	if err != nil {
+		return fmt.Errorf("failed to read container.xml: %w", err)
+	}
+	updated := false
+	for _, rf := range doc.FindElements("//rootfile") {
+		if rf.SelectAttrValue("full-path", "") == oldPath {
+			rf.CreateAttr("full-path", newPath)
+			updated = true
+		}
+	}
+	if !updated {
+		return fmt.Errorf("rootfile %s not found in container.xml", oldPath)
+	}
+	containerData, err := doc.WriteToBytes()
+	if err != nil {
+		return fmt.Errorf("failed to serialize container.xml: %w", err)
+	}
+
+	// Rebase hrefs from the old OPF directory to the new one
+	oldDir, newDir := path.Dir(oldPath), path.Dir(newPath)
+	rebase := func(href string) string {
+		if isExternalRef(href) {
+			return href
+		}
+		return encodedHref(newDir, resolveRelative(oldDir, href)) + hrefFragment(href)
+	}
+	for i := range r.Package.Manifest.Items {
+		r.Package.Manifest.Items[i].Href = rebase(r.Package.Manifest.Items[i].Href)
+	}
+	if r.Package.Guide != nil {
+		for i := range r.Package.Guide.References {
+			r.Package.Guide.References[i].Href = rebase(r.Package.Guide.References[i].Href)
+		}
+	}
+
+	r.setReplacement(containerPath, containerData)
+	r.removeFile(oldPath)
+	r.OpfPath = newPath
+	for i := range r.RootFiles {
+		if r.RootFiles[i].FullPath == oldPath {
+			r.RootFiles[i].FullPath = newPath
+		}
+	}
+	return nil
+}
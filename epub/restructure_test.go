@@ -0,0 +1,154 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+// restructureTestFiles returns a book whose OPF sits at the archive root.
+func restructureTestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", `<?xml version="1.0"?><container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Restructure Test</dc:title>
+    <dc:identifier id="uid">urn:uuid:1234</dc:identifier>
+  </metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine><itemref idref="ch1"/></spine>
+</package>`},
+		{"ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><head><link rel="stylesheet" href="style.css"/></head><body><p>One</p></body></html>`},
+		{"style.css", `p { margin: 0; }`},
+	}
+}
+
+func TestRestructure(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, restructureTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	moves, err := r.Restructure()
+	if err != nil {
+		t.Fatalf("Restructure failed: %v", err)
+	}
+	for from, to := range map[string]string{
+		"content.opf": "OEBPS/content.opf",
+		"ch1.xhtml":   "OEBPS/Text/ch1.xhtml",
+		"style.css":   "OEBPS/Styles/style.css",
+	} {
+		if moves[from] != to {
+			t.Errorf("Expected %s moved to %s, got %q", from, to, moves[from])
+		}
+	}
+
+	r2 := saveAndReopen(t, r)
+	if r2.OpfPath != "OEBPS/content.opf" {
+		t.Errorf("Expected OPF at OEBPS/content.opf, got %s", r2.OpfPath)
+	}
+	names := zipEntryNames(r2)
+	if names["content.opf"] || names["ch1.xhtml"] || names["style.css"] {
+		t.Errorf("Old entries left behind: %v", names)
+	}
+	data, _ := r2.readFile("OEBPS/Text/ch1.xhtml")
+	if !strings.Contains(string(data), `href="../Styles/style.css"`) {
+		t.Errorf("Stylesheet link not rewritten:\n%s", data)
+	}
+}
+
+func TestRestructure_OPFTargetExists(t *testing.T) {
+	files := append(restructureTestFiles(), testFile{"OEBPS/content.opf", "stray"})
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Restructure(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("Expected an error for the existing OEBPS/content.opf, got %v", err)
+	}
+	if r.OpfPath != "content.opf" {
+		t.Errorf("Expected the OPF to stay at content.opf, got %s", r.OpfPath)
+	}
+	if len(r.Replacements) != 0 || len(r.removed) != 0 {
+		t.Errorf("Expected no pending changes, got replacements %v, removed %v", r.Replacements, r.removed)
+	}
+	if href := r.Package.Manifest.Items[0].Href; href != "ch1.xhtml" {
+		t.Errorf("Expected hrefs left unchanged, got %s", href)
+	}
+}
+
+func TestRestructure_MultipleRootfiles(t *testing.T) {
+	path := writeTestEPUB(t, renditionTestFiles())
+	r, err := OpenRendition(path, 1)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Restructure(); err != nil {
+		t.Fatalf("Restructure failed: %v", err)
+	}
+	if got := r.RootFiles[1].FullPath; got != "OEBPS/content.opf" {
+		t.Errorf("Expected the opened rootfile updated, got %s", got)
+	}
+	if got := r.RootFiles[0].FullPath; got != "fixed/content.opf" {
+		t.Errorf("Expected the other rootfile unchanged, got %s", got)
+	}
+
+	r2 := saveAndReopen(t, r)
+	var paths []string
+	for _, rf := range r2.RootFiles {
+		paths = append(paths, rf.FullPath)
+	}
+	if want := "fixed/content.opf OEBPS/content.opf book.pdf"; strings.Join(paths, " ") != want {
+		t.Errorf("Expected rootfiles %s, got %v", want, paths)
+	}
+
+	for i, want := range []string{"Fixed", "Reflow"} {
+		ep, err := OpenRendition(r2.file.Name(), i)
+		if err != nil {
+			t.Fatalf("Open rendition %d failed: %v", i, err)
+		}
+		text, err := ep.ExtractText(TextOptions{})
+		ep.Close()
+		if err != nil {
+			t.Fatalf("ExtractText of rendition %d failed: %v", i, err)
+		}
+		if len(text) != 1 || !strings.Contains(text[0].Text, want) {
+			t.Errorf("Rendition %d: expected text %q, got %+v", i, want, text)
+		}
+	}
+}
+
+func TestRestructure_OPFInPlace(t *testing.T) {
+	files := coverTestFiles()
+	files[1].Content = strings.Replace(files[1].Content, `<item id="ch1"`,
+		`<item id="css" href="style.css" media-type="text/css"/>
+    <item id="ch1"`, 1)
+	files = append(files, testFile{"OEBPS/style.css", `p { margin: 0; }`})
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	moves, err := r.Restructure()
+	if err != nil {
+		t.Fatalf("Restructure failed: %v", err)
+	}
+	if len(moves) != 1 || moves["OEBPS/style.css"] != "OEBPS/Styles/style.css" {
+		t.Errorf("Expected only the stylesheet moved, got %v", moves)
+	}
+	if _, ok := r.Replacements["META-INF/container.xml"]; ok {
+		t.Error("container.xml rewritten although the OPF did not move")
+	}
+	if r.OpfPath != "OEBPS/content.opf" {
+		t.Errorf("Expected the OPF to stay in place, got %s", r.OpfPath)
+	}
+}
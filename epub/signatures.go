@@ -0,0 +1,236 @@
+package epub
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const signaturesPath = "META-INF/signatures.xml"
+
+// Digest algorithms supported by signature verification.
+const (
+	DigestSHA1   = "http://www.w3.org/2000/09/xmldsig#sha1"
+	DigestSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+)
+
+// SignatureStatus is the verification result of a signature reference.
+type SignatureStatus string
+
+const (
+	// SignatureValid: the digest matches the original and the current content.
+	SignatureValid SignatureStatus = "valid"
+	// SignatureInvalidated: the digest matches the original content, but
+	// pending edits change (or remove) the resource, so saving breaks it.
+	SignatureInvalidated SignatureStatus = "invalidated"
+	// SignatureMismatch: the digest does not match the original content.
+	SignatureMismatch SignatureStatus = "mismatch"
+	// SignatureMissing: the resource is not in the archive.
+	SignatureMissing SignatureStatus = "missing"
+	// SignatureUnsupported: the digest algorithm or transforms cannot be
+	// verified.
+	SignatureUnsupported SignatureStatus = "unsupported"
+)
+
+// SignatureReference is a resource referenced by a signature.
+type SignatureReference struct {
+	URI             string          `json:"uri"`
+	Path            string          `json:"path"` // full zip path
+	DigestAlgorithm string          `json:"digest_algorithm"`
+	Status          SignatureStatus `json:"status"`
+}
+
+// Signature is a ds:Signature in META-INF/signatures.xml.
+// Only the resource digests are verified; the signature value itself needs
+// the signer's key and is not checked.
+type Signature struct {
+	ID         string               `json:"id,omitempty"`
+	References []SignatureReference `json:"references"`
+}
+
+// Invalidated reports whether saving the pending edits breaks the signature.
+func (s *Signature) Invalidated() bool {
+	for _, ref := range s.References {
+		if ref.Status == SignatureInvalidated {
+			return true
+		}
+	}
+	return false
+}
+
+// Signatures parses META-INF/signatures.xml and verifies the digest of every
+// referenced resource against the original bytes in the archive and against
+// the content Save would write. Books without signatures return nil.
+//
+// Fonts whose obfuscation key changes with an edited identifier are checked
+// as Save re-obfuscates them; the Reader itself is not changed.
+func (r *Reader) Signatures() ([]Signature, error) {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return nil, err
+	}
+	rekeyed, err := r.pendingRekeys(pkg.currentObfuscationIDs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-obfuscate fonts: %w", err)
+	}
+	if !r.fileExists(signaturesPath) {
+		return nil, nil
+	}
+	doc, err := r.readXMLDocument(signaturesPath)
+	if err != nil {
+		return nil, fmt.Errorf("malformed signatures.xml: %w", err)
+	}
+
+	var sigs []Signature
+	for _, el := range doc.FindElements("//Signature") {
+		sig := Signature{ID: el.SelectAttrValue("Id", "")}
+		for _, ref := range el.FindElements(".//Reference") {
+			uri := ref.SelectAttrValue("URI", "")
+			// Same-document references (e.g. "#manifest") sign XML inside
+			// signatures.xml itself, not a resource
+			if uri == "" || strings.HasPrefix(uri, "#") {
+				continue
+			}
+			sr := SignatureReference{URI: uri, Path: uri}
+			if i := strings.Index(sr.Path, "#"); i >= 0 {
+				sr.Path = sr.Path[:i]
+			}
+			if unescaped, err := url.PathUnescape(sr.Path); err == nil {
+				sr.Path = unescaped
+			}
+			if method := ref.SelectElement("DigestMethod"); method != nil {
+				sr.DigestAlgorithm = method.SelectAttrValue("Algorithm", "")
+			}
+			digest := ""
+			if value := ref.SelectElement("DigestValue"); value != nil {
+				digest = strings.Join(strings.Fields(value.Text()), "")
+			}
+			// Transforms (e.g. canonicalization) change the digested bytes
+			transformed := ref.SelectElement("Transforms") != nil
+			sr.Status = r.verifyReference(sr.Path, sr.DigestAlgorithm, digest, transformed, rekeyed)
+			sig.References = append(sig.References, sr)
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// InvalidatedSignatures returns the signatures that saving the pending edits
+// would break.
+func (r *Reader) InvalidatedSignatures() ([]Signature, error) {
+	sigs, err := r.Signatures()
+	if err != nil {
+		return nil, err
+	}
+	var invalid []Signature
+	for _, sig := range sigs {
+		if sig.Invalidated() {
+			invalid = append(invalid, sig)
+		}
+	}
+	return invalid, nil
+}
+
+// StripInvalidSignatures removes the signatures that saving the pending edits
+// would break from signatures.xml (the file is removed when none remains).
+// It returns the number of signatures removed.
+func (r *Reader) StripInvalidSignatures() (int, error) {
+	sigs, err := r.Signatures()
+	if err != nil || sigs == nil {
+		return 0, err
+	}
+	doc, err := r.readXMLDocument(signaturesPath)
+	if err != nil {
+		return 0, fmt.Errorf("malformed signatures.xml: %w", err)
+	}
+
+	// Signatures() reports signatures in document order
+	removed := 0
+	for i, el := range doc.FindElements("//Signature") {
+		if i < len(sigs) && sigs[i].Invalidated() {
+			el.Parent().RemoveChild(el)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if len(doc.FindElements("//Signature")) == 0 {
+		r.removeFile(signaturesPath)
+		return removed, nil
+	}
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize signatures.xml: %w", err)
+	}
+	r.setReplacement(signaturesPath, data)
+	return removed, nil
+}
+
+// verifyReference checks a digest against the original and current content
+// of the resource at fullPath. rekeyed holds the re-keyed fonts Save writes
+// (see rekeyedFonts).
+func (r *Reader) verifyReference(fullPath, algorithm, digest string, transformed bool, rekeyed map[string][]byte) SignatureStatus {
+	rc, err := r.openFile(fullPath)
+	if err != nil {
+		return SignatureMissing
+	}
+	original, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return SignatureMissing
+	}
+	if transformed {
+		return SignatureUnsupported
+	}
+	sum, ok := digestBase64(algorithm, original)
+	if !ok {
+		return SignatureUnsupported
+	}
+	if sum != digest {
+		return SignatureMismatch
+	}
+
+	current, ok := r.savedContent(fullPath, rekeyed)
+	if !ok || !bytes.Equal(current, original) {
+		return SignatureInvalidated
+	}
+	return SignatureValid
+}
+
+// savedContent returns the content Save writes for fullPath, false when Save
+// drops the file. rekeyed overrides the pending content; a nil value drops
+// the file.
+func (r *Reader) savedContent(fullPath string, rekeyed map[string][]byte) ([]byte, bool) {
+	if data, ok := rekeyed[fullPath]; ok {
+		return data, data != nil
+	}
+	if fullPath == r.OpfPath {
+		data, err := r.opfContent()
+		return data, err == nil
+	}
+	if !r.fileExists(fullPath) {
+		return nil, false
+	}
+	data, err := r.readFile(fullPath)
+	return data, err == nil
+}
+
+// digestBase64 returns the base64 digest of data with algorithm.
+func digestBase64(algorithm string, data []byte) (string, bool) {
+	switch algorithm {
+	case DigestSHA1:
+		sum := sha1.Sum(data)
+		return base64.StdEncoding.EncodeToString(sum[:]), true
+	case DigestSHA256:
+		sum := sha256.Sum256(data)
+		return base64.StdEncoding.EncodeToString(sum[:]), true
+	}
+	return "", false
+}
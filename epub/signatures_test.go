@@ -0,0 +1,220 @@
+package epub
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func sha1Digest(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func sha256Digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func signatureReference(uri, algorithm, digest string) string {
+	return `<Reference URI="` + uri + `"><DigestMethod Algorithm="` + algorithm + `"/><DigestValue>` + digest + `</DigestValue></Reference>`
+}
+
+// signedTestFiles returns epub2TestFiles with a signature over the OPF and a
+// signature over the chapters (plus references that cannot be verified).
+func signedTestFiles() []testFile {
+	files := epub2TestFiles()
+	content := make(map[string]string)
+	for _, f := range files {
+		content[f.Name] = f.Content
+	}
+	signatures := `<?xml version="1.0" encoding="UTF-8"?>
+<signatures xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <Signature Id="sig-opf" xmlns="http://www.w3.org/2000/09/xmldsig#">
+    <SignedInfo>` +
+		signatureReference("OEBPS/content.opf", DigestSHA256, sha256Digest(content["OEBPS/content.opf"])) + `
+    </SignedInfo>
+    <SignatureValue>AAAA</SignatureValue>
+  </Signature>
+  <Signature Id="sig-text" xmlns="http://www.w3.org/2000/09/xmldsig#">
+    <SignedInfo>` +
+		signatureReference("#manifest", DigestSHA1, "AAAA") + `
+    </SignedInfo>
+    <SignatureValue>AAAA</SignatureValue>
+    <Object><Manifest Id="manifest">` +
+		signatureReference("OEBPS/Text/ch1.xhtml", DigestSHA1, sha1Digest(content["OEBPS/Text/ch1.xhtml"])) +
+		signatureReference("OEBPS/Text/ch2.xhtml", DigestSHA1, "d3JvbmcgZGlnZXN0") +
+		signatureReference("OEBPS/Text/missing.xhtml", DigestSHA256, "AAAA") +
+		signatureReference("OEBPS/Images/cover.jpg", "http://www.w3.org/2001/04/xmlenc#sha512", "AAAA") + `
+    </Manifest></Object>
+  </Signature>
+</signatures>`
+	return append(files, testFile{"META-INF/signatures.xml", signatures})
+}
+
+func signatureStatuses(t *testing.T, r *Reader) map[string]SignatureStatus {
+	t.Helper()
+	sigs, err := r.Signatures()
+	if err != nil {
+		t.Fatalf("Signatures failed: %v", err)
+	}
+	statuses := make(map[string]SignatureStatus)
+	for _, sig := range sigs {
+		for _, ref := range sig.References {
+			statuses[ref.Path] = ref.Status
+		}
+	}
+	return statuses
+}
+
+func TestSignatures(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, signedTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	sigs, err := r.Signatures()
+	if err != nil {
+		t.Fatalf("Signatures failed: %v", err)
+	}
+	if len(sigs) != 2 || sigs[0].ID != "sig-opf" || len(sigs[1].References) != 4 {
+		t.Fatalf("Unexpected signatures: %+v", sigs)
+	}
+
+	want := map[string]SignatureStatus{
+		"OEBPS/content.opf":        SignatureValid,
+		"OEBPS/Text/ch1.xhtml":     SignatureValid,
+		"OEBPS/Text/ch2.xhtml":     SignatureMismatch,
+		"OEBPS/Text/missing.xhtml": SignatureMissing,
+		"OEBPS/Images/cover.jpg":   SignatureUnsupported,
+	}
+	got := signatureStatuses(t, r)
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("%s: expected %s, got %s", path, status, got[path])
+		}
+	}
+	if invalid, err := r.InvalidatedSignatures(); err != nil || len(invalid) != 0 {
+		t.Errorf("Unedited book should not invalidate signatures: %+v (%v)", invalid, err)
+	}
+
+	// Saving without edits keeps the OPF bytes, so the signature stays valid
+	r2 := saveAndReopen(t, r)
+	if status := signatureStatuses(t, r2)["OEBPS/content.opf"]; status != SignatureValid {
+		t.Errorf("OPF signature broken by an unchanged save: %s", status)
+	}
+}
+
+func TestSignatures_Invalidated(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, signedTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	r.Package.SetTitle("Changed")
+	r.setReplacement("OEBPS/Text/ch1.xhtml", []byte(`<html xmlns="http://www.w3.org/1999/xhtml"><body/></html>`))
+
+	got := signatureStatuses(t, r)
+	if got["OEBPS/content.opf"] != SignatureInvalidated || got["OEBPS/Text/ch1.xhtml"] != SignatureInvalidated {
+		t.Errorf("Edits not reported: %+v", got)
+	}
+	invalid, err := r.InvalidatedSignatures()
+	if err != nil || len(invalid) != 2 {
+		t.Fatalf("Expected 2 invalidated signatures, got %+v (%v)", invalid, err)
+	}
+
+	n, err := r.StripInvalidSignatures()
+	if err != nil || n != 2 {
+		t.Fatalf("StripInvalidSignatures = %d, %v", n, err)
+	}
+	r2 := saveAndReopen(t, r)
+	if r2.fileExists(signaturesPath) {
+		t.Error("signatures.xml should be removed when no signature remains")
+	}
+}
+
+func TestStripInvalidSignatures_KeepsValid(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, signedTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	// Only the OPF signature is broken
+	r.Package.SetTitle("Changed")
+	n, err := r.StripInvalidSignatures()
+	if err != nil || n != 1 {
+		t.Fatalf("StripInvalidSignatures = %d, %v", n, err)
+	}
+	r2 := saveAndReopen(t, r)
+	sigs, err := r2.Signatures()
+	if err != nil || len(sigs) != 1 || sigs[0].ID != "sig-text" {
+		t.Fatalf("Expected only sig-text to remain, got %+v (%v)", sigs, err)
+	}
+	data, _ := r2.readFile(signaturesPath)
+	if strings.Contains(string(data), "sig-opf") {
+		t.Error("Invalid signature still in signatures.xml")
+	}
+	if status := signatureStatuses(t, r2)["OEBPS/Text/ch1.xhtml"]; status != SignatureValid {
+		t.Errorf("Remaining signature no longer valid: %s", status)
+	}
+}
+
+func TestSignatures_None(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if sigs, err := r.Signatures(); err != nil || sigs != nil {
+		t.Errorf("Expected no signatures, got %+v (%v)", sigs, err)
+	}
+	if n, err := r.StripInvalidSignatures(); err != nil || n != 0 {
+		t.Errorf("StripInvalidSignatures = %d, %v", n, err)
+	}
+}
+
+func TestSignatures_RekeyedFonts(t *testing.T) {
+	files := fontTestFiles(t)
+	content := make(map[string]string)
+	for _, f := range files {
+		content[f.Name] = f.Content
+	}
+	files = append(files, testFile{"META-INF/signatures.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<signatures xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <Signature Id="sig-font" xmlns="http://www.w3.org/2000/09/xmldsig#">
+    <SignedInfo>` +
+		signatureReference("OEBPS/Fonts/a.otf", DigestSHA256, sha256Digest(content["OEBPS/Fonts/a.otf"])) + `
+    </SignedInfo>
+    <SignatureValue>AAAA</SignatureValue>
+  </Signature>
+</signatures>`})
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	// The font key derives from the unique identifier, so only Save's
+	// re-obfuscation touches the font
+	r.Package.SetISBN("9787111111111")
+	invalid, err := r.InvalidatedSignatures()
+	if err != nil {
+		t.Fatalf("InvalidatedSignatures failed: %v", err)
+	}
+	if len(invalid) != 1 || invalid[0].ID != "sig-font" {
+		t.Fatalf("Expected the font signature to be invalidated, got %+v", invalid)
+	}
+	if len(r.Replacements) != 0 {
+		t.Errorf("Checking signatures changed the pending content: %v", r.Replacements)
+	}
+	if n, err := r.StripInvalidSignatures(); err != nil || n != 1 {
+		t.Errorf("Expected 1 stripped signature, got %d: %v", n, err)
+	}
+	checkFonts(t, r)
+}
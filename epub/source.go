@@ -0,0 +1,188 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Source provides the content of a replaced or added file.
+// Open may be called more than once: Save streams the content into the
+// archive, and methods such as Signatures or RenameResources read it before.
+// Each call must return the full content from the start.
+type Source interface {
+	Open() (io.ReadCloser, error)
+}
+
+// SourceFunc adapts a generator function to a Source.
+type SourceFunc func() (io.ReadCloser, error)
+
+// Open calls f.
+func (f SourceFunc) Open() (io.ReadCloser, error) {
+	return f()
+}
+
+// BytesSource returns a Source backed by an in-memory buffer.
+func BytesSource(data []byte) Source {
+	return SourceFunc(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// FileSource returns a Source that streams a file from disk.
+func FileSource(name string) Source {
+	return SourceFunc(func() (io.ReadCloser, error) {
+		return os.Open(name)
+	})
+}
+
+// FSSource returns a Source that streams a file from an fs.FS.
+func FSSource(fsys fs.FS, name string) Source {
+	return SourceFunc(func() (io.ReadCloser, error) {
+		return fsys.Open(name)
+	})
+}
+
+// ReaderSource returns a Source backed by rd.
+// rd is read only once: the content is spilled to a temporary file as it is
+// read, so that the file can be opened again (for example by Signatures or
+// RenameResources before Save) without holding it in memory. If rd
+// implements io.Closer it is closed once fully read. The temporary file is
+// removed when the Reader the source was set on is closed.
+func ReaderSource(rd io.Reader) Source {
+	return &readerSource{rd: rd}
+}
+
+// readerSource replays the content read so far from rd out of a temporary
+// file and reads on from rd when a reader gets past it.
+type readerSource struct {
+	rd   io.Reader
+	file *os.File // holds the content read so far
+	size int64
+	err  error // the error that ended rd, io.EOF when fully read
+}
+
+func (s *readerSource) Open() (io.ReadCloser, error) {
+	if s.file == nil {
+		f, err := os.CreateTemp("", "golibri-source-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		s.file = f
+	}
+	return &replayReader{s: s}, nil
+}
+
+// remove deletes the temporary file.
+func (s *readerSource) remove() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
+
+type replayReader struct {
+	s   *readerSource
+	pos int64
+}
+
+func (r *replayReader) Read(p []byte) (int, error) {
+	s := r.s
+	if s.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.pos == s.size {
+		if s.err != nil {
+			return 0, s.err
+		}
+		n, err := s.rd.Read(p)
+		if n > 0 {
+			if _, werr := s.file.WriteAt(p[:n], s.size); werr != nil {
+				err = fmt.Errorf("failed to write temp file: %w", werr)
+				n = 0
+			}
+			s.size += int64(n)
+		}
+		if err != nil {
+			s.err = err
+			if c, ok := s.rd.(io.Closer); ok {
+				c.Close()
+			}
+		}
+		r.pos += int64(n)
+		return n, err
+	}
+	if rest := s.size - r.pos; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	n, err := s.file.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *replayReader) Close() error {
+	return nil
+}
+
+// zipEntrySource streams an entry of the original archive.
+// Save copies it raw (without recompression) when it is written under a new name.
+type zipEntrySource struct {
+	f *zip.File
+}
+
+func (s zipEntrySource) Open() (io.ReadCloser, error) {
+	return s.f.Open()
+}
+
+// SetReplacementSource replaces or adds the file at fullPath with streamed content.
+// It supersedes any []byte entry in Replacements for the same path.
+func (r *Reader) SetReplacementSource(fullPath string, src Source) {
+	if r.sources == nil {
+		r.sources = make(map[string]Source)
+	}
+	r.sources[fullPath] = src
+	delete(r.Replacements, fullPath)
+	if rs, ok := src.(*readerSource); ok {
+		r.spills = append(r.spills, rs)
+	}
+}
+
+// setReplacement stores in-memory content for fullPath, superseding any Source.
+func (r *Reader) setReplacement(fullPath string, data []byte) {
+	if r.Replacements == nil {
+		r.Replacements = make(map[string][]byte)
+	}
+	r.Replacements[fullPath] = data
+	delete(r.sources, fullPath)
+}
+
+// replacementSource returns the pending content for fullPath, if any.
+// []byte Replacements take precedence over streamed sources.
+func (r *Reader) replacementSource(fullPath string) (Source, bool) {
+	if content, ok := r.Replacements[fullPath]; ok {
+		return BytesSource(content), true
+	}
+	if src, ok := r.sources[fullPath]; ok {
+		return src, true
+	}
+	return nil, false
+}
+
+// currentSource returns a Source for the current content of fullPath:
+// a pending replacement, or the original archive entry.
+func (r *Reader) currentSource(fullPath string) (Source, bool) {
+	if src, ok := r.replacementSource(fullPath); ok {
+		return src, true
+	}
+	if r.removed[fullPath] {
+		return nil, false
+	}
+	if f, ok := r.zipEntry(fullPath); ok {
+		return zipEntrySource{f: f}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,195 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetReplacementSource_File(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	f, err := os.CreateTemp("", "source-*.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("<html>from disk</html>")
+	f.Close()
+
+	r.SetReplacementSource("OEBPS/Text/ch1.xhtml", FileSource(f.Name()))
+	r2 := saveAndReopen(t, r)
+
+	data, _ := r2.readFile("OEBPS/Text/ch1.xhtml")
+	if string(data) != "<html>from disk</html>" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+}
+
+func TestSetReplacementSource_Generator(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	const size = 8 << 20
+	gen := SourceFunc(func() (io.ReadCloser, error) {
+		return io.NopCloser(io.LimitReader(repeatReader('a'), size)), nil
+	})
+	id, err := r.AddResourceFrom("Audio/track.mp3", "audio/mpeg", gen, "")
+	if err != nil {
+		t.Fatalf("AddResourceFrom failed: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out.epub")
+	if err := r.Save(outPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// A run of identical bytes compresses like a zip bomb
+	if _, err := OpenWithOptions(outPath, OpenOptions{}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected compression ratio limit, got %v", err)
+	}
+	r2, err := Open(outPath)
+	if err != nil {
+		t.Fatalf("Re-open failed: %v", err)
+	}
+	defer r2.Close()
+
+	item := r2.findItem(id)
+	if item == nil {
+		t.Fatal("Manifest item missing")
+	}
+	for _, f := range r2.zipReader.File {
+		if f.Name == "OEBPS/Audio/track.mp3" && f.UncompressedSize64 != size {
+			t.Errorf("Unexpected size: %d", f.UncompressedSize64)
+		}
+	}
+}
+
+func TestReplacementPrecedence(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	r.SetReplacementSource("OEBPS/Text/ch1.xhtml", BytesSource([]byte("stream")))
+	r.Replacements = map[string][]byte{"OEBPS/Text/ch1.xhtml": []byte("bytes")}
+	r2 := saveAndReopen(t, r)
+
+	data, _ := r2.readFile("OEBPS/Text/ch1.xhtml")
+	if string(data) != "bytes" {
+		t.Errorf("Expected []byte replacement to win, got %q", data)
+	}
+}
+
+func TestRenameResource_RawCopiesUnchangedEntry(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	var orig *zip.File
+	for _, f := range r.zipReader.File {
+		if f.Name == "OEBPS/Images/cover.jpg" {
+			orig = f
+		}
+	}
+	if err := r.RenameResource("cover-img", "Art/cover.jpg"); err != nil {
+		t.Fatalf("RenameResource failed: %v", err)
+	}
+	if _, buffered := r.Replacements["OEBPS/Art/cover.jpg"]; buffered {
+		t.Error("Moved binary resource should not be buffered in memory")
+	}
+	r2 := saveAndReopen(t, r)
+
+	for _, f := range r2.zipReader.File {
+		if f.Name == "OEBPS/Art/cover.jpg" {
+			if f.CRC32 != orig.CRC32 || f.CompressedSize64 != orig.CompressedSize64 || f.Method != orig.Method {
+				t.Error("Moved entry was not copied raw")
+			}
+			return
+		}
+	}
+	t.Error("Moved entry missing")
+}
+
+func TestReaderSource(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	if err := r.ReplaceResourceFrom("ch1", ReaderSource(strings.NewReader("once"))); err != nil {
+		t.Fatal(err)
+	}
+	r2 := saveAndReopen(t, r)
+	data, _ := r2.readFile("OEBPS/Text/ch1.xhtml")
+	if !bytes.Equal(data, []byte("once")) {
+		t.Errorf("Unexpected content: %q", data)
+	}
+}
+
+// closeCounter counts the Close calls on a reader.
+type closeCounter struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestReaderSource_ReadBeforeSave(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	content := `<html xmlns="http://www.w3.org/1999/xhtml"><body><a href="cover.xhtml">cover</a></body></html>`
+	rd := &closeCounter{Reader: strings.NewReader(content)}
+	if err := r.ReplaceResourceFrom("ch1", ReaderSource(rd)); err != nil {
+		t.Fatal(err)
+	}
+	// RenameResources reads every content document, ch1 included
+	if err := r.RenameResource("nav", "Text/toc.xhtml"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := r.readFile("OEBPS/Text/ch1.xhtml"); err != nil || string(data) != content {
+		t.Fatalf("Unexpected content on second read %q: %v", data, err)
+	}
+
+	r2 := saveAndReopen(t, r)
+	data, _ := r2.readFile("OEBPS/Text/ch1.xhtml")
+	if string(data) != content {
+		t.Errorf("Save wrote %q, want the full content", data)
+	}
+	if rd.closed != 1 {
+		t.Errorf("Expected the reader closed once, got %d", rd.closed)
+	}
+}
+
+func TestReaderSource_SpillsToTempFile(t *testing.T) {
+	r := openCoverTestEPUB(t)
+
+	content := strings.Repeat("spilled ", 1<<14)
+	src := ReaderSource(strings.NewReader(content))
+	if err := r.ReplaceResourceFrom("ch1", src); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := r.readFile("OEBPS/Text/ch1.xhtml"); err != nil || string(data) != content {
+		t.Fatalf("Unexpected content (%d bytes): %v", len(data), err)
+	}
+	spill := src.(*readerSource).file.Name()
+	if data, err := os.ReadFile(spill); err != nil || string(data) != content {
+		t.Fatalf("Expected the content in %s (%d bytes): %v", spill, len(data), err)
+	}
+
+	r2 := saveAndReopen(t, r)
+	if data, _ := r2.readFile("OEBPS/Text/ch1.xhtml"); string(data) != content {
+		t.Errorf("Save wrote %d bytes, want %d", len(data), len(content))
+	}
+	r.Close()
+	if _, err := os.Stat(spill); !os.IsNotExist(err) {
+		t.Errorf("Expected %s removed on Close, got %v", spill, err)
+	}
+}
+
+// repeatReader yields an endless stream of a single byte.
+type repeatReader byte
+
+func (b repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,113 @@
+package epub
+
+import (
+	"math"
+	"unicode"
+)
+
+// Reading-speed assumptions used for page and time estimates.
+// CJK text is measured in characters, other scripts in words.
+const (
+	WordsPerPage      = 250
+	CJKCharsPerPage   = 500
+	WordsPerMinute    = 230
+	CJKCharsPerMinute = 400
+)
+
+// ItemStats holds counts for one spine item.
+type ItemStats struct {
+	Index int    `json:"index"`
+	ID    string `json:"id"`
+	Path  string `json:"path"`
+	TextCounts
+}
+
+// TextCounts holds word and character counts.
+type TextCounts struct {
+	// Words counts whitespace-separated words plus one per CJK character.
+	Words int `json:"words"`
+
+	// Characters counts non-whitespace characters.
+	Characters int `json:"characters"`
+
+	// CJKCharacters counts Han, Kana and Hangul characters.
+	CJKCharacters int `json:"cjk_characters"`
+}
+
+// BookStats holds totals for the book.
+type BookStats struct {
+	TextCounts
+	Pages          int         `json:"pages"`
+	ReadingMinutes int         `json:"reading_minutes"`
+	Items          []ItemStats `json:"items,omitempty"`
+}
+
+// Stats counts words and characters of the linear spine content and
+// estimates pages and reading time. Labels such as noteref "[1]" or list
+// numbers are not counted; the footnotes themselves are.
+func (r *Reader) Stats() (*BookStats, error) {
+	chapters, err := r.ExtractText(TextOptions{bodyOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &BookStats{Items: []ItemStats{}}
+	for _, ch := range chapters {
+		counts := CountText(ch.Text)
+		stats.Items = append(stats.Items, ItemStats{
+			Index:      ch.Index,
+			ID:         ch.ID,
+			Path:       ch.Path,
+			TextCounts: counts,
+		})
+		stats.Words += counts.Words
+		stats.Characters += counts.Characters
+		stats.CJKCharacters += counts.CJKCharacters
+	}
+
+	latinWords := float64(stats.Words - stats.CJKCharacters)
+	cjk := float64(stats.CJKCharacters)
+	stats.Pages = int(math.Ceil(latinWords/WordsPerPage + cjk/CJKCharsPerPage))
+	stats.ReadingMinutes = int(math.Ceil(latinWords/WordsPerMinute + cjk/CJKCharsPerMinute))
+	return stats, nil
+}
+
+// CountText counts words and characters in text.
+// Each CJK character counts as one word; other words are runs of
+// non-space characters containing at least one letter or digit.
+func CountText(text string) TextCounts {
+	var c TextCounts
+	inWord, wordHasContent := false, false
+	endWord := func() {
+		if inWord && wordHasContent {
+			c.Words++
+		}
+		inWord, wordHasContent = false, false
+	}
+
+	for _, ch := range text {
+		switch {
+		case unicode.IsSpace(ch):
+			endWord()
+		case isCJK(ch):
+			endWord()
+			c.Words++
+			c.CJKCharacters++
+			c.Characters++
+		default:
+			c.Characters++
+			inWord = true
+			if unicode.IsLetter(ch) || unicode.IsDigit(ch) {
+				wordHasContent = true
+			}
+		}
+	}
+	endWord()
+	return c
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character.
+func isCJK(r rune) bool {
+	// U+30FC (prolonged sound mark) belongs to the Common script but is part of Katakana words
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == '\u30FC'
+}
@@ -0,0 +1,133 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountText(t *testing.T) {
+	cases := []struct {
+		text  string
+		words int
+		chars int
+		cjk   int
+	}{
+		{"Hello, world!", 2, 12, 0},
+		{"  it's  a  test — ok ", 4, 12, 0},
+		{"第二章的内容。", 6, 7, 6},
+		{"コーヒーを飲む", 7, 7, 7},
+		{"Go语言 1.24 版本", 6, 10, 4},
+		{"", 0, 0, 0},
+	}
+	for _, tc := range cases {
+		got := CountText(tc.text)
+		if got.Words != tc.words || got.Characters != tc.chars || got.CJKCharacters != tc.cjk {
+			t.Errorf("CountText(%q) = %+v, want words=%d chars=%d cjk=%d", tc.text, got, tc.words, tc.chars, tc.cjk)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, textTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	stats, err := r.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if len(stats.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(stats.Items))
+	}
+
+	sum := 0
+	for _, item := range stats.Items {
+		sum += item.Words
+	}
+	if sum != stats.Words || stats.Words == 0 {
+		t.Errorf("Total words %d does not match items %d", stats.Words, sum)
+	}
+	if stats.CJKCharacters != 2 {
+		t.Errorf("Expected 2 CJK characters, got %d", stats.CJKCharacters)
+	}
+	if stats.Pages != 1 || stats.ReadingMinutes != 1 {
+		t.Errorf("Unexpected estimates: pages=%d minutes=%d", stats.Pages, stats.ReadingMinutes)
+	}
+}
+
+func TestStats_SkipsLabels(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, textTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	stats, err := r.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	// Chapter One / Some emphasized and bold text / First Second Nested /
+	// Line break 漢字 / The note: the noteref "[1]", the footnote marker and
+	// the list number "1." are not words
+	if got := stats.Items[0].Words; got != 16 {
+		t.Errorf("Expected 16 words in the first chapter, got %d", got)
+	}
+
+	// The text itself keeps the labels
+	chapters, err := r.ExtractText(TextOptions{})
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	if got := CountText(chapters[0].Text).Words; got != 18 {
+		t.Errorf("Expected 18 words with labels, got %d", got)
+	}
+}
+
+func TestSetCalibreColumn(t *testing.T) {
+	for _, version := range []string{"2.0", "3.0"} {
+		t.Run(version, func(t *testing.T) {
+			files := textTestFiles()
+			files[1].Content = strings.Replace(files[1].Content, `version="3.0"`, `version="`+version+`"`, 1)
+			r, err := Open(writeTestEPUB(t, files))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			if err := r.Package.SetCalibreColumn("#words", "Words", "int", 1200); err != nil {
+				t.Fatalf("SetCalibreColumn failed: %v", err)
+			}
+			if err := r.Package.SetCalibreColumn("pages", "Pages", "int", 5); err != nil {
+				t.Fatalf("SetCalibreColumn failed: %v", err)
+			}
+			// Updating keeps a single definition
+			if err := r.Package.SetCalibreColumn("pages", "Pages", "int", 6); err != nil {
+				t.Fatalf("SetCalibreColumn failed: %v", err)
+			}
+
+			r2 := saveAndReopen(t, r)
+			for label, want := range map[string]float64{"words": 1200, "#pages": 6} {
+				v, ok := r2.Package.GetCalibreColumn(label)
+				if !ok || v != want {
+					t.Errorf("Column %s = %v (%v), want %v", label, v, ok, want)
+				}
+			}
+
+			count := 0
+			for _, m := range r2.Package.Metadata.Meta {
+				if strings.HasPrefix(m.Name, calibreUserMetadata) || m.Property == calibreUserMetadata {
+					count++
+				}
+			}
+			want := 2
+			if version == "3.0" {
+				want = 1
+			}
+			if count != want {
+				t.Errorf("Expected %d user metadata entries, got %d", want, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,599 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// TextFormat selects the output format of ExtractText.
+type TextFormat int
+
+const (
+	// FormatText produces plain text.
+	FormatText TextFormat = iota
+	// FormatMarkdown produces Markdown (headings, lists, emphasis, footnotes).
+	FormatMarkdown
+)
+
+// TextOptions controls text extraction.
+type TextOptions struct {
+	Format TextFormat
+
+	// IncludeNonLinear includes spine items marked linear="no".
+	IncludeNonLinear bool
+
+	// IncludeHidden includes elements with the hidden attribute or display:none.
+	IncludeHidden bool
+
+	// bodyOnly drops the labels the converter adds (noteref labels, footnote
+	// markers, list numbers), so that Stats counts only the words of the book.
+	bodyOnly bool
+}
+
+// ChapterText is the extracted text of one spine item.
+type ChapterText struct {
+	// Index is the position in the spine (0-based).
+	Index int
+
+	// ID is the manifest item id.
+	ID string
+
+	// Path is the full zip path of the content document.
+	Path string
+
+	// Title is the first heading of the chapter, if any.
+	Title string
+
+	Text string
+}
+
+// ExtractText converts the spine content documents to text in reading order.
+func (r *Reader) ExtractText(opts TextOptions) ([]ChapterText, error) {
+	spine, err := r.Spine()
+	if err != nil {
+		return nil, err
+	}
+	var chapters []ChapterText
+	for _, entry := range spine {
+		if !entry.Linear && !opts.IncludeNonLinear {
+			continue
+		}
+		mt := strings.ToLower(entry.Item.MediaType)
+		if mt != "application/xhtml+xml" && mt != "text/html" {
+			continue
+		}
+
+		rc, err := r.openPath(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Path, err)
+		}
+
+		text, title, err := convertXHTML(data, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", entry.Path, err)
+		}
+		chapters = append(chapters, ChapterText{
+			Index: entry.Index,
+			ID:    entry.Item.ID,
+			Path:  entry.Path,
+			Title: title,
+			Text:  text,
+		})
+	}
+	return chapters, nil
+}
+
+// JoinChapters concatenates chapter texts, placing separator (if any) on its
+// own line between chapters. Empty chapters are skipped.
+func JoinChapters(chapters []ChapterText, separator string) string {
+	var parts []string
+	for _, ch := range chapters {
+		if ch.Text != "" {
+			parts = append(parts, ch.Text)
+		}
+	}
+	glue := "\n\n"
+	if separator != "" {
+		glue = "\n\n" + separator + "\n\n"
+	}
+	return strings.Join(parts, glue)
+}
+
+// convertXHTML renders an XHTML document to text and returns its first heading.
+func convertXHTML(data []byte, opts TextOptions) (string, string, error) {
+	doc, err := parseHTMLTree(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	root := doc.FindElement("//body")
+	if root == nil {
+		root = doc
+	}
+
+	c := &textConverter{
+		markdown:      opts.Format == FormatMarkdown,
+		includeHidden: opts.IncludeHidden,
+		bodyOnly:      opts.bodyOnly,
+		labels:        make(map[string]string),
+	}
+	blocks := c.blocks(root)
+
+	// Footnotes go after the chapter body
+	for _, n := range c.notes {
+		if c.bodyOnly {
+			blocks = append(blocks, n.text)
+		} else if c.markdown {
+			blocks = append(blocks, fmt.Sprintf("[^%s]: %s", n.id, n.text))
+		} else {
+			label := c.labels[n.id]
+			if label == "" {
+				label = n.id
+			}
+			blocks = append(blocks, fmt.Sprintf("[%s] %s", label, n.text))
+		}
+	}
+	return strings.Join(blocks, "\n\n"), c.title, nil
+}
+
+// parseHTMLTree builds an element tree from XHTML, tolerating tag soup found in
+// real books (unclosed <p>, uppercase tags, HTML entities, bare ampersands).
+// etree rejects mismatched end tags even in permissive mode, so the tree is
+// assembled from raw tokens: an end tag closes the nearest open element with the
+// same name and is ignored if there is none.
+func parseHTMLTree(data []byte) (*etree.Element, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = charsetReader
+
+	voids := make(map[string]bool)
+	for _, tag := range xml.HTMLAutoClose {
+		voids[tag] = true
+	}
+
+	root := etree.NewElement("")
+	stack := []*etree.Element{root}
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Keep what was parsed so far; truncated documents still have text.
+			if len(root.Child) == 0 {
+				return nil, err
+			}
+			break
+		}
+
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := etree.NewElement(strings.ToLower(t.Name.Local))
+			for _, a := range t.Attr {
+				key := a.Name.Local
+				if a.Name.Space != "" {
+					key = a.Name.Space + ":" + key
+				}
+				el.CreateAttr(key, a.Value)
+			}
+			top.AddChild(el)
+			if !voids[el.Tag] {
+				stack = append(stack, el)
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].Tag == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			top.AddChild(etree.NewText(string(t)))
+		}
+	}
+	return root, nil
+}
+
+type footnote struct {
+	id   string
+	text string
+}
+
+// textConverter walks an XHTML tree and renders it as blocks of text.
+type textConverter struct {
+	markdown      bool
+	includeHidden bool
+	title         string
+	notes         []footnote
+	labels        map[string]string // footnote id -> noteref label
+	bodyOnly      bool
+	verbatim      bool // inside a Markdown code span
+}
+
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hr": true, "li": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+var skippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "rt": true, "rp": true,
+}
+
+func tagName(el *etree.Element) string {
+	return strings.ToLower(el.Tag)
+}
+
+// skip reports whether an element produces no text.
+func (c *textConverter) skip(el *etree.Element) bool {
+	if skippedTags[tagName(el)] {
+		return true
+	}
+	if c.includeHidden {
+		return false
+	}
+	if el.SelectAttr("hidden") != nil {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(el.SelectAttrValue("style", "")), " ", "")
+	return strings.Contains(style, "display:none")
+}
+
+// isFootnote reports whether el is an EPUB 3 footnote/endnote container.
+func isFootnote(el *etree.Element) bool {
+	t := el.SelectAttrValue("epub:type", "")
+	return hasProperty(t, "footnote") || hasProperty(t, "endnote") || hasProperty(t, "rearnote")
+}
+
+// blocks renders the children of el as a list of text blocks.
+func (c *textConverter) blocks(el *etree.Element) []string {
+	var out []string
+	var line strings.Builder
+	flush := func() {
+		if s := cleanInline(line.String()); s != "" {
+			if c.markdown {
+				s = escapeLineStarts(s)
+			}
+			out = append(out, s)
+		}
+		line.Reset()
+	}
+
+	for _, tok := range el.Child {
+		switch t := tok.(type) {
+		case *etree.CharData:
+			line.WriteString(c.text(t.Data))
+		case *etree.Element:
+			if c.skip(t) {
+				continue
+			}
+			if blockTags[tagName(t)] || isFootnote(t) {
+				flush()
+				out = append(out, c.block(t)...)
+			} else {
+				line.WriteString(c.inline(t))
+			}
+		}
+	}
+	flush()
+	return out
+}
+
+// block renders a block-level element.
+func (c *textConverter) block(el *etree.Element) []string {
+	if isFootnote(el) {
+		if id := el.SelectAttrValue("id", ""); id != "" {
+			c.notes = append(c.notes, footnote{id: id, text: strings.Join(c.blocks(el), " ")})
+			return nil
+		}
+	}
+
+	tag := tagName(el)
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := c.inlineText(el)
+		if text == "" {
+			return nil
+		}
+		if c.title == "" {
+			c.title = strings.ReplaceAll(text, "\n", " ")
+		}
+		if c.markdown {
+			level, _ := strconv.Atoi(tag[1:])
+			return []string{strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\n", " ")}
+		}
+		return []string{text}
+
+	case "ul", "ol":
+		return c.list(el, tag == "ol")
+
+	case "blockquote":
+		inner := c.blocks(el)
+		if !c.markdown || len(inner) == 0 {
+			return inner
+		}
+		lines := strings.Split(strings.Join(inner, "\n\n"), "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight("> "+l, " ")
+		}
+		return []string{strings.Join(lines, "\n")}
+
+	case "pre":
+		text := strings.Trim(rawText(el), "\n")
+		if c.markdown {
+			return []string{"```\n" + text + "\n```"}
+		}
+		return []string{text}
+
+	case "hr":
+		if c.markdown {
+			return []string{"---"}
+		}
+		return []string{"* * *"}
+
+	case "table":
+		return c.table(el)
+
+	default:
+		return c.blocks(el)
+	}
+}
+
+// list renders ul/ol items; nested content is indented under the marker.
+func (c *textConverter) list(el *etree.Element, ordered bool) []string {
+	var items []string
+	n := 0
+	if start, err := strconv.Atoi(el.SelectAttrValue("start", "")); err == nil {
+		n = start - 1
+	}
+	for _, li := range el.ChildElements() {
+		if c.skip(li) {
+			continue
+		}
+		var inner []string
+		if tagName(li) == "li" {
+			inner = c.blocks(li)
+		} else {
+			inner = c.block(li)
+		}
+		if len(inner) == 0 {
+			continue
+		}
+		n++
+		marker := "- "
+		if ordered && !c.bodyOnly {
+			marker = strconv.Itoa(n) + ". "
+		}
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(strings.Join(inner, "\n"), "\n")
+		for i := range lines {
+			if i == 0 {
+				lines[i] = marker + lines[i]
+			} else if lines[i] != "" {
+				lines[i] = indent + lines[i]
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return []string{strings.Join(items, "\n")}
+}
+
+// table renders rows one per line: tab-separated cells, or a Markdown table.
+func (c *textConverter) table(el *etree.Element) []string {
+	var rows []string
+	for _, tr := range el.FindElements(".//tr") {
+		var cells []string
+		for _, cell := range tr.ChildElements() {
+			if t := tagName(cell); (t == "td" || t == "th") && !c.skip(cell) {
+				cells = append(cells, strings.ReplaceAll(c.inlineText(cell), "\n", " "))
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		if !c.markdown {
+			rows = append(rows, strings.Join(cells, "\t"))
+			continue
+		}
+		for i := range cells {
+			cells[i] = strings.ReplaceAll(cells[i], "|", `\|`)
+		}
+		header := len(rows) == 0
+		rows = append(rows, "| "+strings.Join(cells, " | ")+" |")
+		if header {
+			rows = append(rows, "|"+strings.Repeat(" --- |", len(cells)))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return []string{strings.Join(rows, "\n")}
+}
+
+// inline renders an inline element (emphasis, links, line breaks...).
+func (c *textConverter) inline(el *etree.Element) string {
+	switch tagName(el) {
+	case "br":
+		return lineBreak
+	case "img", "svg", "math", "audio", "video", "object":
+		return ""
+	}
+
+	// Code spans are written as is
+	if c.markdown && codeTags[tagName(el)] && !c.verbatim {
+		c.verbatim = true
+		defer func() { c.verbatim = false }()
+	}
+
+	var sb strings.Builder
+	for _, tok := range el.Child {
+		switch t := tok.(type) {
+		case *etree.CharData:
+			sb.WriteString(c.text(t.Data))
+		case *etree.Element:
+			if !c.skip(t) {
+				sb.WriteString(c.inline(t))
+			}
+		}
+	}
+	text := sb.String()
+
+	if tagName(el) == "a" && hasProperty(el.SelectAttrValue("epub:type", ""), "noteref") {
+		if c.bodyOnly {
+			return ""
+		}
+		_, frag := splitRef(el.SelectAttrValue("href", ""))
+		id := strings.TrimPrefix(frag, "#")
+		label := strings.Trim(strings.TrimSpace(text), "[]")
+		if c.markdown {
+			label = strings.TrimSuffix(strings.TrimPrefix(label, "\\["), "\\")
+		}
+		if id != "" {
+			c.labels[id] = label
+			if c.markdown {
+				return "[^" + id + "]"
+			}
+		}
+		if c.markdown {
+			return "\\[" + label + "\\]"
+		}
+		return "[" + label + "]"
+	}
+
+	if !c.markdown {
+		return text
+	}
+	switch tagName(el) {
+	case "em", "i", "cite", "dfn":
+		return wrapInline(text, "*")
+	case "strong", "b":
+		return wrapInline(text, "**")
+	case "code", "kbd", "samp":
+		return wrapInline(text, "`")
+	}
+	return text
+}
+
+var codeTags = map[string]bool{"code": true, "kbd": true, "samp": true}
+
+// text prepares a run of document text, escaping Markdown syntax outside
+// code spans.
+func (c *textConverter) text(s string) string {
+	s = stripLineBreaks(s)
+	if !c.markdown || c.verbatim {
+		return s
+	}
+	return markdownEscaper.Replace(s)
+}
+
+// markdownEscaper escapes the characters that are Markdown syntax anywhere
+// in a line.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+)
+
+// orderedMarker matches an ordered list marker ("1." or "1)") at the start
+// of a line.
+var orderedMarker = regexp.MustCompile(`^(\d{1,9})([.)])(\s|$)`)
+
+// escapeLineStarts escapes the Markdown block markers (headings, quotes,
+// list items) that text at the start of a line would form.
+func escapeLineStarts(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "#"), strings.HasPrefix(l, ">"),
+			strings.HasPrefix(l, "- "), strings.HasPrefix(l, "+ "), l == "-", l == "+":
+			lines[i] = `\` + l
+		default:
+			lines[i] = orderedMarker.ReplaceAllString(l, `$1\$2$3`)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineText renders the children of el as a single cleaned line.
+func (c *textConverter) inlineText(el *etree.Element) string {
+	var sb strings.Builder
+	for _, tok := range el.Child {
+		switch t := tok.(type) {
+		case *etree.CharData:
+			sb.WriteString(c.text(t.Data))
+		case *etree.Element:
+			if !c.skip(t) {
+				sb.WriteString(c.inline(t))
+			}
+		}
+	}
+	return cleanInline(sb.String())
+}
+
+// rawText returns the text of el and its descendants with whitespace intact.
+func rawText(el *etree.Element) string {
+	var sb strings.Builder
+	for _, tok := range el.Child {
+		switch t := tok.(type) {
+		case *etree.CharData:
+			sb.WriteString(t.Data)
+		case *etree.Element:
+			if tagName(t) == "br" {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString(rawText(t))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// wrapInline surrounds text with a Markdown marker, keeping outer whitespace outside.
+func wrapInline(text, mark string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + mark + trimmed + mark + text[start+len(trimmed):]
+}
+
+// lineBreak marks a <br> in inline content. Source newlines are ordinary
+// whitespace in XHTML, so a private marker keeps the two apart until
+// cleanInline. NUL is not allowed in XML text, and stripLineBreaks removes it
+// from character data in case a lenient parse let one through.
+const lineBreak = "\x00"
+
+// stripLineBreaks removes the lineBreak marker from document text.
+func stripLineBreaks(s string) string {
+	return strings.ReplaceAll(s, lineBreak, "")
+}
+
+// cleanInline collapses whitespace runs while keeping explicit line breaks.
+func cleanInline(s string) string {
+	var out []string
+	for _, l := range strings.Split(s, lineBreak) {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
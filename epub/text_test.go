@@ -0,0 +1,213 @@
+package epub
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const textTestChapter = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>Ignored</title><style>p { color: red; }</style></head>
+<body>
+  <h1>Chapter   One</h1>
+  <p>Some <em>emphasized</em> and <b>bold</b>
+     text.<a epub:type="noteref" href="#fn1">1</a></p>
+  <ul>
+    <li>First</li>
+    <li>Second
+      <ol><li>Nested</li></ol>
+    </li>
+  </ul>
+  <p hidden="hidden">Secret</p>
+  <div style="display: none">Invisible</div>
+  <p>Line<br/>break &amp; <ruby>漢<rt>kan</rt></ruby>字</p>
+  <aside epub:type="footnote" id="fn1"><p>The note.</p></aside>
+</body>
+</html>`
+
+func textTestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Text Test</dc:title>
+    <dc:identifier id="uid">urn:uuid:9999</dc:identifier>
+  </metadata>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="aux" href="aux.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="c2.html" media-type="text/html"/>
+    <item id="img" href="a.jpg" media-type="image/jpeg"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref idref="aux" linear="no"/>
+    <itemref idref="c2"/>
+  </spine>
+</package>`},
+		{"OEBPS/c1.xhtml", textTestChapter},
+		{"OEBPS/aux.xhtml", `<html><body><p>Auxiliary</p></body></html>`},
+		{"OEBPS/c2.html", `<html><body><P>Unclosed<p>HTML &nbsp;paragraph</body></html>`},
+		{"OEBPS/a.jpg", "\xFF\xD8"},
+	}
+}
+
+func TestExtractText_Plain(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, textTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	chapters, err := r.ExtractText(TextOptions{})
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	if len(chapters) != 2 {
+		t.Fatalf("Expected 2 linear chapters, got %d", len(chapters))
+	}
+	if chapters[0].Title != "Chapter One" || chapters[0].ID != "c1" {
+		t.Errorf("Unexpected chapter info: %+v", chapters[0])
+	}
+
+	expected := "Chapter One\n\n" +
+		"Some emphasized and bold text.[1]\n\n" +
+		"- First\n- Second\n  1. Nested\n\n" +
+		"Line\nbreak & 漢字\n\n" +
+		"[1] The note."
+	if chapters[0].Text != expected {
+		t.Errorf("Unexpected text:\n%s\n--- want ---\n%s", chapters[0].Text, expected)
+	}
+	if !strings.Contains(chapters[1].Text, "Unclosed") || !strings.Contains(chapters[1].Text, "HTML") {
+		t.Errorf("HTML chapter not extracted: %q", chapters[1].Text)
+	}
+}
+
+func TestExtractText_LineSeparatorInText(t *testing.T) {
+	data := "<html xmlns=\"http://www.w3.org/1999/xhtml\"><body><p>one\u2028two&#x2028;three<br/>four</p></body></html>"
+	text, _, err := convertXHTML([]byte(data), TextOptions{})
+	if err != nil {
+		t.Fatalf("convertXHTML failed: %v", err)
+	}
+	if text != "one two three\nfour" {
+		t.Errorf("Expected only <br> to break lines, got %q", text)
+	}
+}
+
+func TestExtractText_Markdown(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, textTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	chapters, err := r.ExtractText(TextOptions{Format: FormatMarkdown})
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	text := chapters[0].Text
+	for _, want := range []string{
+		"# Chapter One",
+		"Some *emphasized* and **bold** text.[^fn1]",
+		"- Second\n  1. Nested",
+		"[^fn1]: The note.",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestExtractText_MarkdownEscaping(t *testing.T) {
+	data := `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<p>#hashtag and 2*3*4 with snake_case_name</p>
+<p>1. not a list</p>
+<p>- not an item<br/>&gt; not a quote</p>
+<p>See [1] and a back` + "`" + `quote, <em>really</em></p>
+<p>Call <code>a_b[0] * 2</code></p>
+<pre>x = a_b[0] * 2
+# comment</pre>
+<table><tr><td>a|b</td></tr></table>
+</body></html>`
+	text, _, err := convertXHTML([]byte(data), TextOptions{Format: FormatMarkdown})
+	if err != nil {
+		t.Fatalf("convertXHTML failed: %v", err)
+	}
+	for _, want := range []string{
+		`\#hashtag and 2\*3\*4 with snake\_case\_name`,
+		`1\. not a list`,
+		"\\- not an item\n\\> not a quote",
+		"See \\[1\\] and a back\\`quote, *really*",
+		"Call `a_b[0] * 2`",
+		"```\nx = a_b[0] * 2\n# comment\n```",
+		`| a\|b |`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, text)
+		}
+	}
+
+	// Plain text is left alone
+	plain, _, err := convertXHTML([]byte(data), TextOptions{})
+	if err != nil {
+		t.Fatalf("convertXHTML failed: %v", err)
+	}
+	if !strings.Contains(plain, "#hashtag and 2*3*4 with snake_case_name") {
+		t.Errorf("Plain text escaped:\n%s", plain)
+	}
+}
+
+func TestExtractText_Options(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, textTestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	chapters, err := r.ExtractText(TextOptions{IncludeNonLinear: true, IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	if len(chapters) != 3 || chapters[1].ID != "aux" {
+		t.Fatalf("Non-linear chapter not included: %+v", chapters)
+	}
+	for _, want := range []string{"Secret", "Invisible"} {
+		if !strings.Contains(chapters[0].Text, want) {
+			t.Errorf("Hidden content %q not included", want)
+		}
+	}
+
+	joined := JoinChapters(chapters, "----")
+	if strings.Count(joined, "\n\n----\n\n") != 2 {
+		t.Errorf("Unexpected separators in joined text:\n%s", joined)
+	}
+}
+
+func TestExtractText_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub2/215584.epub",
+		"../cmd/test-suite/testdata/samples/epub3-pure/215714.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			chapters, err := r.ExtractText(TextOptions{Format: FormatMarkdown})
+			if err != nil {
+				t.Fatalf("ExtractText failed: %v", err)
+			}
+			if len(strings.TrimSpace(JoinChapters(chapters, ""))) == 0 {
+				t.Error("No text extracted")
+			}
+		})
+	}
+}
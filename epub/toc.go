@@ -0,0 +1,367 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// navPoint is a navigation entry shared by the NCX, the EPUB 3 nav document
+// and the guide. Targets are stored as full zip paths so they can be written
+// relative to any document.
+type navPoint struct {
+	Title    string
+	Type     string // landmark/guide type (epub:type vocabulary)
+	Path     string // full zip path of the target, "" for headings without a link
+	Fragment string // "#id" or ""
+	Children []navPoint
+}
+
+// guideToLandmark maps EPUB 2 guide types to EPUB 3 structural semantics.
+var guideToLandmark = map[string]string{
+	"cover":            "cover",
+	"title-page":       "titlepage",
+	"toc":              "toc",
+	"text":             "bodymatter",
+	"preface":          "preface",
+	"foreword":         "foreword",
+	"acknowledgements": "acknowledgments",
+	"bibliography":     "bibliography",
+	"glossary":         "glossary",
+	"index":            "index",
+	"loi":              "loi",
+	"lot":              "lot",
+	"colophon":         "colophon",
+	"copyright-page":   "copyright-page",
+	"dedication":       "dedication",
+	"epigraph":         "epigraph",
+	"notes":            "endnotes",
+}
+
+// ncxItem returns the NCX manifest item: the spine toc attribute, or the
+// first item with the NCX media type.
+func (r *Reader) ncxItem() *Item {
+	if r.Package.Spine.Toc != "" {
+		if item := r.findItem(r.Package.Spine.Toc); item != nil {
+			return item
+		}
+	}
+	for i := range r.Package.Manifest.Items {
+		if strings.EqualFold(r.Package.Manifest.Items[i].MediaType, "application/x-dtbncx+xml") {
+			return &r.Package.Manifest.Items[i]
+		}
+	}
+	return nil
+}
+
+// readNCX parses the NCX navMap and pageList.
+func (r *Reader) readNCX(ncxPath string) ([]navPoint, []navPoint, error) {
+	doc, err := r.readXMLDocument(ncxPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir := path.Dir(ncxPath)
+
+	target := func(el *etree.Element) navPoint {
+		var p navPoint
+		if label := el.FindElement("./navLabel/text"); label != nil {
+			p.Title = strings.TrimSpace(label.Text())
+		}
+		if content := el.FindElement("./content"); content != nil {
+			src := content.SelectAttrValue("src", "")
+			if src != "" && !isExternalRef(src) {
+				p.Path = resolveRelative(dir, src)
+				p.Fragment = hrefFragment(src)
+			}
+		}
+		return p
+	}
+
+	var walk func(parent *etree.Element) []navPoint
+	walk = func(parent *etree.Element) []navPoint {
+		var points []navPoint
+		for _, el := range parent.SelectElements("navPoint") {
+			p := target(el)
+			p.Children = walk(el)
+			points = append(points, p)
+		}
+		return points
+	}
+
+	var toc, pages []navPoint
+	if navMap := doc.FindElement("//navMap"); navMap != nil {
+		toc = walk(navMap)
+	}
+	if pageList := doc.FindElement("//pageList"); pageList != nil {
+		for _, el := range pageList.SelectElements("pageTarget") {
+			pages = append(pages, target(el))
+		}
+	}
+	return toc, pages, nil
+}
+
+// readNav parses the EPUB 3 navigation document: toc, landmarks and page-list.
+func (r *Reader) readNav(navPath string) (toc, landmarks, pages []navPoint, err error) {
+	doc, err := r.readXMLDocument(navPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dir := path.Dir(navPath)
+
+	var walk func(ol *etree.Element) []navPoint
+	walk = func(ol *etree.Element) []navPoint {
+		var points []navPoint
+		for _, li := range ol.SelectElements("li") {
+			var p navPoint
+			if a := li.SelectElement("a"); a != nil {
+				p.Title = collapseSpace(elementText(a))
+				p.Type = a.SelectAttrValue("epub:type", "")
+				if href := a.SelectAttrValue("href", ""); href != "" && !isExternalRef(href) {
+					p.Path = resolveRelative(dir, href)
+					p.Fragment = hrefFragment(href)
+				}
+			} else if span := li.SelectElement("span"); span != nil {
+				p.Title = collapseSpace(elementText(span))
+			}
+			if sub := li.SelectElement("ol"); sub != nil {
+				p.Children = walk(sub)
+			}
+			points = append(points, p)
+		}
+		return points
+	}
+
+	for _, nav := range doc.FindElements("//nav") {
+		ol := nav.SelectElement("ol")
+		if ol == nil {
+			continue
+		}
+		navType := nav.SelectAttrValue("epub:type", "")
+		switch {
+		case hasProperty(navType, "toc") && toc == nil:
+			toc = walk(ol)
+		case hasProperty(navType, "landmarks") && landmarks == nil:
+			landmarks = walk(ol)
+		case hasProperty(navType, "page-list") && pages == nil:
+			pages = walk(ol)
+		}
+	}
+	return toc, landmarks, pages, nil
+}
+
+// guideLandmarks converts guide references to landmarks.
+func (r *Reader) guideLandmarks() []navPoint {
+	if r.Package.Guide == nil {
+		return nil
+	}
+	var landmarks []navPoint
+	for _, ref := range r.Package.Guide.References {
+		landmark, ok := guideToLandmark[strings.ToLower(ref.Type)]
+		if !ok || isExternalRef(ref.Href) {
+			continue
+		}
+		landmarks = append(landmarks, navPoint{
+			Title:    ref.Title,
+			Type:     landmark,
+			Path:     r.resolveHref(ref.Href),
+			Fragment: hrefFragment(ref.Href),
+		})
+	}
+	return landmarks
+}
+
+// buildNavDocument renders an EPUB 3 navigation document located at navPath.
+func buildNavDocument(navPath, title, lang string, toc, landmarks, pages []navPoint) ([]byte, error) {
+	dir := path.Dir(navPath)
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="utf-8"`)
+	doc.CreateDirective("DOCTYPE html")
+
+	html := doc.CreateElement("html")
+	html.CreateAttr("xmlns", "http://www.w3.org/1999/xhtml")
+	html.CreateAttr("xmlns:epub", "http://www.idpf.org/2007/ops")
+	if lang != "" {
+		html.CreateAttr("lang", lang)
+		html.CreateAttr("xml:lang", lang)
+	}
+	head := html.CreateElement("head")
+	head.CreateElement("title").SetText(title)
+	body := html.CreateElement("body")
+
+	var list func(parent *etree.Element, points []navPoint)
+	list = func(parent *etree.Element, points []navPoint) {
+		ol := parent.CreateElement("ol")
+		for _, p := range points {
+			li := ol.CreateElement("li")
+			label := p.Title
+			if label == "" {
+				label = path.Base(p.Path)
+			}
+			if p.Path != "" {
+				a := li.CreateElement("a")
+				if p.Type != "" {
+					a.CreateAttr("epub:type", p.Type)
+				}
+				a.CreateAttr("href", encodedHref(dir, p.Path)+p.Fragment)
+				a.SetText(label)
+			} else {
+				li.CreateElement("span").SetText(label)
+			}
+			if len(p.Children) > 0 {
+				list(li, p.Children)
+			}
+		}
+	}
+
+	nav := body.CreateElement("nav")
+	nav.CreateAttr("epub:type", "toc")
+	nav.CreateAttr("id", "toc")
+	nav.CreateElement("h1").SetText(title)
+	list(nav, toc)
+
+	if len(landmarks) > 0 {
+		nav := body.CreateElement("nav")
+		nav.CreateAttr("epub:type", "landmarks")
+		nav.CreateAttr("id", "landmarks")
+		nav.CreateAttr("hidden", "")
+		list(nav, landmarks)
+	}
+	if len(pages) > 0 {
+		nav := body.CreateElement("nav")
+		nav.CreateAttr("epub:type", "page-list")
+		nav.CreateAttr("id", "page-list")
+		nav.CreateAttr("hidden", "")
+		list(nav, pages)
+	}
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+// elementText returns the concatenated text content of el.
+func elementText(el *etree.Element) string {
+	var sb strings.Builder
+	for _, tok := range el.Child {
+		switch t := tok.(type) {
+		case *etree.CharData:
+			sb.WriteString(t.Data)
+		case *etree.Element:
+			sb.WriteString(elementText(t))
+		}
+	}
+	return sb.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// buildNCX renders an NCX document located at ncxPath.
+// Entries without a target take the target of their first descendant, as the
+// NCX requires a content element on every navPoint. Entries pointing to the
+// same target share a playOrder.
+func buildNCX(ncxPath, uid, title, lang string, toc, pages []navPoint) ([]byte, error) {
+	dir := path.Dir(ncxPath)
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="utf-8"`)
+	ncx := doc.CreateElement("ncx")
+	ncx.CreateAttr("xmlns", "http://www.daisy.org/z3986/2005/ncx/")
+	ncx.CreateAttr("version", "2005-1")
+	if lang != "" {
+		ncx.CreateAttr("xml:lang", lang)
+	}
+
+	head := ncx.CreateElement("head")
+	meta := func(name, content string) {
+		m := head.CreateElement("meta")
+		m.CreateAttr("name", name)
+		m.CreateAttr("content", content)
+	}
+	meta("dtb:uid", uid)
+	meta("dtb:depth", fmt.Sprintf("%d", max(navDepth(toc), 1)))
+	meta("dtb:totalPageCount", fmt.Sprintf("%d", len(pages)))
+	meta("dtb:maxPageNumber", fmt.Sprintf("%d", len(pages)))
+	ncx.CreateElement("docTitle").CreateElement("text").SetText(title)
+
+	playOrder := make(map[string]int)
+	order := func(src string) string {
+		if _, ok := playOrder[src]; !ok {
+			playOrder[src] = len(playOrder) + 1
+		}
+		return fmt.Sprintf("%d", playOrder[src])
+	}
+
+	count := 0
+	var points func(parent *etree.Element, entries []navPoint)
+	points = func(parent *etree.Element, entries []navPoint) {
+		for _, p := range entries {
+			target := p
+			if target.Path == "" {
+				target = firstTarget(p.Children)
+			}
+			if target.Path == "" {
+				points(parent, p.Children)
+				continue
+			}
+			count++
+			src := encodedHref(dir, target.Path) + target.Fragment
+			np := parent.CreateElement("navPoint")
+			np.CreateAttr("id", fmt.Sprintf("navPoint-%d", count))
+			np.CreateAttr("playOrder", order(src))
+			label := p.Title
+			if label == "" {
+				label = path.Base(target.Path)
+			}
+			np.CreateElement("navLabel").CreateElement("text").SetText(label)
+			np.CreateElement("content").CreateAttr("src", src)
+			points(np, p.Children)
+		}
+	}
+	points(ncx.CreateElement("navMap"), toc)
+
+	if len(pages) > 0 {
+		pageList := ncx.CreateElement("pageList")
+		for i, p := range pages {
+			if p.Path == "" {
+				continue
+			}
+			src := encodedHref(dir, p.Path) + p.Fragment
+			pt := pageList.CreateElement("pageTarget")
+			pt.CreateAttr("id", fmt.Sprintf("pageTarget-%d", i+1))
+			pt.CreateAttr("type", "normal")
+			pt.CreateAttr("value", p.Title)
+			pt.CreateAttr("playOrder", order(src))
+			pt.CreateElement("navLabel").CreateElement("text").SetText(p.Title)
+			pt.CreateElement("content").CreateAttr("src", src)
+		}
+	}
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+// firstTarget returns the first entry with a target in document order.
+func firstTarget(entries []navPoint) navPoint {
+	for _, p := range entries {
+		if p.Path != "" {
+			return p
+		}
+		if t := firstTarget(p.Children); t.Path != "" {
+			return t
+		}
+	}
+	return navPoint{}
+}
+
+// navDepth returns the nesting depth of entries.
+func navDepth(entries []navPoint) int {
+	depth := 0
+	for _, p := range entries {
+		depth = max(depth, 1+navDepth(p.Children))
+	}
+	return depth
+}
@@ -0,0 +1,261 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// modifiedFormat is the dcterms:modified format required by EPUB 3 (CCYY-MM-DDThh:mm:ssZ).
+const modifiedFormat = "2006-01-02T15:04:05Z"
+
+// contentProperties detects content that must be declared in EPUB 3 manifest properties.
+var contentProperties = []struct {
+	prop string
+	re   *regexp.Regexp
+}{
+	{"mathml", regexp.MustCompile(`<(?:\w+:)?math[\s>/]`)},
+	{"scripted", regexp.MustCompile(`(?i)<script[\s>/]`)},
+	{"svg", regexp.MustCompile(`<(?:\w+:)?svg[\s>/]`)},
+}
+
+// UpgradeToEPUB3 converts an EPUB 2 package to EPUB 3 in place.
+//
+// It generates an XHTML navigation document from the NCX (the NCX is kept for
+// EPUB 2 reading systems), maps guide references to landmarks, converts
+// opf:role, opf:file-as and opf:scheme attributes to refines meta, adds
+// dcterms:modified and sets the cover-image, nav, svg, mathml and scripted
+// manifest properties. Call Save to write the result.
+func UpgradeToEPUB3(r *Reader) error {
+	pkg, err := r.fullPackage()
+	if err != nil {
+		return err
+	}
+	if pkg.isEPUB3() {
+		return fmt.Errorf("package is already EPUB %s", pkg.Version)
+	}
+
+	// 1. Navigation document
+	if r.navItem() == nil {
+		if err := r.addNavFromNCX(); err != nil {
+			return err
+		}
+	}
+
+	// 2. Metadata attributes -> refines
+	pkg.convertAttributesToRefines()
+
+	// 3. Manifest properties
+	if item, err := r.findCoverItem(); err == nil && !hasProperty(item.Properties, "cover-image") {
+		item.Properties = strings.TrimSpace(item.Properties + " cover-image")
+	}
+	r.setContentProperties()
+
+	// 4. Version and modification date
+	pkg.Version = "3.0"
+	pkg.SetModified(time.Now())
+	return nil
+}
+
+// SetModified sets dcterms:modified (EPUB 3 last modification date) in UTC.
+func (pkg *Package) SetModified(t time.Time) {
+	value := t.UTC().Format(modifiedFormat)
+	for i := range pkg.Metadata.Meta {
+		if pkg.Metadata.Meta[i].Property == "dcterms:modified" && pkg.Metadata.Meta[i].Refines == "" {
+			pkg.Metadata.Meta[i].Value = value
+			return
+		}
+	}
+	pkg.Metadata.Meta = append(pkg.Metadata.Meta, Meta{Property: "dcterms:modified", Value: value})
+}
+
+// addNavFromNCX writes a nav document built from the NCX (or the spine when
+// there is no NCX) and the guide, and adds it to the manifest.
+func (r *Reader) addNavFromNCX() error {
+	var toc, pages []navPoint
+	if ncx := r.ncxItem(); ncx != nil {
+		var err error
+		toc, pages, err = r.readNCX(r.resolveHref(ncx.Href))
+		if err != nil {
+			return fmt.Errorf("failed to read NCX: %w", err)
+		}
+	}
+	spine, err := r.Spine()
+	if err != nil {
+		return err
+	}
+	if len(toc) == 0 {
+		toc = r.spineTOC(spine)
+	}
+
+	// Place the nav next to the content documents
+	dir := path.Dir(r.OpfPath)
+	if len(spine) > 0 {
+		dir = path.Dir(spine[0].Path)
+	}
+	navPath := r.uniqueFilePath(dir, "nav", ".xhtml")
+
+	lang := r.Package.GetLanguage()
+	data, err := buildNavDocument(navPath, tocHeading(lang), lang, toc, r.guideLandmarks(), pages)
+	if err != nil {
+		return fmt.Errorf("failed to build nav document: %w", err)
+	}
+	if _, err := r.AddResource(encodedHref(path.Dir(r.OpfPath), navPath), "application/xhtml+xml", data, "nav"); err != nil {
+		return fmt.Errorf("failed to add nav document: %w", err)
+	}
+	return nil
+}
+
+// spineTOC builds a flat table of contents from the linear entries of spine,
+// titled by the first heading of each document.
+func (r *Reader) spineTOC(spine []SpineEntry) []navPoint {
+	var toc []navPoint
+	for _, entry := range spine {
+		if !entry.Linear || !isMarkupMediaType(entry.Item.MediaType) {
+			continue
+		}
+		p := navPoint{Path: entry.Path, Title: strings.TrimSuffix(path.Base(entry.Path), path.Ext(entry.Path))}
+		if data, err := r.readFile(entry.Path); err == nil {
+			if _, title, err := convertXHTML(data, TextOptions{}); err == nil && title != "" {
+				p.Title = title
+			}
+		}
+		toc = append(toc, p)
+	}
+	return toc
+}
+
+// tocHeading returns the heading of the generated table of contents.
+func tocHeading(lang string) string {
+	lang = strings.ToLower(lang)
+	switch {
+	case strings.HasPrefix(lang, "zh"):
+		return "目录"
+	case strings.HasPrefix(lang, "ja"):
+		return "目次"
+	default:
+		return "Contents"
+	}
+}
+
+// convertAttributesToRefines replaces EPUB 2 opf:role, opf:file-as and
+// opf:scheme attributes with EPUB 3 refines meta.
+func (pkg *Package) convertAttributesToRefines() {
+	used := pkg.usedIDs()
+	newID := func(base string) string {
+		for i := 1; ; i++ {
+			candidate := fmt.Sprintf("%s%02d", base, i)
+			if !used[candidate] {
+				used[candidate] = true
+				return candidate
+			}
+		}
+	}
+
+	convert := func(people []AuthorMeta, base string) {
+		for i := range people {
+			p := &people[i]
+			if p.Role == "" && p.FileAs == "" {
+				continue
+			}
+			if p.ID == "" {
+				p.ID = newID(base)
+			}
+			if p.Role != "" {
+				pkg.setRefinement(p.ID, "role", "marc:relators", p.Role)
+				p.Role = ""
+			}
+			if p.FileAs != "" {
+				pkg.setRefinement(p.ID, "file-as", "", p.FileAs)
+				p.FileAs = ""
+			}
+		}
+	}
+	convert(pkg.Metadata.Creators, "creator")
+	convert(pkg.Metadata.Contributors, "contributor")
+
+	for i := range pkg.Metadata.Identifiers {
+		id := &pkg.Metadata.Identifiers[i]
+		if id.Scheme == "" {
+			continue
+		}
+		if id.ID == "" {
+			id.ID = newID("id")
+		}
+		scheme, value := "", id.Scheme
+		switch normalizeScheme(id.Scheme) {
+		case "isbn":
+			scheme, value = "onix:codelist5", "15"
+			if len(strings.ReplaceAll(strings.ReplaceAll(id.Value, "-", ""), " ", "")) == 10 {
+				value = "02"
+			}
+		case "doi":
+			scheme, value = "onix:codelist5", "06"
+		}
+		pkg.setRefinement(id.ID, "identifier-type", scheme, value)
+		id.Scheme = ""
+	}
+}
+
+// setRefinement adds or updates <meta refines="#id" property="..." scheme="...">value</meta>.
+func (pkg *Package) setRefinement(id, property, scheme, value string) {
+	for i := range pkg.Metadata.Meta {
+		m := &pkg.Metadata.Meta[i]
+		if m.Refines == "#"+id && m.Property == property {
+			m.Scheme = scheme
+			m.Value = value
+			return
+		}
+	}
+	pkg.Metadata.Meta = append(pkg.Metadata.Meta, Meta{Refines: "#" + id, Property: property, Scheme: scheme, Value: value})
+}
+
+// usedIDs returns every id attribute used in the package document.
+func (pkg *Package) usedIDs() map[string]bool {
+	used := make(map[string]bool)
+	md := &pkg.Metadata
+	for _, group := range [][]SimpleMeta{md.Titles, md.Subjects, md.Descriptions, md.Publishers, md.Dates,
+		md.Types, md.Formats, md.Sources, md.Languages, md.Rights} {
+		for _, m := range group {
+			used[m.ID] = true
+		}
+	}
+	for _, group := range [][]AuthorMeta{md.Creators, md.Contributors} {
+		for _, m := range group {
+			used[m.ID] = true
+		}
+	}
+	for _, m := range md.Identifiers {
+		used[m.ID] = true
+	}
+	for _, m := range md.Meta {
+		used[m.ID] = true
+	}
+	for _, item := range pkg.Manifest.Items {
+		used[item.ID] = true
+	}
+	delete(used, "")
+	return used
+}
+
+// setContentProperties declares svg, mathml and scripted manifest properties
+// for XHTML documents that need them in EPUB 3.
+func (r *Reader) setContentProperties() {
+	for i := range r.Package.Manifest.Items {
+		item := &r.Package.Manifest.Items[i]
+		if !strings.EqualFold(item.MediaType, "application/xhtml+xml") || hasProperty(item.Properties, "nav") {
+			continue
+		}
+		data, err := r.readFile(r.resolveHref(item.Href))
+		if err != nil {
+			continue
+		}
+		for _, c := range contentProperties {
+			if c.re.Match(data) && !hasProperty(item.Properties, c.prop) {
+				item.Properties = strings.TrimSpace(item.Properties + " " + c.prop)
+			}
+		}
+	}
+}
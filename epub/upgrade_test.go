@@ -0,0 +1,229 @@
+package epub
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func epub2TestFiles() []testFile {
+	return []testFile{
+		{"META-INF/container.xml", testContainerXML},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="BookId">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>Upgrade Test</dc:title>
+    <dc:creator opf:role="aut" opf:file-as="Doe, Jane">Jane Doe</dc:creator>
+    <dc:contributor opf:role="bkp">Sigil</dc:contributor>
+    <dc:language>en</dc:language>
+    <dc:identifier id="BookId" opf:scheme="UUID">0b2c7c1e-1111-2222-3333-444455556666</dc:identifier>
+    <dc:identifier opf:scheme="ISBN">9787020002207</dc:identifier>
+    <dc:identifier opf:scheme="douban">1234567</dc:identifier>
+    <meta name="cover" content="cover-img"/>
+  </metadata>
+  <manifest>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="cover-img" href="Images/cover.jpg" media-type="image/jpeg"/>
+    <item id="cover" href="Text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch1" href="Text/ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="Text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="cover"/>
+    <itemref idref="ch1"/>
+    <itemref idref="ch2"/>
+  </spine>
+  <guide>
+    <reference type="cover" title="Cover" href="Text/cover.xhtml"/>
+    <reference type="text" title="Start" href="Text/ch1.xhtml#start"/>
+    <reference type="other.ms-coverimage" title="Cover" href="Images/cover.jpg"/>
+  </guide>
+</package>`},
+		{"OEBPS/toc.ncx", `<?xml version="1.0" encoding="utf-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head><meta name="dtb:uid" content="0b2c7c1e-1111-2222-3333-444455556666"/></head>
+  <docTitle><text>Upgrade Test</text></docTitle>
+  <navMap>
+    <navPoint id="np1" playOrder="1">
+      <navLabel><text>Chapter 1</text></navLabel>
+      <content src="Text/ch1.xhtml"/>
+      <navPoint id="np2" playOrder="2">
+        <navLabel><text>Section 1.1</text></navLabel>
+        <content src="Text/ch1.xhtml#s1"/>
+      </navPoint>
+    </navPoint>
+    <navPoint id="np3" playOrder="3">
+      <navLabel><text>Chapter 2</text></navLabel>
+      <content src="Text/ch2.xhtml"/>
+    </navPoint>
+  </navMap>
+  <pageList>
+    <pageTarget id="p1" type="normal" value="1"><navLabel><text>1</text></navLabel><content src="Text/ch1.xhtml#page1"/></pageTarget>
+  </pageList>
+</ncx>`},
+		{"OEBPS/Images/cover.jpg", "\xFF\xD8\xFF\xE0"},
+		{"OEBPS/Text/cover.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><svg xmlns="http://www.w3.org/2000/svg"><image href="../Images/cover.jpg"/></svg></body></html>`},
+		{"OEBPS/Text/ch1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1 id="start">Chapter 1</h1><h2 id="s1">Section</h2></body></html>`},
+		{"OEBPS/Text/ch2.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter 2</h1></body></html>`},
+	}
+}
+
+func TestUpgradeToEPUB3(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := UpgradeToEPUB3(r); err != nil {
+		t.Fatalf("UpgradeToEPUB3 failed: %v", err)
+	}
+	r2 := saveAndReopen(t, r)
+	pkg := r2.Package
+
+	if pkg.Version != "3.0" {
+		t.Errorf("Version not bumped: %s", pkg.Version)
+	}
+
+	// Metadata survives as refines
+	if pkg.GetAuthorSort() != "Doe, Jane" {
+		t.Errorf("Author sort lost: %q", pkg.GetAuthorSort())
+	}
+	if pkg.GetProducer() != "Sigil" {
+		t.Errorf("Producer lost: %q", pkg.GetProducer())
+	}
+	if pkg.GetISBN() != "9787020002207" {
+		t.Errorf("ISBN lost: %q", pkg.GetISBN())
+	}
+	if ids := pkg.GetIdentifiers(); ids["douban"] != "1234567" {
+		t.Errorf("Identifier scheme lost: %v", ids)
+	}
+	for _, c := range pkg.Metadata.Creators {
+		if c.Role != "" || c.FileAs != "" {
+			t.Errorf("opf: attributes not converted: %+v", c)
+		}
+	}
+	if pkg.refinement(pkg.Metadata.Creators[0].ID, "role") != "aut" {
+		t.Error("Missing role refines")
+	}
+	modified := false
+	for _, m := range pkg.Metadata.Meta {
+		if m.Property == "dcterms:modified" && len(m.Value) == len(modifiedFormat) {
+			modified = true
+		}
+	}
+	if !modified {
+		t.Error("Missing dcterms:modified")
+	}
+
+	// Manifest properties
+	if item := r2.findItem("cover-img"); !hasProperty(item.Properties, "cover-image") {
+		t.Error("Cover not marked cover-image")
+	}
+	if item := r2.findItem("cover"); !hasProperty(item.Properties, "svg") {
+		t.Error("SVG content not declared")
+	}
+	if r2.ncxItem() == nil || pkg.Spine.Toc != "ncx" {
+		t.Error("NCX should be kept for hybrid compatibility")
+	}
+
+	// Navigation document
+	nav := r2.navItem()
+	if nav == nil {
+		t.Fatal("Nav item not added")
+	}
+	navPath := r2.resolveHref(nav.Href)
+	if navPath != "OEBPS/Text/nav.xhtml" {
+		t.Errorf("Unexpected nav path: %s", navPath)
+	}
+	toc, landmarks, pages, err := r2.readNav(navPath)
+	if err != nil {
+		t.Fatalf("readNav failed: %v", err)
+	}
+	if len(toc) != 2 || toc[0].Title != "Chapter 1" || len(toc[0].Children) != 1 {
+		t.Fatalf("Unexpected toc: %+v", toc)
+	}
+	if sub := toc[0].Children[0]; sub.Path != "OEBPS/Text/ch1.xhtml" || sub.Fragment != "#s1" {
+		t.Errorf("Unexpected nested entry: %+v", sub)
+	}
+	if len(landmarks) != 2 || landmarks[0].Type != "cover" || landmarks[1].Type != "bodymatter" || landmarks[1].Fragment != "#start" {
+		t.Errorf("Unexpected landmarks: %+v", landmarks)
+	}
+	if len(pages) != 1 || pages[0].Title != "1" {
+		t.Errorf("Unexpected page list: %+v", pages)
+	}
+	if n := brokenLinks(t, r2); n != 0 {
+		t.Errorf("%d broken links after upgrade", n)
+	}
+
+	if err := UpgradeToEPUB3(r2); err == nil {
+		t.Error("Expected error when upgrading an EPUB 3 package")
+	}
+}
+
+func TestUpgradeToEPUB3_NoNCX(t *testing.T) {
+	files := epub2TestFiles()
+	files[1].Content = strings.Replace(files[1].Content, `<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>`, "", 1)
+	files[1].Content = strings.Replace(files[1].Content, `<spine toc="ncx">`, "<spine>", 1)
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := UpgradeToEPUB3(r); err != nil {
+		t.Fatalf("UpgradeToEPUB3 failed: %v", err)
+	}
+	toc, _, _, err := r.readNav(r.resolveHref(r.navItem().Href))
+	if err != nil {
+		t.Fatalf("readNav failed: %v", err)
+	}
+	if len(toc) != 3 || toc[1].Title != "Chapter 1" || toc[0].Title != "cover" {
+		t.Errorf("Unexpected spine-based toc: %+v", toc)
+	}
+}
+
+func TestUpgradeToEPUB3_Samples(t *testing.T) {
+	samples := []string{
+		"../cmd/test-suite/testdata/samples/epub2/215584.epub",
+		"../cmd/test-suite/testdata/samples/epub2/215591.epub",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			if _, err := os.Stat(sample); err != nil {
+				t.Skip("sample not available")
+			}
+			r, err := Open(sample)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			title, identifiers := r.Package.GetTitle(), r.Package.GetIdentifiers()
+			if err := UpgradeToEPUB3(r); err != nil {
+				t.Fatalf("UpgradeToEPUB3 failed: %v", err)
+			}
+			r2 := saveAndReopen(t, r)
+
+			if r2.Package.GetTitle() != title {
+				t.Errorf("Title changed: %q", r2.Package.GetTitle())
+			}
+			for k, v := range identifiers {
+				if got := r2.Package.GetIdentifiers()[k]; got != v {
+					t.Errorf("Identifier %s changed: %q -> %q", k, v, got)
+				}
+			}
+			nav := r2.navItem()
+			if nav == nil {
+				t.Fatal("Nav item not added")
+			}
+			toc, _, _, err := r2.readNav(r2.resolveHref(nav.Href))
+			if err != nil || len(toc) == 0 {
+				t.Errorf("Empty nav toc: %v", err)
+			}
+			if n := brokenLinks(t, r2); n != 0 {
+				t.Errorf("%d broken links after upgrade", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package epub
+
+import "fmt"
+
+// Warning codes reported by Reader.Warnings.
+const (
+	WarnInvalidComment          = "invalid-comment"           // OPF comment containing "--" removed
+	WarnNamespaceTypo           = "namespace-typo"            // mlns= rewritten to xmlns=
+	WarnUnknownEncoding         = "unknown-encoding"          // declared encoding read as UTF-8
+	WarnApproximateEncoding     = "approximate-encoding"      // Windows-1252 read as ISO-8859-1
+	WarnFallbackRootfile        = "fallback-rootfile"         // rootfile chosen without the OPF media type
+	WarnMissingUniqueIdentifier = "missing-unique-identifier" // unique-identifier unset or dangling
+	WarnDanglingSpineItem       = "dangling-spine-itemref"    // spine itemref without manifest item
+)
+
+// Warning is a diagnostic about a problem Open tolerated instead of failing.
+type Warning struct {
+	Code    string `json:"code"`
+	Path    string `json:"path,omitempty"` // file the warning applies to
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	if w.Path != "" {
+		return fmt.Sprintf("%s: %s [%s]", w.Path, w.Message, w.Code)
+	}
+	return fmt.Sprintf("%s [%s]", w.Message, w.Code)
+}
+
+// Warnings returns the problems tolerated while opening the book, in the
+// order they were found.
+func (r *Reader) Warnings() []Warning {
+	return append([]Warning(nil), r.warnings...)
+}
+
+func (r *Reader) warn(code, path, format string, args ...any) {
+	r.warnings = append(r.warnings, Warning{Code: code, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkPackage reports structural problems of the parsed package that the
+// reader works around.
+func (r *Reader) checkPackage() {
+	pkg := r.Package
+	if pkg.UniqueIdentifier == "" {
+		r.warn(WarnMissingUniqueIdentifier, r.OpfPath, "package has no unique-identifier attribute")
+	} else if !pkg.hasIdentifierID(pkg.UniqueIdentifier) {
+		r.warn(WarnMissingUniqueIdentifier, r.OpfPath, "unique-identifier %q does not reference a dc:identifier", pkg.UniqueIdentifier)
+	}
+	r.checkSpine()
+}
+
+// checkSpine reports spine itemrefs without a manifest item.
+func (r *Reader) checkSpine() {
+	for _, ref := range r.Package.Spine.ItemRefs {
+		if r.findItem(ref.IDRef) == nil {
+			r.warn(WarnDanglingSpineItem, r.OpfPath, "spine itemref %q has no manifest item", ref.IDRef)
+		}
+	}
+}
+
+// hasIdentifierID reports whether a dc:identifier has the given id.
+func (pkg *Package) hasIdentifierID(id string) bool {
+	for _, ident := range pkg.Metadata.Identifiers {
+		if ident.ID == id {
+			return true
+		}
+	}
+	return false
+}
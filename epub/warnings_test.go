@@ -0,0 +1,106 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func warningCodes(r *Reader) map[string]int {
+	codes := make(map[string]int)
+	for _, w := range r.Warnings() {
+		codes[w.Code]++
+	}
+	return codes
+}
+
+func TestWarnings(t *testing.T) {
+	opf := func(replace, with string) func([]testFile) {
+		return func(files []testFile) {
+			files[1].Content = strings.Replace(files[1].Content, replace, with, 1)
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func([]testFile)
+		code   string
+	}{
+		{"invalid comment", opf("<manifest>", "<!-- bad -- comment --><manifest>"), WarnInvalidComment},
+		{"namespace typo", opf(`<package xmlns="http://www.idpf.org/2007/opf"`, `<package mlns="http://www.idpf.org/2007/opf"`), WarnNamespaceTypo},
+		{"unknown encoding", opf(`encoding="utf-8"`, `encoding="x-unknown"`), WarnUnknownEncoding},
+		{"windows-1252", opf(`encoding="utf-8"`, `encoding="windows-1252"`), WarnApproximateEncoding},
+		{"fallback rootfile", func(files []testFile) {
+			files[0].Content = strings.Replace(testContainerXML, "application/oebps-package+xml", "text/xml", 1)
+		}, WarnFallbackRootfile},
+		{"dangling unique identifier", opf(`unique-identifier="BookId"`, `unique-identifier="missing"`), WarnMissingUniqueIdentifier},
+		{"no unique identifier", opf(` unique-identifier="BookId"`, ""), WarnMissingUniqueIdentifier},
+		{"dangling spine itemref", opf(`<itemref idref="ch2"/>`, `<itemref idref="ch2"/><itemref idref="ch3"/>`), WarnDanglingSpineItem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := epub2TestFiles()
+			tt.modify(files)
+			r, err := Open(writeTestEPUB(t, files))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			codes := warningCodes(r)
+			if codes[tt.code] != 1 || len(codes) != 1 {
+				t.Errorf("Expected one %s warning, got %v", tt.code, r.Warnings())
+			}
+		})
+	}
+}
+
+func TestOpen_CharsetCase(t *testing.T) {
+	tests := []struct {
+		encoding string
+		title    string
+		want     string
+		warnings int
+	}{
+		{"LATIN1", "Caf\xe9", "Caf\u00e9", 0},
+		{"ISO-8859-1", "Caf\xe9", "Caf\u00e9", 0},
+		{"WINDOWS-1252", "Caf\xe9", "Caf\u00e9", 1},
+		{"US-ASCII", "Cafe", "Cafe", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			files := epub2TestFiles()
+			files[1].Content = strings.Replace(files[1].Content, `encoding="utf-8"`, `encoding="`+tt.encoding+`"`, 1)
+			files[1].Content = strings.Replace(files[1].Content, "Upgrade Test", tt.title, 1)
+			r, err := Open(writeTestEPUB(t, files))
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer r.Close()
+
+			if got := r.Package.GetTitle(); got != tt.want {
+				t.Errorf("Title = %q, want %q", got, tt.want)
+			}
+			if w := r.Warnings(); len(w) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %v", tt.warnings, w)
+			}
+		})
+	}
+}
+
+func TestWarnings_Clean(t *testing.T) {
+	r, err := Open(writeTestEPUB(t, epub2TestFiles()))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	if w := r.Warnings(); len(w) != 0 {
+		t.Errorf("Expected no warnings, got %v", w)
+	}
+}
+
+func TestWarningString(t *testing.T) {
+	w := Warning{Code: WarnDanglingSpineItem, Path: "OEBPS/content.opf", Message: `spine itemref "x" has no manifest item`}
+	if got := w.String(); got != `OEBPS/content.opf: spine itemref "x" has no manifest item [dangling-spine-itemref]` {
+		t.Errorf("Unexpected string: %s", got)
+	}
+}
@@ -2,18 +2,63 @@ package epub
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
+// SaveOptions controls SaveWithOptions.
+type SaveOptions struct {
+	// Deterministic makes the output depend only on the book and the edits:
+	// entries get the ModTime timestamp, 0644 permissions and no extra fields,
+	// new entries are written sorted by path, and a rewritten EPUB 3 package
+	// gets ModTime as dcterms:modified.
+	Deterministic bool
+	// ModTime is the timestamp in deterministic mode; zero uses 1980-01-01.
+	ModTime time.Time
+}
+
+// zipEpoch is the earliest timestamp of the MS-DOS date format.
+var zipEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// headerFunc adjusts the header of each written entry; nil leaves it as is.
+type headerFunc func(h *zip.FileHeader)
+
 // Save writes the modified EPUB to the specified output path.
 // It preserves the original ZIP entry order (except mimetype which must be first).
 // It preserves the original compression method for each entry.
 // It writes to a temporary file first to support in-place rewriting.
 func (r *Reader) Save(outputPath string) error {
+	return r.SaveWithOptions(outputPath, SaveOptions{})
+}
+
+// SaveWithOptions is Save with options, e.g. for reproducible output.
+func (r *Reader) SaveWithOptions(outputPath string, opts SaveOptions) error {
+	if _, err := r.fullPackage(); err != nil {
+		return err
+	}
+	var normalize headerFunc
+	var modified time.Time
+	if opts.Deterministic {
+		modTime := opts.ModTime
+		if modTime.IsZero() {
+			modTime = zipEpoch
+		}
+		normalize = func(h *zip.FileHeader) { normalizeHeader(h, modTime) }
+		// A rewritten package records the same modification date every time
+		modified = modTime
+	}
+	// 0. Keep obfuscated fonts readable if the unique identifier changed
+	if err := r.rekeyObfuscatedFonts(); err != nil {
+		return fmt.Errorf("failed to re-obfuscate fonts: %w", err)
+	}
+
 	// 1. Create temp file
 	tempDir := filepath.Dir(outputPath)
 	if tempDir == "." {
@@ -38,13 +83,13 @@ func (r *Reader) Save(outputPath string) error {
 	w := zip.NewWriter(tmpF)
 
 	// 3. Write mimetype (MUST be first, STORED, no extra fields)
-	if err := writeMimetype(w); err != nil {
+	if err := writeMimetype(w, normalize); err != nil {
 		return err
 	}
 
 	// 4. Prepare modified content
 	// Serialize OPF using etree for better namespace control
-	opfContent, err := r.Package.marshalOPFWithEtree()
+	opfContent, err := r.opfContentAt(modified)
 	if err != nil {
 		return fmt.Errorf("failed to marshal OPF: %w", err)
 	}
@@ -54,12 +99,6 @@ func (r *Reader) Save(outputPath string) error {
 	writtenFiles := make(map[string]bool)
 	writtenFiles["mimetype"] = true
 
-	// Build a map of original files for looking up compression method
-	originalFiles := make(map[string]*zip.File)
-	for _, f := range r.zipReader.File {
-		originalFiles[f.Name] = f
-	}
-
 	// 5. Stream copy files in ORIGINAL order, replacing OPF and Replacements
 	for _, f := range r.zipReader.File {
 		name := f.Name
@@ -74,54 +113,70 @@ func (r *Reader) Save(outputPath string) error {
 			continue
 		}
 
+		// Skip files deleted via the editing API
+		src, replaced := r.replacementSource(name)
+		if r.removed[name] && !replaced {
+			continue
+		}
+
 		// Mark as written
 		writtenFiles[name] = true
 
 		// Determine what content to write
 		if name == r.OpfPath {
 			// Write modified OPF, preserving original compression method
-			if err := writeContentWithMethod(w, name, opfContent, f.Method); err != nil {
+			if err := writeContentWithMethod(w, name, bytes.NewReader(opfContent), f.Method, normalize); err != nil {
 				return fmt.Errorf("failed to write OPF: %w", err)
 			}
-		} else if r.Replacements != nil {
-			if content, ok := r.Replacements[name]; ok {
-				// Write replacement content, preserving original compression method
-				if err := writeContentWithMethod(w, name, content, f.Method); err != nil {
-					return fmt.Errorf("failed to write replacement %s: %w", name, err)
-				}
-			} else {
-				// Copy original file unchanged (raw copy, no re-compression)
-				if err := copyZipFile(r, f, w); err != nil {
-					return fmt.Errorf("failed to copy file %s: %w", name, err)
-				}
+		} else if replaced {
+			// Write replacement content, preserving original compression method
+			if err := r.writeSource(w, name, src, f.Method, normalize); err != nil {
+				return fmt.Errorf("failed to write replacement %s: %w", name, err)
 			}
 		} else {
-			// Copy original file unchanged
-			if err := copyZipFile(r, f, w); err != nil {
+			// Copy original file unchanged (raw copy, no re-compression)
+			if err := copyZipFile(r, f, w, normalize); err != nil {
 				return fmt.Errorf("failed to copy file %s: %w", name, err)
 			}
 		}
 	}
 
-	// 6. Write any NEW Replacement files (not in original ZIP)
-	if r.Replacements != nil {
-		for path, content := range r.Replacements {
-			if writtenFiles[path] {
-				continue
-			}
-			// New file: use Deflate by default, but if there's an original with same path, inherit its method
-			method := zip.Deflate
-			if orig, ok := originalFiles[path]; ok {
-				method = orig.Method
-			}
-			if err := writeContentWithMethod(w, path, content, method); err != nil {
-				return fmt.Errorf("failed to write new file %s: %w", path, err)
-			}
-			writtenFiles[path] = true
+	// 6. Write the OPF if it was relocated to a path not in the original ZIP
+	if !writtenFiles[r.OpfPath] {
+		if err := writeContentWithMethod(w, r.OpfPath, bytes.NewReader(opfContent), zip.Deflate, normalize); err != nil {
+			return fmt.Errorf("failed to write OPF: %w", err)
 		}
+		writtenFiles[r.OpfPath] = true
 	}
 
-	// 7. Close Writer explicitly to flush
+	// 7. Write any NEW replacement files (not in original ZIP)
+	var newPaths []string
+	for path := range r.Replacements {
+		newPaths = append(newPaths, path)
+	}
+	for path := range r.sources {
+		if _, ok := r.Replacements[path]; !ok {
+			newPaths = append(newPaths, path)
+		}
+	}
+	sort.Strings(newPaths)
+	for _, path := range newPaths {
+		if writtenFiles[path] {
+			continue
+		}
+		src, _ := r.replacementSource(path)
+		// New file: use Deflate by default, but if there's an original with same path, inherit its method
+		method := zip.Deflate
+		if orig, ok := r.entries[path]; ok {
+			method = orig.Method
+		}
+		if err := r.writeSource(w, path, src, method, normalize); err != nil {
+			return fmt.Errorf("failed to write new file %s: %w", path, err)
+		}
+		writtenFiles[path] = true
+	}
+
+	// 8. Close Writer explicitly to flush
 	if err := w.Close(); err != nil {
 		return fmt.Errorf("failed to close zip writer: %w", err)
 	}
@@ -129,7 +184,7 @@ func (r *Reader) Save(outputPath string) error {
 	// Close temp file before rename (required on Windows)
 	tmpF.Close()
 
-	// 8. Atomic rename
+	// 9. Atomic rename
 	if err := os.Rename(tmpPath, outputPath); err != nil {
 		return fmt.Errorf("failed to move temp file to output: %w", err)
 	}
@@ -138,27 +193,116 @@ func (r *Reader) Save(outputPath string) error {
 	return nil
 }
 
-// writeContentWithMethod writes content to the zip with specified compression method.
-func writeContentWithMethod(w *zip.Writer, name string, content []byte, method uint16) error {
+// opfContent returns the OPF bytes Save writes. An unchanged package keeps its
+// original bytes so that digests over the OPF (signatures.xml) stay valid.
+func (r *Reader) opfContent() ([]byte, error) {
+	return r.opfContentAt(time.Time{})
+}
+
+// opfContentAt is opfContent that, for a non-zero modified, writes it as the
+// dcterms:modified date of a changed EPUB 3 package. r.Package is left as it
+// is.
+func (r *Reader) opfContentAt(modified time.Time) ([]byte, error) {
+	pkg := r.Package
+	data, err := pkg.marshalOPFWithEtree()
+	if err != nil {
+		return nil, err
+	}
+	if original, ok := r.originalOPF(data); ok {
+		return original, nil
+	}
+	if modified.IsZero() || !pkg.isEPUB3() {
+		return data, nil
+	}
+	stamped := *pkg
+	stamped.Metadata.Meta = slices.Clone(pkg.Metadata.Meta)
+	stamped.SetModified(modified)
+	return stamped.marshalOPFWithEtree()
+}
+
+// OPFPreview returns the OPF as stored in the archive and as Save would write
+// it, e.g. to show a diff before saving. Both are equal for an unchanged
+// package.
+func (r *Reader) OPFPreview() (original, saved []byte, err error) {
+	if _, err := r.fullPackage(); err != nil {
+		return nil, nil, err
+	}
+	original, err = r.readOriginal(r.OpfPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read OPF: %w", err)
+	}
+	saved, err = r.opfContent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal OPF: %w", err)
+	}
+	return original, saved, nil
+}
+
+// originalOPF returns the original OPF bytes if the package, serialized to
+// data, is unchanged since it was read.
+func (r *Reader) originalOPF(data []byte) ([]byte, bool) {
+	if _, replaced := r.replacementSource(r.OpfPath); replaced || r.removed[r.OpfPath] {
+		return nil, false
+	}
+	rc, err := r.openFile(r.OpfPath)
+	if err != nil {
+		return nil, false
+	}
+	original, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, false
+	}
+	if pkg, err := r.readPackage(r.OpfPath); err == nil {
+		if unchanged, err := pkg.marshalOPFWithEtree(); err == nil && bytes.Equal(unchanged, data) {
+			return original, true
+		}
+	}
+	return nil, false
+}
+
+// writeContentWithMethod streams content to the zip with specified compression method.
+func writeContentWithMethod(w *zip.Writer, name string, content io.Reader, method uint16, normalize headerFunc) error {
 	header := &zip.FileHeader{
 		Name:   name,
 		Method: method,
 	}
+	if normalize != nil {
+		normalize(header)
+	}
 
 	fw, err := w.CreateHeader(header)
 	if err != nil {
 		return err
 	}
 
-	_, err = fw.Write(content)
+	_, err = io.Copy(fw, content)
 	return err
 }
 
-func writeMimetype(w *zip.Writer) error {
+// writeSource streams a replacement Source into the zip.
+// Entries of the original archive (e.g. moved resources) are copied raw under the new name.
+func (r *Reader) writeSource(w *zip.Writer, name string, src Source, method uint16, normalize headerFunc) error {
+	if entry, ok := src.(zipEntrySource); ok {
+		return copyZipFileAs(r, entry.f, w, name, normalize)
+	}
+
+	rc, err := src.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeContentWithMethod(w, name, rc, method, normalize)
+}
+
+func writeMimetype(w *zip.Writer, normalize headerFunc) error {
 	header := &zip.FileHeader{
 		Name:   "mimetype",
 		Method: zip.Store, // No compression
 	}
+	if normalize != nil {
+		normalize(header)
+	}
 
 	fw, err := w.CreateHeader(header)
 	if err != nil {
@@ -176,7 +320,12 @@ func writeMimetype(w *zip.Writer) error {
 
 // copyZipFile copies a file entry from source to destination zip using raw copy.
 // This preserves the original compression without re-encoding.
-func copyZipFile(r *Reader, f *zip.File, w *zip.Writer) error {
+func copyZipFile(r *Reader, f *zip.File, w *zip.Writer, normalize headerFunc) error {
+	return copyZipFileAs(r, f, w, f.Name, normalize)
+}
+
+// copyZipFileAs raw-copies a file entry, storing it under name.
+func copyZipFileAs(r *Reader, f *zip.File, w *zip.Writer, name string, normalize headerFunc) error {
 	// Directory entries are optional; skip them to avoid "zip: write to directory".
 	if f.FileInfo().IsDir() || strings.HasSuffix(f.Name, "/") {
 		return nil
@@ -187,6 +336,10 @@ func copyZipFile(r *Reader, f *zip.File, w *zip.Writer) error {
 
 	// Copy the header (CreateRaw treats it as immutable)
 	header := f.FileHeader
+	header.Name = name
+	if normalize != nil {
+		normalize(&header)
+	}
 
 	fw, err := w.CreateRaw(&header)
 	if err != nil {
@@ -196,7 +349,7 @@ func copyZipFile(r *Reader, f *zip.File, w *zip.Writer) error {
 	// Read raw bytes from Reader's underlying file
 	offset, err := f.DataOffset()
 	if err != nil {
-		return fmt.Errorf("failed to get data offset: %w", err)
+		return fmt.Errorf("failed to get data offset of %s: %w", f.Name, err)
 	}
 
 	// Read exactly CompressedSize64 bytes from the raw offset
@@ -205,3 +358,21 @@ func copyZipFile(r *Reader, f *zip.File, w *zip.Writer) error {
 	_, err = io.Copy(fw, section)
 	return err
 }
+
+// normalizeHeader gives h the timestamp t, 0644 permissions and no extra
+// fields or comment. The timestamp goes in the MS-DOS fields only: a Modified
+// time would make zip.Writer add an extended timestamp field, and CreateRaw
+// would ignore it.
+func normalizeHeader(h *zip.FileHeader, t time.Time) {
+	t = t.UTC()
+	if t.Before(zipEpoch) {
+		t = zipEpoch
+	}
+	h.Modified = time.Time{}
+	h.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	h.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	h.Extra = nil
+	h.Comment = ""
+	h.CreatorVersion = 0
+	h.SetMode(0644)
+}
@@ -2,10 +2,15 @@ package epub
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSave(t *testing.T) {
@@ -287,3 +292,139 @@ func TestSavePreservesCompressionMethod(t *testing.T) {
 		t.Errorf("mimetype should be Store, got method=%d", zr.File[0].Method)
 	}
 }
+
+func TestSaveWithOptions_Deterministic(t *testing.T) {
+	src := writeTestEPUB(t, epub2TestFiles())
+	opts := SaveOptions{Deterministic: true, ModTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	// The same edits, which include several new files and an upgrade stamped
+	// at different times, must give the same bytes
+	save := func(out string, now time.Time) []byte {
+		r, err := Open(src)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer r.Close()
+		r.Package.SetTitle("Reproducible")
+		for i := range 8 {
+			name := fmt.Sprintf("Text/new%d.xhtml", i)
+			if _, err := r.AddResource(name, "application/xhtml+xml", []byte("<html/>"), ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := UpgradeToEPUB3(r); err != nil {
+			t.Fatal(err)
+		}
+		r.Package.SetModified(now)
+		if err := r.SaveWithOptions(out, opts); err != nil {
+			t.Fatalf("SaveWithOptions failed: %v", err)
+		}
+		// The date is stamped on the written OPF only
+		for _, m := range r.Package.Metadata.Meta {
+			if m.Property == "dcterms:modified" && m.Value != now.UTC().Format(modifiedFormat) {
+				t.Errorf("SaveWithOptions changed the package: dcterms:modified %q", m.Value)
+			}
+		}
+		data, _ := os.ReadFile(out)
+		return data
+	}
+	dir := t.TempDir()
+	first := save(filepath.Join(dir, "a.epub"), time.Now())
+	second := save(filepath.Join(dir, "b.epub"), time.Now().Add(time.Hour))
+	if !bytes.Equal(first, second) {
+		t.Fatal("Expected byte-identical output")
+	}
+
+	r, err := Open(filepath.Join(dir, "a.epub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.zipReader.File {
+		names = append(names, f.Name)
+		if !f.Modified.Equal(opts.ModTime) || f.Mode() != 0644 || len(f.Extra) != 0 {
+			t.Errorf("Entry %s not normalized: %v %v extra %d", f.Name, f.Modified, f.Mode(), len(f.Extra))
+		}
+	}
+	var added []string
+	for _, name := range names {
+		if strings.Contains(name, "/new") {
+			added = append(added, name)
+		}
+	}
+	for i := 1; i < len(added); i++ {
+		if added[i-1] > added[i] {
+			t.Errorf("Expected new entries sorted, got %v", added)
+			break
+		}
+	}
+	for _, m := range r.Package.Metadata.Meta {
+		if m.Property == "dcterms:modified" && m.Value != "2024-05-01T12:00:00Z" {
+			t.Errorf("Expected dcterms:modified from ModTime, got %q", m.Value)
+		}
+	}
+}
+
+func TestOPFPreview(t *testing.T) {
+	files := epub2TestFiles()
+	r, err := Open(writeTestEPUB(t, files))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	original, saved, err := r.OPFPreview()
+	if err != nil {
+		t.Fatalf("OPFPreview failed: %v", err)
+	}
+	if string(original) != files[1].Content || !bytes.Equal(saved, original) {
+		t.Errorf("Expected the stored OPF on both sides of an unchanged package")
+	}
+
+	r.Package.SetTitle("Preview")
+	original, saved, err = r.OPFPreview()
+	if err != nil {
+		t.Fatalf("OPFPreview failed: %v", err)
+	}
+	if string(original) != files[1].Content {
+		t.Error("Expected the stored OPF as original")
+	}
+	r2 := saveAndReopen(t, r)
+	if written, _ := r2.readFile(r2.OpfPath); !bytes.Equal(saved, written) {
+		t.Errorf("Preview differs from the saved OPF:\n%s\n---\n%s", saved, written)
+	}
+}
+
+func TestSave_CorruptEntryHeader(t *testing.T) {
+	path := writeTestEPUB(t, coverTestFiles())
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	// Break the local header of the last entry after the archive was opened
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := bytes.LastIndex(data, []byte("PK\x03\x04"))
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte("XXXX"), int64(last))
+	f.Close()
+
+	err = r.Save(filepath.Join(t.TempDir(), "out.epub"))
+	if err == nil {
+		t.Fatal("Expected Save to fail")
+	}
+	if errors.Is(err, ErrNotZip) {
+		t.Errorf("Expected a save error, not ErrNotZip: %v", err)
+	}
+	if !errors.Is(err, zip.ErrFormat) {
+		t.Errorf("Expected the underlying zip.ErrFormat, got %v", err)
+	}
+}